@@ -0,0 +1,130 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/accountproof"
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/spf13/cobra"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+var accountProofCmd = &cobra.Command{
+	Use:   "proof",
+	Short: "Build a proof of an account state",
+	Long: `This command builds a merkle proof of the state of an account at a masterchain block:
+the balance and the last transaction lt and hash, and with --with-account also the hashes
+of the code and the data of the account. Both masterchain and basechain accounts are supported.
+
+The proof is a cell with the layout
+    account_state_proof#_ mc_block_proof:^Cell state_proof:^Cell
+        shard_block_proof:(Maybe ^Cell) account:(Maybe ^Cell) = AccountStateProof;
+where mc_block_proof is checked against the masterchain block hash, shard_block_proof
+is present for basechain accounts and state_proof leads from the state hash of the block
+to the ShardAccount of the address.
+
+Example:
+    trustless-bridge-cli account proof -a EQ... -s 706883 --with-account`,
+	RunE: runAccountProof,
+}
+
+func init() {
+	accountCmd.AddCommand(accountProofCmd)
+	accountProofCmd.Flags().StringP("address", "a", "", "Address of the account")
+	accountProofCmd.Flags().Uint32P("seqno", "s", 0, "Seqno of the masterchain block, the last block if not set")
+	accountProofCmd.Flags().Bool("with-account", false, "Keep the account cell with the code and data hashes in the proof")
+	accountProofCmd.Flags().StringP("output-format", "f", "hex", "Output format: bin, hex")
+	_ = accountProofCmd.MarkFlagRequired("address")
+}
+
+func runAccountProof(cmd *cobra.Command, args []string) error {
+	addrStr, err := cmd.Flags().GetString("address")
+	if err != nil {
+		return fmt.Errorf("failed to get address: %w", err)
+	}
+	seqno, err := cmd.Flags().GetUint32("seqno")
+	if err != nil {
+		return fmt.Errorf("failed to get seqno: %w", err)
+	}
+	withAccount, err := cmd.Flags().GetBool("with-account")
+	if err != nil {
+		return fmt.Errorf("failed to get with-account: %w", err)
+	}
+	outputFormat, err := cmd.Flags().GetString("output-format")
+	if err != nil {
+		return fmt.Errorf("failed to get output format: %w", err)
+	}
+	addr, err := address.ParseAddr(addrStr)
+	if err != nil {
+		return inputError("failed to parse address: %w", err)
+	}
+
+	ctx := context.Background()
+	if !cmd.Flags().Changed("seqno") {
+		master, err := tonClient.API.CurrentMasterchainInfo(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get masterchain info: %w", err)
+		}
+		seqno = master.SeqNo
+	}
+	mcBlock, mcBlockBOC, err := blockutils.FetchMasterchainBlockBOC(ctx, tonClient, seqno)
+	if err != nil {
+		return fmt.Errorf("failed to fetch masterchain block: %w", err)
+	}
+
+	proof, err := accountproof.Build(ctx, tonClient, mcBlock, mcBlockBOC, addr, withAccount)
+	if err != nil {
+		return fmt.Errorf("failed to build account proof: %w", err)
+	}
+
+	setResult("seqno", mcBlock.SeqNo)
+	setResult("balance", proof.Balance.Nano().String())
+	setResult("last_tx_lt", proof.ShardAccount.LastTransLT)
+	setResult("last_tx_hash", hex.EncodeToString(proof.ShardAccount.LastTransHash))
+	var codeHash, dataHash []byte
+	if proof.State != nil {
+		setResult("status", string(proof.State.Status))
+		if proof.State.Status == tlb.AccountStatusActive && proof.State.StateInit != nil {
+			if code := proof.State.StateInit.Code; code != nil {
+				codeHash = code.Hash()
+				setResult("code_hash", hex.EncodeToString(codeHash))
+			}
+			if data := proof.State.StateInit.Data; data != nil {
+				dataHash = data.Hash()
+				setResult("data_hash", hex.EncodeToString(dataHash))
+			}
+		}
+	}
+
+	// in bin format the proof is the only output
+	if outputFormat != "bin" {
+		printf("Block: %d\n", mcBlock.SeqNo)
+		printf("Balance: %s TON\n", proof.Balance.String())
+		printf("Last transaction: %d:%x\n", proof.ShardAccount.LastTransLT, proof.ShardAccount.LastTransHash)
+		if codeHash != nil {
+			printf("Code hash: %x\n", codeHash)
+		}
+		if dataHash != nil {
+			printf("Data hash: %x\n", dataHash)
+		}
+	}
+	writeBOC("boc", outputFormat, proof.ToCell().ToBOC())
+	return nil
+}
@@ -21,9 +21,10 @@ import (
 var blockCmd = &cobra.Command{
 	Use:   "block",
 	Short: "Utilities for working with blocks",
-	Run: func(cmd *cobra.Command, args []string) {
-		cmd.Help()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
 	},
+	Annotations: map[string]string{offlineAnnotation: "true"},
 }
 
 func init() {
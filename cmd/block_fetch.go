@@ -16,9 +16,9 @@ package cmd
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 )
@@ -26,7 +26,7 @@ import (
 var blockFetchCmd = &cobra.Command{
 	Use:   "fetch",
 	Short: "Fetch a block using its seqno",
-	Run:   runBlockFetch,
+	RunE:  runBlockFetch,
 }
 
 func init() {
@@ -37,51 +37,47 @@ func init() {
 	blockFetchCmd.MarkFlagRequired("seqno")
 }
 
-func runBlockFetch(cmd *cobra.Command, args []string) {
+func runBlockFetch(cmd *cobra.Command, args []string) error {
 	outputFormat, err := cmd.Flags().GetString("output-format")
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to get output format: %w", err)
 	}
 	workchain, err := cmd.Flags().GetInt32("workchain")
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to get workchain: %w", err)
 	}
 	seqno, err := cmd.Flags().GetUint32("seqno")
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to get seqno: %w", err)
 	}
 
-	blockIDExt, err := tonClient.API.LookupBlock(context.Background(), workchain, 0, seqno)
+	blockIDExt, err := tonClient.LookupBlock(context.Background(), workchain, 0, seqno)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to lookup block: %w", err)
 	}
+	setResult("workchain", blockIDExt.Workchain)
+	setResult("seqno", blockIDExt.SeqNo)
+	setResult("root_hash", hex.EncodeToString(blockIDExt.RootHash))
+	setResult("file_hash", hex.EncodeToString(blockIDExt.FileHash))
 
-	switch outputFormat {
-	case "json":
-		block, err := tonClient.API.GetBlockData(context.Background(), blockIDExt)
+	if outputFormat == "json" {
+		block, err := tonClient.GetBlockData(context.Background(), blockIDExt)
 		if err != nil {
-			panic(err)
+			return fmt.Errorf("failed to get block data: %w", err)
 		}
 		blockJSON, err := json.MarshalIndent(block, "", "  ")
 		if err != nil {
-			panic(err)
+			return fmt.Errorf("failed to marshal block: %w", err)
 		}
-		fmt.Printf("%s\n", blockJSON)
-
-	case "hex":
-		blockBOC, err := tonClient.GetBlockBOC(context.Background(), blockIDExt)
-		if err != nil {
-			panic(err)
-		}
-		fmt.Printf("%x\n", blockBOC)
+		setResult("block", json.RawMessage(blockJSON))
+		printf("%s\n", blockJSON)
+		return nil
+	}
 
-	case "bin":
-		fallthrough
-	default:
-		blockBOC, err := tonClient.GetBlockBOC(context.Background(), blockIDExt)
-		if err != nil {
-			panic(err)
-		}
-		os.Stdout.Write(blockBOC)
+	blockBOC, err := tonClient.GetBlockBOC(context.Background(), blockIDExt)
+	if err != nil {
+		return fmt.Errorf("failed to get block BOC: %w", err)
 	}
+	writeBOC("boc", outputFormat, blockBOC)
+	return nil
 }
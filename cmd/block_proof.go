@@ -17,7 +17,6 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/xssnick/tonutils-go/ton"
@@ -27,7 +26,7 @@ import (
 var blockProofCmd = &cobra.Command{
 	Use:   "proof",
 	Short: "Generate a proof for a block (currently works only with blocks from the masterchain)",
-	Run:   runBlockProof,
+	RunE:  runBlockProof,
 }
 
 func init() {
@@ -39,40 +38,39 @@ func init() {
 	blockProofCmd.MarkFlagRequired("to-seqno")
 }
 
-func runBlockProof(cmd *cobra.Command, args []string) {
+func runBlockProof(cmd *cobra.Command, args []string) error {
 	outputFormat, err := cmd.Flags().GetString("output-format")
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to get output format: %w", err)
 	}
 	fromSeqno, err := cmd.Flags().GetUint32("from-seqno")
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to get from seqno: %w", err)
 	}
 	toSeqno, err := cmd.Flags().GetUint32("to-seqno")
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to get to seqno: %w", err)
 	}
 	toWorkchain := int32(-1)
 	fromWorkchain := int32(-1)
 
-	fromBlockIDExt, err := tonClient.API.LookupBlock(
+	fromBlockIDExt, err := tonClient.LookupBlock(
 		context.Background(),
 		fromWorkchain,
 		0,
 		fromSeqno,
 	)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to lookup block %d: %w", fromSeqno, err)
 	}
-	toBlockIDExt, err := tonClient.API.LookupBlock(
+	toBlockIDExt, err := tonClient.LookupBlock(
 		context.Background(),
 		toWorkchain,
 		0,
 		toSeqno,
 	)
-
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to lookup block %d: %w", toSeqno, err)
 	}
 
 	blockProof, err := tonClient.API.GetBlockProof(
@@ -81,7 +79,7 @@ func runBlockProof(cmd *cobra.Command, args []string) {
 		toBlockIDExt,
 	)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to get block proof: %w", err)
 	}
 
 	var result *cell.Cell
@@ -90,19 +88,17 @@ func runBlockProof(cmd *cobra.Command, args []string) {
 		if back, ok := step.(ton.BlockLinkBackward); ok {
 			boc, err := cell.FromBOC(back.Proof)
 			if err != nil {
-				panic(err)
+				return fmt.Errorf("failed to parse proof BOC: %w", err)
 			}
 			result = boc
 		}
 	}
-
-	switch outputFormat {
-	case "hex":
-		fmt.Printf("%x\n", result.ToBOC())
-
-	case "bin":
-		fallthrough
-	default:
-		os.Stdout.Write(result.ToBOC())
+	if result == nil {
+		return fmt.Errorf("%w: no backward link from block %d to block %d", ton.ErrNoProof, fromSeqno, toSeqno)
 	}
+
+	setResult("from_seqno", fromSeqno)
+	setResult("to_seqno", toSeqno)
+	writeBOC("boc", outputFormat, result.ToBOC())
+	return nil
 }
@@ -0,0 +1,108 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/spf13/cobra"
+)
+
+var blockProofConfigCmd = &cobra.Command{
+	Use:   "proof-config",
+	Short: "Build a proof of config params of a key block",
+	Long: `This command builds a merkle proof of a key block that keeps the given config params,
+for example 32 (previous validators), 34 (current validators), 36 (next validators)
+or the gas and message forwarding prices. The proof has the same form as the proof
+of config param 34 built by "block prune -e".
+
+The key block is read from a BOC file with -i, or fetched from the network with -s:
+    trustless-bridge-cli block proof-config -i key_block.boc --params 34,36
+    trustless-bridge-cli block proof-config -s 706883 --params 20,21,24,25`,
+	RunE:        runBlockProofConfig,
+	Annotations: map[string]string{offlineAnnotation: "true"},
+}
+
+func init() {
+	blockCmd.AddCommand(blockProofConfigCmd)
+	blockProofConfigCmd.Flags().StringP("input-file", "i", "", "Input file with the key block BOC")
+	blockProofConfigCmd.Flags().Uint32P("seqno", "s", 0, "Seqno of the masterchain key block to fetch")
+	blockProofConfigCmd.Flags().UintSlice("params", []uint{34}, "Config params to keep in the proof")
+	blockProofConfigCmd.Flags().StringP("output-format", "f", "bin", "Output format: bin, hex")
+	blockProofConfigCmd.MarkFlagsOneRequired("input-file", "seqno")
+	blockProofConfigCmd.MarkFlagsMutuallyExclusive("input-file", "seqno")
+}
+
+func runBlockProofConfig(cmd *cobra.Command, args []string) error {
+	inputFile, err := cmd.Flags().GetString("input-file")
+	if err != nil {
+		return fmt.Errorf("failed to get input file: %w", err)
+	}
+	seqno, err := cmd.Flags().GetUint32("seqno")
+	if err != nil {
+		return fmt.Errorf("failed to get seqno: %w", err)
+	}
+	paramsFlag, err := cmd.Flags().GetUintSlice("params")
+	if err != nil {
+		return fmt.Errorf("failed to get params: %w", err)
+	}
+	outputFormat, err := cmd.Flags().GetString("output-format")
+	if err != nil {
+		return fmt.Errorf("failed to get output format: %w", err)
+	}
+
+	var params []uint32
+	for _, p := range paramsFlag {
+		if uint(uint32(p)) != p {
+			return inputError("invalid config param: %d", p)
+		}
+		params = append(params, uint32(p))
+	}
+	slices.Sort(params)
+	params = slices.Compact(params)
+	if len(params) == 0 {
+		return inputError("no config params to prove")
+	}
+
+	var blockBOC []byte
+	if inputFile != "" {
+		blockBOC, err = os.ReadFile(inputFile)
+		if err != nil {
+			return inputError("failed to read input file: %w", err)
+		}
+	} else {
+		if err = connect(); err != nil {
+			return err
+		}
+		_, blockBOC, err = blockutils.FetchMasterchainBlockBOC(context.Background(), tonClient, seqno)
+		if err != nil {
+			return fmt.Errorf("failed to fetch masterchain block: %w", err)
+		}
+		setResult("seqno", seqno)
+	}
+
+	proof, err := blockutils.BuildConfigProof(blockBOC, params)
+	if err != nil {
+		return inputError("failed to build config proof: %w", err)
+	}
+
+	setResult("params", params)
+	writeBOC("boc", outputFormat, proof.ToBOC())
+	return nil
+}
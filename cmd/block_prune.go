@@ -24,10 +24,11 @@ import (
 )
 
 var blockPruneCmd = &cobra.Command{
-	Use:   "prune",
-	Short: "Prune a block to remove unnecessary data",
-	Long:  "This command returns either a pruned block with block info or with config param 34 if the provided block was a key block with the corresponding config.",
-	Run:   runBlockPrune,
+	Use:         "prune",
+	Short:       "Prune a block to remove unnecessary data",
+	Long:        "This command returns either a pruned block with block info or with config param 34 if the provided block was a key block with the corresponding config.",
+	RunE:        runBlockPrune,
+	Annotations: map[string]string{offlineAnnotation: "true"},
 }
 
 func init() {
@@ -38,45 +39,38 @@ func init() {
 	blockPruneCmd.MarkFlagRequired("input-file")
 }
 
-func runBlockPrune(cmd *cobra.Command, args []string) {
+func runBlockPrune(cmd *cobra.Command, args []string) error {
 	inputFile, err := cmd.Flags().GetString("input-file")
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to get input file: %w", err)
 	}
 	outputFormat, err := cmd.Flags().GetString("output-format")
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to get output format: %w", err)
 	}
 	asExotic, err := cmd.Flags().GetBool("as-exotic")
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to get as exotic: %w", err)
 	}
 
 	blockBOC, err := os.ReadFile(inputFile)
 	if err != nil {
-		panic(err)
+		return inputError("failed to read input file: %w", err)
 	}
 
 	var result *cell.Cell
 	if asExotic {
 		result, err = blockutils.BuildBlockProof(blockBOC)
 		if err != nil {
-			panic(err)
+			return inputError("failed to build block proof: %w", err)
 		}
 	} else {
 		result, err = blockutils.PruneBlock(blockBOC)
 		if err != nil {
-			panic(err)
+			return inputError("failed to prune block: %w", err)
 		}
 	}
 
-	switch outputFormat {
-	case "hex":
-		fmt.Printf("%x\n", result.ToBOC())
-
-	case "bin":
-		fallthrough
-	default:
-		os.Stdout.Write(result.ToBOC())
-	}
+	writeBOC("boc", outputFormat, result.ToBOC())
+	return nil
 }
@@ -21,16 +21,13 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"math/big"
-	"os"
-	"sort"
+	"log"
+	"strconv"
+	"strings"
 
 	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
 	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
 	"github.com/spf13/cobra"
-	"github.com/xssnick/tonutils-go/adnl"
-	"github.com/xssnick/tonutils-go/tl"
-	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/ton"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
@@ -42,185 +39,120 @@ var blockSignaturesCmd = &cobra.Command{
 -	json: An array of objects like [{ "pubkey": "...", "signature": "..." }].
 -	bin: A BOC containing a TLB-encoded dictionary of type Dict<int256, 512> that maps each validator's public key (int256) to the 512-bit signature.
 -	hex: The same BOC as in bin mode, but presented as a hex-encoded string.`,
-	Run: runBlockSignatures,
+	RunE: runBlockSignatures,
 }
 
 func init() {
 	blockCmd.AddCommand(blockSignaturesCmd)
 	blockSignaturesCmd.Flags().Uint32P("seqno", "s", 0, "Block seqno")
+	blockSignaturesCmd.Flags().Int32P("workchain", "w", -1, "Workchain")
+	blockSignaturesCmd.Flags().String("shard", "8000000000000000", "Shard of a shardchain block, hex")
+	blockSignaturesCmd.Flags().Uint32("from", 0, "Seqno of the known masterchain block the proof chain starts from (default is the previous key block)")
 	blockSignaturesCmd.Flags().StringP("output-format", "f", "hex", "Output format: json, bin, hex")
+	addSignatureFlags(blockSignaturesCmd.Flags())
 	blockSignaturesCmd.MarkFlagRequired("seqno")
 }
 
-func runBlockSignatures(cmd *cobra.Command, args []string) {
+func runBlockSignatures(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
 	outputFormat, err := cmd.Flags().GetString("output-format")
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to get output format: %w", err)
 	}
 	seqno, err := cmd.Flags().GetUint32("seqno")
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to get seqno: %w", err)
 	}
-
-	signaturesMap, err := GetBlockSignatures(seqno, tonClient)
+	workchain, err := cmd.Flags().GetInt32("workchain")
 	if err != nil {
-		panic(err)
-	}
-
-	switch outputFormat {
-	case "json":
-		stringKeyMap := make(map[string]string)
-		for key, value := range signaturesMap {
-			stringKeyMap[hex.EncodeToString(key[:])] = hex.EncodeToString(value)
-		}
-
-		jsonData, err := json.MarshalIndent(stringKeyMap, "", "  ")
-		if err != nil {
-			panic(err)
-		}
-		fmt.Println(string(jsonData))
-	case "hex":
-		fmt.Printf("%x\n", SignaturesMapToDict(signaturesMap).AsCell().ToBOC())
-	case "bin":
-		fallthrough
-	default:
-		os.Stdout.Write(SignaturesMapToDict(signaturesMap).AsCell().ToBOC())
+		return fmt.Errorf("failed to get workchain: %w", err)
 	}
-}
-
-func GetBlockSignatures(seqno uint32, tonClient *tonclient.TonClient) (map[[32]byte][]byte, error) {
-	workchain := int32(-1)
-
-	blockIDExt, err := tonClient.API.LookupBlock(context.Background(), workchain, 0, seqno)
+	shardHex, err := cmd.Flags().GetString("shard")
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to get shard: %w", err)
 	}
-	block, err := tonClient.API.GetBlockData(context.Background(), blockIDExt)
-	if err != nil {
-		return nil, err
+	shard, err := strconv.ParseUint(strings.TrimPrefix(shardHex, "0x"), 16, 64)
+	if err != nil || shard == 0 {
+		return inputError("invalid shard: %s", shardHex)
 	}
 
-	prevBlockIDExt, err := tonClient.API.LookupBlock(
-		context.Background(),
-		block.BlockInfo.Shard.WorkchainID,
-		int64(block.BlockInfo.Shard.GetShardID()),
-		seqno-1,
-	)
-	if err != nil {
-		return nil, err
+	var known *ton.BlockIDExt
+	if cmd.Flags().Changed("from") {
+		fromSeqno, err := cmd.Flags().GetUint32("from")
+		if err != nil {
+			return fmt.Errorf("failed to get known block seqno: %w", err)
+		}
+		if known, err = tonClient.LookupBlock(ctx, -1, 0, fromSeqno); err != nil {
+			return fmt.Errorf("failed to lookup known block: %w", err)
+		}
 	}
 
-	prevKeyBlockIDExt, err := tonClient.API.LookupBlock(
-		context.Background(),
-		block.BlockInfo.Shard.WorkchainID,
-		int64(block.BlockInfo.Shard.GetShardID()),
-		block.BlockInfo.PrevKeyBlockSeqno,
-	)
-	if err != nil {
-		return nil, err
-	}
-	prevKeyBlock, err := tonClient.API.GetBlockData(context.Background(), prevKeyBlockIDExt)
-	if err != nil {
-		return nil, err
+	var target *ton.BlockIDExt
+	if workchain == -1 {
+		target, err = tonClient.LookupBlock(ctx, -1, 0, seqno)
+	} else {
+		target, err = tonClient.LookupBlock(ctx, workchain, int64(shard), seqno)
 	}
-
-	validatorsRootCell, err := prevKeyBlock.Extra.Custom.ConfigParams.Config.Params.LoadValueByIntKey(big.NewInt(34))
 	if err != nil {
-		return nil, err
-	}
-	var validatorSet tlb.ValidatorSetAny
-	if err = tlb.LoadFromCell(&validatorSet, validatorsRootCell.MustLoadRef()); err != nil {
-		return nil, err
+		return fmt.Errorf("failed to lookup block: %w", err)
 	}
+	setResult("workchain", target.Workchain)
+	setResult("seqno", seqno)
 
-	validators, _, _, err := blockutils.ExtractMainValidators(prevKeyBlock, tonClient)
-	if err != nil {
-		return nil, err
+	if workchain != -1 {
+		setResult("shard", fmt.Sprintf("%016x", uint64(target.Shard)))
+		if target, err = blockutils.FindCommittingMasterBlock(ctx, tonClient, target); err != nil {
+			return fmt.Errorf("failed to find masterchain block of the shard block: %w", err)
+		}
+		log.Printf("Attention: shard block %d is proven by the signatures of masterchain block %d", seqno, target.SeqNo)
 	}
 
-	blockProof, err := tonClient.GetBlockProofExt(
-		context.Background(),
-		prevBlockIDExt,
-		blockIDExt,
-	)
+	signatures, err := blockutils.FetchBlockSignatures(ctx, tonClient, known, target)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to get block signatures: %w", err)
 	}
-
-	signatures := extractSignatures(blockProof)
-
-	signaturesMap, err := mapValidatorsToSignatures(validators, signatures)
+	signaturesMap, err := selectSignatures(signatures)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to select block signatures: %w", err)
 	}
+	setResult("masterchain_seqno", signatures.Block.SeqNo)
+	setResult("catchain_seqno", signatures.CatchainSeqno)
+	setResult("validator_set_hash", signatures.ValidatorSetHash)
 
-	return signaturesMap, nil
-}
-
-func extractSignatures(proof *ton.PartialBlockProof) []ton.Signature {
-	for i := len(proof.Steps) - 1; i >= 0; i-- {
-		if fwd, ok := proof.Steps[i].(ton.BlockLinkForward); ok {
-			return fwd.SignatureSet.Signatures
+	if outputFormat == "json" {
+		stringKeyMap := make(map[string]string)
+		for key, value := range signaturesMap {
+			stringKeyMap[hex.EncodeToString(key[:])] = hex.EncodeToString(value)
 		}
-	}
-	return nil
-}
+		setResult("signatures", stringKeyMap)
 
-func mapValidatorsToSignatures(
-	validators []*tlb.ValidatorAddr,
-	signatures []ton.Signature,
-) (map[[32]byte][]byte, error) {
-	var totalWeight uint64
-	validatorsMap := make(map[string]*tlb.ValidatorAddr)
-
-	for _, validator := range validators {
-		kid, err := tl.Hash(adnl.PublicKeyED25519{Key: validator.PublicKey.Key})
+		jsonData, err := json.MarshalIndent(stringKeyMap, "", "  ")
 		if err != nil {
-			return nil, err
-		}
-		validatorsMap[string(kid)] = validator
-		totalWeight += validator.Weight
-	}
-
-	type signerInfo struct {
-		key       [32]byte
-		weight    uint64
-		signature []byte
-	}
-	var signers []signerInfo
-
-	for _, s := range signatures {
-		if v, ok := validatorsMap[string(s.NodeIDShort)]; ok {
-			var key [32]byte
-			copy(key[:], v.PublicKey.Key)
-			signers = append(signers, signerInfo{
-				key:       key,
-				weight:    v.Weight,
-				signature: s.Signature,
-			})
+			return fmt.Errorf("failed to marshal signatures: %w", err)
 		}
+		printf("%s\n", jsonData)
+		return nil
 	}
 
-	sort.Slice(signers, func(i, j int) bool {
-		return signers[i].weight > signers[j].weight
-	})
+	writeBOC("boc", outputFormat, SignaturesMapToDict(signaturesMap).AsCell().ToBOC())
+	return nil
+}
 
-	var signedWeight uint64
-	minSignatures := make(map[[32]byte][]byte)
+// GetBlockSignatures returns the signatures of the masterchain block
+// selected with the strategy of the signature flags.
+func GetBlockSignatures(seqno uint32, tonClient *tonclient.TonClient) (map[[32]byte][]byte, error) {
+	ctx := context.Background()
 
-	for _, signer := range signers {
-		minSignatures[signer.key] = signer.signature
-		signedWeight += signer.weight
-		if 3*signedWeight > 2*totalWeight {
-			break
-		}
+	target, err := tonClient.LookupBlock(ctx, -1, 0, seqno)
+	if err != nil {
+		return nil, err
 	}
-
-	if 3*signedWeight <= 2*totalWeight {
-		return nil, fmt.Errorf("insufficient signed weight (%d/%d)", 3*signedWeight, 2*totalWeight)
+	signatures, err := blockutils.FetchBlockSignatures(ctx, tonClient, nil, target)
+	if err != nil {
+		return nil, err
 	}
-
-	return minSignatures, nil
+	return selectSignatures(signatures)
 }
 
 func SignaturesMapToDict(signaturesMap map[[32]byte][]byte) *cell.Dictionary {
@@ -15,17 +15,153 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/liteclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
 	"github.com/spf13/cobra"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
 var deployCmd = &cobra.Command{
 	Use:   "deploy",
 	Short: "Deploy a contract",
-	Run: func(cmd *cobra.Command, args []string) {
-		cmd.Help()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
 	},
+	Annotations: map[string]string{offlineAnnotation: "true"},
 }
 
 func init() {
 	rootCmd.AddCommand(deployCmd)
+	addSendOptionFlags(deployCmd)
+	deployCmd.PersistentFlags().String("manifest", "deployment.json", "Path to the manifest file with the deployed contracts")
+}
+
+// trustedInitData builds the initial data of the LiteClient from the validators of the trusted
+// key block of the source network. If the block is not a key block, the previous key block is used,
+// its seqno is returned.
+func trustedInitData(
+	ctx context.Context,
+	sourceTonClient *tonclient.TonClient,
+	trustedBlockSeqno uint32,
+) (uint32, *liteclient.InitData, error) {
+	trustedBlock, err := blockutils.FetchMasterchainBlock(ctx, sourceTonClient, trustedBlockSeqno)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to fetch masterchain block: %w", err)
+	}
+
+	if !trustedBlock.BlockInfo.KeyBlock {
+		log.Printf("given trusted block is not a key block: %v", trustedBlock.BlockInfo.SeqNo)
+		log.Printf("switch to last key block with seqno: %v", trustedBlock.BlockInfo.PrevKeyBlockSeqno)
+		trustedBlockSeqno = trustedBlock.BlockInfo.PrevKeyBlockSeqno
+
+		trustedBlock, err = blockutils.FetchMasterchainBlock(ctx, sourceTonClient, trustedBlockSeqno)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to fetch masterchain block: %w", err)
+		}
+	}
+
+	validators, totalMainWeight, epochHash, err := blockutils.ExtractMainValidators(trustedBlock, sourceTonClient)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to extract main validators: %w", err)
+	}
+
+	validatorDict := cell.NewDict(256)
+
+	for _, validator := range validators {
+		validatorDict.Set(
+			cell.BeginCell().MustStoreSlice(validator.PublicKey.Key, 256).EndCell(),
+			cell.BeginCell().MustStoreUInt(validator.Weight, 64).EndCell(),
+		)
+	}
+
+	return trustedBlockSeqno, &liteclient.InitData{
+		EpochHash:             epochHash,
+		ValidatorsTotalWeight: totalMainWeight,
+		ValidatorDict:         validatorDict,
+	}, nil
+}
+
+// contractPlan is a contract to deploy with its account on the target network.
+type contractPlan struct {
+	name     string
+	state    *tlb.StateInit
+	stateBOC []byte
+	addr     *address.Address
+	account  *tlb.Account
+}
+
+func newContractPlan(name string, wc int8, code, data *cell.Cell) (*contractPlan, error) {
+	state, addr, err := tonclient.ContractStateInit(byte(wc), code, data)
+	if err != nil {
+		return nil, err
+	}
+	stateCell, err := tlb.ToCell(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize state init of %s: %w", name, err)
+	}
+	return &contractPlan{name: name, state: state, stateBOC: stateCell.ToBOC(), addr: addr}, nil
+}
+
+// loadAccount gets the account at the address of the contract in the latest masterchain block.
+func (c *contractPlan) loadAccount(ctx context.Context) error {
+	master, err := tonClient.API.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	if c.account, err = tonClient.API.GetAccount(ctx, master, c.addr); err != nil {
+		return fmt.Errorf("failed to get account of %s: %w", c.name, err)
+	}
+	return nil
+}
+
+func (c *contractPlan) status() tlb.AccountStatus {
+	if c.account == nil || c.account.State == nil {
+		return tlb.AccountStatusNonExist
+	}
+	return c.account.State.Status
+}
+
+func (c *contractPlan) active() bool {
+	return c.account != nil && c.account.IsActive && c.status() == tlb.AccountStatusActive
+}
+
+// deployed reports whether the contract is active with the planned code.
+func (c *contractPlan) deployed() bool {
+	return c.active() && c.account.Code != nil && bytes.Equal(c.account.Code.Hash(), c.state.Code.Hash())
+}
+
+// ensureDeployed deploys the contract with deploy unless it is already active at its address.
+// It reports whether the contract was deployed now.
+func ensureDeployed(
+	ctx context.Context,
+	c *contractPlan,
+	deploy func(ctx context.Context) (*address.Address, error),
+) (bool, error) {
+	if err := c.loadAccount(ctx); err != nil {
+		return false, err
+	}
+	if c.deployed() {
+		log.Printf("Attention: %s is already deployed at %s, skipping", c.name, c.addr)
+		return false, nil
+	}
+	if c.active() {
+		return false, fmt.Errorf("a contract with a different code is already active at the %s address %s", c.name, c.addr)
+	}
+
+	addr, err := deploy(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !addr.Equals(c.addr) {
+		return false, fmt.Errorf("%s was deployed to %s instead of the planned address %s", c.name, addr, c.addr)
+	}
+	return true, nil
 }
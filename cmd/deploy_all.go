@@ -16,22 +16,25 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
-	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
-	"github.com/rsquad/trustless-bridge-cli/internal/liteclient"
 	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
-	"github.com/rsquad/trustless-bridge-cli/internal/txchecker"
 	"github.com/spf13/cobra"
-	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
 var deployAllCmd = &cobra.Command{
 	Use:   "all",
 	Short: "Deploy system contracts",
-	Long: `This command deploys system contracts to the opposite network.
+	Long: `This command deploys system contracts to the target network, initialized with a key block
+of the source network.
 If the network is specified as testnet, the system will fetch a block from fastnet
-and deploy the system to testnet using the block from fastnet, and vice versa.`,
+and deploy the system to testnet using the block from fastnet, and vice versa.
+
+Contracts that are already active at their addresses are skipped, so a failed deploy
+can be resumed by running the command again. The deployed contracts are recorded
+in the manifest, and the trusted block and the workchain are taken from it
+if the flags are not set.`,
 	RunE: runDeployAll,
 }
 
@@ -39,94 +42,41 @@ func init() {
 	deployCmd.AddCommand(deployAllCmd)
 	deployAllCmd.Flags().Uint32P("trusted-block-seqno", "s", 0, "Trusted block seqno")
 	deployAllCmd.Flags().Int8P("workchain", "w", 0, "Workchain")
-	deployAllCmd.MarkFlagRequired("trusted-block-seqno")
-	deployAllCmd.MarkFlagRequired("workchain")
 }
 
 func runDeployAll(cmd *cobra.Command, args []string) error {
-	trustedBlockSeqno, err := cmd.Flags().GetUint32("trusted-block-seqno")
-	if err != nil {
-		return fmt.Errorf("failed to get trusted block seqno: %w", err)
-	}
-	network, err := cmd.Flags().GetString("network")
-	if err != nil {
-		network = "testnet"
-	}
-	oppositeNetwork := "testnet"
-	if network == "testnet" {
-		oppositeNetwork = "fastnet"
-	}
-	oppositeTonClient, err := tonclient.NewTonClientNetwork(oppositeNetwork)
+	manifest, err := openDeployment(cmd)
 	if err != nil {
-		return fmt.Errorf("failed to create TonClient: %w", err)
+		return err
 	}
-	wc, err := cmd.Flags().GetInt8("workchain")
-	if err != nil {
-		return fmt.Errorf("failed to get workchain: %w", err)
-	}
-	wcb := byte(wc)
-	if wc != 0 {
-		wcb = 255
-	}
-
-	fmt.Printf("Attention: You are deploying contracts to the %s network with block %d from %s network\n", network, trustedBlockSeqno, oppositeNetwork)
-
-	trustedBlock, err := blockutils.FetchMasterchainBlock(context.Background(), oppositeTonClient, trustedBlockSeqno)
+	trustedBlockSeqno, err := trustedBlockSeqnoFlag(cmd, manifest)
 	if err != nil {
-		return fmt.Errorf("failed to fetch masterchain block: %w", err)
+		return err
 	}
-
-	if !trustedBlock.BlockInfo.KeyBlock {
-		fmt.Printf("given trusted block is not a key block: %v\n", trustedBlock.BlockInfo.SeqNo)
-		fmt.Printf("switch to last key block with seqno: %v\n", trustedBlock.BlockInfo.PrevKeyBlockSeqno)
-		trustedBlockSeqno = trustedBlock.BlockInfo.PrevKeyBlockSeqno
-
-		trustedBlock, err = blockutils.FetchMasterchainBlock(context.Background(), oppositeTonClient, trustedBlockSeqno)
-		if err != nil {
-			return fmt.Errorf("failed to fetch masterchain block: %w", err)
-		}
-	}
-
-	validators, totalMainWeight, epochHash, err := blockutils.ExtractMainValidators(trustedBlock, oppositeTonClient)
+	wc, err := workchainFlag(cmd, manifest)
 	if err != nil {
-		return fmt.Errorf("failed to extract main validators: %w", err)
+		return err
 	}
 
-	validatorDict := cell.NewDict(256)
-
-	for _, validator := range validators {
-		validatorDict.Set(
-			cell.BeginCell().MustStoreSlice(validator.PublicKey.Key, 256).EndCell(),
-			cell.BeginCell().MustStoreUInt(validator.Weight, 64).EndCell(),
-		)
-	}
-
-	liteClientAddr, err := liteclient.DeployLiteClient(
-		context.Background(),
-		tonClient,
-		wcb,
-		&liteclient.InitData{
-			EpochHash:             epochHash,
-			ValidatorsTotalWeight: totalMainWeight,
-			ValidatorDict:         validatorDict,
-		},
-	)
-	if err != nil {
+	ctx := context.Background()
+	liteClient, err := deployLiteClientStep(ctx, manifest, trustedBlockSeqno, wc)
+	dryRun := errors.Is(err, tonclient.ErrDryRun)
+	if err != nil && !dryRun {
 		return fmt.Errorf("failed to deploy lite client: %w", err)
 	}
 
-	txCheckerAddr, err := txchecker.DeployTxChecker(
-		context.Background(),
-		tonClient,
-		wcb,
-		&txchecker.InitData{LiteClientAddr: liteClientAddr},
-	)
-	if err != nil {
+	txChecker, err := deployTxCheckerStep(ctx, manifest, liteClient.addr, wc)
+	if dryRunDone(err) {
+		dryRun = true
+	} else if err != nil {
 		return fmt.Errorf("failed to deploy tx checker: %w", err)
 	}
 
-	fmt.Printf("LiteClient successfully deployed: %v\n", liteClientAddr)
-	fmt.Printf("TxChecker successfully deployed: %v\n", txCheckerAddr)
-
+	if dryRun {
+		printf("LiteClient address: %v\n", liteClient.addr)
+		printf("TxChecker address: %v\n", txChecker.addr)
+		setResult("lite_client", liteClient.addr.String())
+		setResult("tx_checker", txChecker.addr.String())
+	}
 	return nil
 }
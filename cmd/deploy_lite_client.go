@@ -0,0 +1,125 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"log"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/liteclient"
+	"github.com/spf13/cobra"
+	"github.com/xssnick/tonutils-go/address"
+)
+
+var deployLiteClientCmd = &cobra.Command{
+	Use:   "lite-client",
+	Short: "Deploy the LiteClient contract",
+	Long: `This command deploys the LiteClient to the target network, initialized with a key block
+of the source network, and records it in the manifest. Nothing is sent if the LiteClient
+is already active at its address.
+
+The trusted block and the workchain are taken from the manifest if the flags are not set.`,
+	RunE: runDeployLiteClient,
+}
+
+func init() {
+	deployCmd.AddCommand(deployLiteClientCmd)
+	deployLiteClientCmd.Flags().Uint32P("trusted-block-seqno", "s", 0, "Trusted block seqno")
+	deployLiteClientCmd.Flags().Int8P("workchain", "w", 0, "Workchain")
+}
+
+func runDeployLiteClient(cmd *cobra.Command, args []string) error {
+	manifest, err := openDeployment(cmd)
+	if err != nil {
+		return err
+	}
+	trustedBlockSeqno, err := trustedBlockSeqnoFlag(cmd, manifest)
+	if err != nil {
+		return err
+	}
+	wc, err := workchainFlag(cmd, manifest)
+	if err != nil {
+		return err
+	}
+
+	liteClient, err := deployLiteClientStep(context.Background(), manifest, trustedBlockSeqno, wc)
+	if dryRunDone(err) {
+		printf("LiteClient address: %v\n", liteClient.addr)
+		setResult("lite_client", liteClient.addr.String())
+		return nil
+	}
+	return err
+}
+
+// deployLiteClientStep deploys the LiteClient unless it is already active and records it in the manifest.
+func deployLiteClientStep(
+	ctx context.Context,
+	manifest *deployment,
+	trustedBlockSeqno uint32,
+	wc int8,
+) (*contractPlan, error) {
+	code, err := liteclient.LoadCode()
+	if err != nil {
+		return nil, err
+	}
+	sourceTonClient, sourceName, err := connectSource()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Attention: You are deploying the LiteClient to the %s network with block %d from %s network", network, trustedBlockSeqno, sourceName)
+
+	trustedBlockSeqno, initData, err := trustedInitData(ctx, sourceTonClient, trustedBlockSeqno)
+	if err != nil {
+		return nil, err
+	}
+	liteClient, err := newContractPlan("LiteClient", wc, code, liteclient.InitDataToCell(initData))
+	if err != nil {
+		return nil, err
+	}
+
+	deployed, err := ensureDeployed(ctx, liteClient, func(ctx context.Context) (*address.Address, error) {
+		return liteclient.DeployLiteClient(ctx, tonClient, byte(wc), initData)
+	})
+	if err != nil {
+		return liteClient, err
+	}
+
+	if manifest.LiteClient != nil && manifest.LiteClient.Address != liteClient.addr.String() {
+		log.Printf("Attention: LiteClient %s in the manifest is replaced with %s", manifest.LiteClient.Address, liteClient.addr)
+		manifest.TxChecker = nil
+	}
+	if manifest.LiteClient == nil || manifest.LiteClient.Address != liteClient.addr.String() {
+		manifest.LiteClient = newManifestRecord(liteClient)
+	}
+	manifest.SourceNetwork = sourceName
+	manifest.Workchain = wc
+	manifest.TrustedBlockSeqno = trustedBlockSeqno
+	manifest.EpochHash = hex.EncodeToString(initData.EpochHash)
+	if err = manifest.save(); err != nil {
+		return liteClient, err
+	}
+
+	if deployed {
+		printf("LiteClient successfully deployed: %v\n", liteClient.addr)
+	} else {
+		printf("LiteClient already deployed: %v\n", liteClient.addr)
+	}
+	setResult("trusted_block_seqno", trustedBlockSeqno)
+	setResult("lite_client", liteClient.addr.String())
+	setResult("manifest", manifest.path)
+	return liteClient, nil
+}
@@ -0,0 +1,157 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// deployManifest records a deployment of the bridge contracts to a network,
+// so that a partially failed deploy can be resumed and other tools can find the contracts.
+type deployManifest struct {
+	Network           string          `json:"network"`
+	SourceNetwork     string          `json:"source_network,omitempty"`
+	Workchain         int8            `json:"workchain"`
+	TrustedBlockSeqno uint32          `json:"trusted_block_seqno,omitempty"`
+	EpochHash         string          `json:"epoch_hash,omitempty"`
+	LiteClient        *manifestRecord `json:"lite_client,omitempty"`
+	TxChecker         *manifestRecord `json:"tx_checker,omitempty"`
+}
+
+type manifestRecord struct {
+	Address    string    `json:"address"`
+	CodeHash   string    `json:"code_hash"`
+	DataHash   string    `json:"data_hash"`
+	DeployedAt time.Time `json:"deployed_at"`
+}
+
+func newManifestRecord(c *contractPlan) *manifestRecord {
+	return &manifestRecord{
+		Address:    c.addr.String(),
+		CodeHash:   hex.EncodeToString(c.state.Code.Hash()),
+		DataHash:   hex.EncodeToString(c.state.Data.Hash()),
+		DeployedAt: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// loadDeployManifest reads the manifest of the current network. A missing file is an empty manifest,
+// a manifest of another network is an error.
+func loadDeployManifest(path string) (*deployManifest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &deployManifest{Network: network}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest deployManifest
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return nil, inputError("failed to parse manifest %s: %w", path, err)
+	}
+	if manifest.Network != network {
+		return nil, inputError("manifest %s is for the %s network, not %s, use --manifest", path, manifest.Network, network)
+	}
+	return &manifest, nil
+}
+
+func saveDeployManifest(path string, manifest *deployManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// deployment is the manifest of a deploy command. A dry run does not change the manifest file.
+type deployment struct {
+	*deployManifest
+	path   string
+	dryRun bool
+}
+
+// openDeployment loads the manifest of the --manifest flag.
+func openDeployment(cmd *cobra.Command) (*deployment, error) {
+	path, err := cmd.Flags().GetString("manifest")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest: %w", err)
+	}
+	if path == "" {
+		return nil, inputError("manifest path must not be empty")
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dry run: %w", err)
+	}
+	manifest, err := loadDeployManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	return &deployment{deployManifest: manifest, path: path, dryRun: dryRun}, nil
+}
+
+func (d *deployment) save() error {
+	if d.dryRun {
+		return nil
+	}
+	return saveDeployManifest(d.path, d.deployManifest)
+}
+
+// trustedBlockSeqnoFlag returns --trusted-block-seqno, or the trusted block of the manifest if it is not set.
+func trustedBlockSeqnoFlag(cmd *cobra.Command, manifest *deployment) (uint32, error) {
+	if !cmd.Flags().Changed("trusted-block-seqno") {
+		if manifest.TrustedBlockSeqno == 0 {
+			return 0, inputError("--trusted-block-seqno is required, the manifest has no trusted block")
+		}
+		log.Printf("Using trusted block %d from the manifest", manifest.TrustedBlockSeqno)
+		return manifest.TrustedBlockSeqno, nil
+	}
+	seqno, err := cmd.Flags().GetUint32("trusted-block-seqno")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get trusted block seqno: %w", err)
+	}
+	return seqno, nil
+}
+
+// workchainFlag returns --workchain, or the workchain of the manifest if it is not set.
+func workchainFlag(cmd *cobra.Command, manifest *deployment) (int8, error) {
+	if !cmd.Flags().Changed("workchain") {
+		if manifest.LiteClient == nil && manifest.TxChecker == nil {
+			return 0, inputError("--workchain is required, the manifest has no deployed contracts")
+		}
+		return manifest.Workchain, nil
+	}
+	wc, err := cmd.Flags().GetInt8("workchain")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get workchain: %w", err)
+	}
+	if wc != 0 && wc != -1 {
+		return 0, inputError("workchain must be 0 or -1: %d", wc)
+	}
+	return wc, nil
+}
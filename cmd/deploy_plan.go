@@ -0,0 +1,134 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/liteclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/txchecker"
+	"github.com/spf13/cobra"
+)
+
+var deployPlanCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Compute the addresses of system contracts without deploying them",
+	Long: `This command builds the StateInit of the LiteClient and the TxChecker exactly like deploy all:
+the LiteClient is initialized with the validators of the trusted key block of the source network,
+and the TxChecker with the address of the LiteClient. It prints the addresses, the code hashes
+and the StateInit BOCs of the contracts without sending anything.
+
+The addresses depend only on the configured code, the trusted key block and the workchain,
+so they can be given to other services before the deploy. A warning is printed if a contract
+is already active at the address.`,
+	RunE: runDeployPlan,
+}
+
+func init() {
+	deployCmd.AddCommand(deployPlanCmd)
+	deployPlanCmd.Flags().Uint32P("trusted-block-seqno", "s", 0, "Trusted block seqno")
+	deployPlanCmd.Flags().Int8P("workchain", "w", 0, "Workchain")
+	deployPlanCmd.MarkFlagRequired("trusted-block-seqno")
+	deployPlanCmd.MarkFlagRequired("workchain")
+}
+
+func runDeployPlan(cmd *cobra.Command, args []string) error {
+	trustedBlockSeqno, err := cmd.Flags().GetUint32("trusted-block-seqno")
+	if err != nil {
+		return fmt.Errorf("failed to get trusted block seqno: %w", err)
+	}
+	wc, err := cmd.Flags().GetInt8("workchain")
+	if err != nil {
+		return fmt.Errorf("failed to get workchain: %w", err)
+	}
+	if wc != 0 && wc != -1 {
+		return inputError("workchain must be 0 or -1: %d", wc)
+	}
+	liteClientCode, err := liteclient.LoadCode()
+	if err != nil {
+		return err
+	}
+	txCheckerCode, err := txchecker.LoadCode()
+	if err != nil {
+		return err
+	}
+	sourceTonClient, sourceName, err := connectSource()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	trustedBlockSeqno, initData, err := trustedInitData(ctx, sourceTonClient, trustedBlockSeqno)
+	if err != nil {
+		return err
+	}
+
+	liteClient, err := newContractPlan("LiteClient", wc, liteClientCode, liteclient.InitDataToCell(initData))
+	if err != nil {
+		return err
+	}
+	txChecker, err := newContractPlan(
+		"TxChecker",
+		wc,
+		txCheckerCode,
+		txchecker.InitDataToCell(&txchecker.InitData{LiteClientAddr: liteClient.addr}),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range []*contractPlan{liteClient, txChecker} {
+		if err = c.loadAccount(ctx); err != nil {
+			return err
+		}
+	}
+
+	printf("Trusted key block: %d of %s network\n", trustedBlockSeqno, sourceName)
+	printf("Epoch hash: %x\n", initData.EpochHash)
+	setResult("trusted_block_seqno", trustedBlockSeqno)
+	setResult("epoch_hash", hex.EncodeToString(initData.EpochHash))
+	setResult("lite_client", reportContractPlan(liteClient))
+	setResult("tx_checker", reportContractPlan(txChecker))
+	return nil
+}
+
+// reportContractPlan prints the planned contract and warns if a contract is already active at its address.
+func reportContractPlan(c *contractPlan) map[string]any {
+	printf("%s:\n", c.name)
+	printf("  Address: %s\n", c.addr)
+	printf("  Raw address: %s\n", c.addr.StringRaw())
+	printf("  Code hash: %x\n", c.state.Code.Hash())
+	printf("  Data hash: %x\n", c.state.Data.Hash())
+	printf("  Status: %s\n", c.status())
+	printf("  StateInit: %x\n", c.stateBOC)
+
+	if c.deployed() {
+		log.Printf("Attention: %s is already deployed at %s", c.name, c.addr)
+	} else if c.active() {
+		log.Printf("Attention: A contract with a different code is already active at the %s address %s", c.name, c.addr)
+	}
+
+	return map[string]any{
+		"address":     c.addr.String(),
+		"raw_address": c.addr.StringRaw(),
+		"code_hash":   hex.EncodeToString(c.state.Code.Hash()),
+		"data_hash":   hex.EncodeToString(c.state.Data.Hash()),
+		"status":      string(c.status()),
+		"state_init":  hex.EncodeToString(c.stateBOC),
+	}
+}
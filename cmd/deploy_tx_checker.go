@@ -0,0 +1,123 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/txchecker"
+	"github.com/spf13/cobra"
+	"github.com/xssnick/tonutils-go/address"
+)
+
+var deployTxCheckerCmd = &cobra.Command{
+	Use:   "tx-checker",
+	Short: "Deploy the TxChecker contract",
+	Long: `This command deploys the TxChecker bound to a LiteClient to the target network
+and records it in the manifest. Nothing is sent if the TxChecker is already active
+at its address.
+
+The LiteClient and the workchain are taken from the manifest if the flags are not set.`,
+	RunE: runDeployTxChecker,
+}
+
+func init() {
+	deployCmd.AddCommand(deployTxCheckerCmd)
+	deployTxCheckerCmd.Flags().String("lite-client", "", "Address of the LiteClient contract")
+	deployTxCheckerCmd.Flags().Int8P("workchain", "w", 0, "Workchain")
+}
+
+func runDeployTxChecker(cmd *cobra.Command, args []string) error {
+	manifest, err := openDeployment(cmd)
+	if err != nil {
+		return err
+	}
+	liteClientStr, err := cmd.Flags().GetString("lite-client")
+	if err != nil {
+		return fmt.Errorf("failed to get lite client: %w", err)
+	}
+	switch {
+	case liteClientStr == "" && manifest.LiteClient == nil:
+		return inputError("--lite-client is required, the manifest has no LiteClient")
+	case liteClientStr == "":
+		liteClientStr = manifest.LiteClient.Address
+	case manifest.LiteClient != nil && manifest.LiteClient.Address != liteClientStr:
+		return inputError("LiteClient %s differs from %s in the manifest %s, use another --manifest",
+			liteClientStr, manifest.LiteClient.Address, manifest.path)
+	}
+	liteClientAddr, err := address.ParseAddr(liteClientStr)
+	if err != nil {
+		return inputError("failed to parse lite client address: %w", err)
+	}
+	wc, err := workchainFlag(cmd, manifest)
+	if err != nil {
+		return err
+	}
+
+	txChecker, err := deployTxCheckerStep(context.Background(), manifest, liteClientAddr, wc)
+	if dryRunDone(err) {
+		printf("TxChecker address: %v\n", txChecker.addr)
+		setResult("tx_checker", txChecker.addr.String())
+		return nil
+	}
+	return err
+}
+
+// deployTxCheckerStep deploys the TxChecker unless it is already active and records it in the manifest.
+func deployTxCheckerStep(
+	ctx context.Context,
+	manifest *deployment,
+	liteClientAddr *address.Address,
+	wc int8,
+) (*contractPlan, error) {
+	code, err := txchecker.LoadCode()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Attention: You are deploying the TxChecker of LiteClient %s to the %s network", liteClientAddr, network)
+
+	initData := &txchecker.InitData{LiteClientAddr: liteClientAddr}
+	txChecker, err := newContractPlan("TxChecker", wc, code, txchecker.InitDataToCell(initData))
+	if err != nil {
+		return nil, err
+	}
+
+	deployed, err := ensureDeployed(ctx, txChecker, func(ctx context.Context) (*address.Address, error) {
+		return txchecker.DeployTxChecker(ctx, tonClient, byte(wc), initData)
+	})
+	if err != nil {
+		return txChecker, err
+	}
+
+	if manifest.TxChecker == nil || manifest.TxChecker.Address != txChecker.addr.String() {
+		manifest.TxChecker = newManifestRecord(txChecker)
+	}
+	manifest.Workchain = wc
+	if err = manifest.save(); err != nil {
+		return txChecker, err
+	}
+
+	if deployed {
+		printf("TxChecker successfully deployed: %v\n", txChecker.addr)
+	} else {
+		printf("TxChecker already deployed: %v\n", txChecker.addr)
+	}
+	setResult("tx_checker", txChecker.addr.String())
+	setResult("manifest", manifest.path)
+	return txChecker, nil
+}
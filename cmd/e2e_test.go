@@ -0,0 +1,1466 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/batch"
+	"github.com/rsquad/trustless-bridge-cli/internal/blocktest"
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/fees"
+	"github.com/rsquad/trustless-bridge-cli/internal/jobs"
+	"github.com/rsquad/trustless-bridge-cli/internal/keystore"
+	"github.com/rsquad/trustless-bridge-cli/internal/liteclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/txchecker"
+	"github.com/rsquad/trustless-bridge-cli/internal/txutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
+	"github.com/spf13/viper"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const testLiteClientAddr = "EQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAM9c"
+
+func prepareBlockID(seqno uint32, rootHash []byte) *ton.BlockIDExt {
+	return &ton.BlockIDExt{
+		Workchain: -1,
+		Shard:     -9223372036854775808,
+		SeqNo:     seqno,
+		RootHash:  rootHash,
+		FileHash:  make([]byte, 32),
+	}
+}
+
+func prepareMasterchainInfo(fixtures *tonclient.Fixtures, seqno uint32) *ton.BlockIDExt {
+	last := prepareBlockID(seqno, make([]byte, 32))
+	prepareLastBlock(fixtures, last)
+	return last
+}
+
+// prepareLastBlock makes the block the last masterchain block.
+func prepareLastBlock(fixtures *tonclient.Fixtures, last *ton.BlockIDExt) {
+	err := fixtures.Add(ton.GetMasterchainInf{}, ton.MasterchainInfo{
+		Last:          last,
+		StateRootHash: make([]byte, 32),
+		Init:          &ton.ZeroStateIDExt{Workchain: -1, RootHash: make([]byte, 32), FileHash: make([]byte, 32)},
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// prepareConfigParams adds the config params of the masterchain block with its new state,
+// proven like liteservers prove them.
+func prepareConfigParams(fixtures *tonclient.Fixtures, block *blocktest.Block, params ...int32) {
+	blockProof, err := blockutils.BuildBlockProofWithState(block.BOC())
+	if err != nil {
+		panic(err)
+	}
+	sk := cell.CreateProofSkeleton()
+	sk.SetRecursive()
+	stateProof, err := block.NewState.CreateProof(sk)
+	if err != nil {
+		panic(err)
+	}
+	err = fixtures.Add(
+		ton.GetConfigParams{Mode: 0, BlockID: block.ID(), Params: params},
+		ton.ConfigAll{Mode: 0, ID: block.ID(), StateProof: blockProof, ConfigProof: stateProof},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// prepareMsgLimits returns config param 43 with the message size limits.
+func prepareMsgLimits(limits fees.MsgLimits) *cell.Cell {
+	return cell.BeginCell().
+		MustStoreUInt(0x01, 8).
+		MustStoreUInt(limits.MaxMsgBits, 32).
+		MustStoreUInt(limits.MaxMsgCells, 32).
+		MustStoreUInt(1000, 32).
+		MustStoreUInt(512, 16).
+		MustStoreUInt(limits.MaxExtMsgSize, 32).
+		MustStoreUInt(limits.MaxExtMsgDepth, 16).
+		EndCell()
+}
+
+func prepareGetMethod(fixtures *tonclient.Fixtures, block *ton.BlockIDExt, addr *address.Address, method string, result ...any) {
+	params, err := (&tlb.Stack{}).ToCell()
+	if err != nil {
+		panic(err)
+	}
+	var stack tlb.Stack
+	for i := len(result) - 1; i >= 0; i-- {
+		stack.Push(result[i])
+	}
+	stackCell, err := stack.ToCell()
+	if err != nil {
+		panic(err)
+	}
+
+	err = fixtures.Add(
+		&ton.RunSmcMethod{
+			Mode:     4,
+			ID:       block,
+			Account:  ton.AccountID{Workchain: addr.Workchain(), ID: addr.Data()},
+			MethodID: tlb.MethodNameHash(method),
+			Params:   params,
+		},
+		ton.RunMethodResult{Mode: 4, ID: block, ShardBlock: block, Result: stackCell},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func prepareValidatorDict() *cell.Dictionary {
+	dict := cell.NewDict(256)
+	dict.Set(
+		cell.BeginCell().MustStoreSlice(bytes.Repeat([]byte{0x11}, 32), 256).EndCell(),
+		cell.BeginCell().MustStoreUInt(100, 64).EndCell(),
+	)
+	return dict
+}
+
+// runWithFixtures executes the CLI with the liteservers of every network
+// replaced by the given fixtures and returns its standard output.
+func runWithFixtures(t *testing.T, fixtures map[string]*tonclient.Fixtures, args ...string) (string, error) {
+	dir := t.TempDir()
+	for network, f := range fixtures {
+		if err := f.Save(filepath.Join(dir, network+".json")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	viper.Set("fixtures_dir", dir)
+	tonClient = nil
+	t.Cleanup(func() {
+		viper.Set("fixtures_dir", "")
+		tonClient = nil
+		outputMode = outputText
+	})
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+
+	rootCmd.SetArgs(args)
+	err = execute()
+	w.Close()
+	return <-out, err
+}
+
+func TestBlockFetch(t *testing.T) {
+	blockCell := cell.BeginCell().MustStoreUInt(0x11ef55aa, 32).EndCell()
+	blockID := prepareBlockID(100, blockCell.Hash())
+
+	testnet := &tonclient.Fixtures{}
+	err := testnet.Add(
+		ton.LookupBlock{Mode: 1, ID: &ton.BlockInfoShort{Workchain: -1, Shard: 0, Seqno: 100}},
+		ton.BlockHeader{ID: blockID, HeaderProof: []byte{}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = testnet.Add(ton.GetBlockData{ID: blockID}, ton.BlockData{ID: blockID, Payload: blockCell.ToBOC()}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": testnet},
+		"block", "fetch", "--network", "testnet", "-s", "100", "-f", "hex")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(out) != fmt.Sprintf("%x", blockCell.ToBOC()) {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
+
+func TestTargetNetworkAlias(t *testing.T) {
+	blockCell := cell.BeginCell().MustStoreUInt(0x11ef55aa, 32).EndCell()
+	blockID := prepareBlockID(100, blockCell.Hash())
+
+	fastnet := &tonclient.Fixtures{}
+	err := fastnet.Add(
+		ton.LookupBlock{Mode: 1, ID: &ton.BlockInfoShort{Workchain: -1, Shard: 0, Seqno: 100}},
+		ton.BlockHeader{ID: blockID, HeaderProof: []byte{}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fastnet.Add(ton.GetBlockData{ID: blockID}, ton.BlockData{ID: blockID, Payload: blockCell.ToBOC()}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rootCmd.PersistentFlags().Set("network", "testnet") })
+
+	_, err = runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": {}, "fastnet": fastnet},
+		"block", "fetch", "--target-network", "fastnet", "-s", "100", "-f", "hex")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if network != "fastnet" {
+		t.Fatalf("--target-network did not set the network: %s", network)
+	}
+}
+
+func TestGetLiteClientStorage(t *testing.T) {
+	addr := address.MustParseAddr(testLiteClientAddr)
+	epochHash := bytes.Repeat([]byte{0xAB}, 32)
+
+	testnet := &tonclient.Fixtures{}
+	block := prepareMasterchainInfo(testnet, 200)
+	prepareGetMethod(testnet, block, addr, "get_storage",
+		prepareValidatorDict().AsCell(),
+		big.NewInt(100),
+		new(big.Int).SetBytes(epochHash),
+	)
+
+	out, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": testnet},
+		"get", "lite-client-storage", "--network", "testnet", "-a", testLiteClientAddr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, fmt.Sprintf("Epoch hash: %x", epochHash)) {
+		t.Fatalf("epoch hash is not in the output: %s", out)
+	}
+	if !strings.Contains(out, "Validators total weight: 100") {
+		t.Fatalf("total weight is not in the output: %s", out)
+	}
+}
+
+func TestGetLiteClientValidators(t *testing.T) {
+	addr := address.MustParseAddr(testLiteClientAddr)
+
+	testnet := &tonclient.Fixtures{}
+	block := prepareMasterchainInfo(testnet, 200)
+	prepareGetMethod(testnet, block, addr, "get_validators", prepareValidatorDict().AsCell())
+
+	out, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": testnet},
+		"get", "lite-client-validators", "--network", "testnet", "-a", testLiteClientAddr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := fmt.Sprintf(`{"%x":"0000000000000064"}`, bytes.Repeat([]byte{0x11}, 32))
+	if !strings.Contains(out, want) {
+		t.Fatalf("validator dict is not in the output: %s", out)
+	}
+}
+
+func TestSendCheckBlockWithoutFixture(t *testing.T) {
+	_, err := runWithFixtures(t, map[string]*tonclient.Fixtures{
+		"testnet": {},
+		"fastnet": {},
+	}, "send", "check-block", "--network", "testnet", "-a", testLiteClientAddr, "-s", "100")
+	if !errors.Is(err, tonclient.ErrNoFixture) {
+		t.Fatalf("expected ErrNoFixture, got %v", err)
+	}
+}
+
+func TestBlockFetchJSONOutput(t *testing.T) {
+	blockCell := cell.BeginCell().MustStoreUInt(0x11ef55aa, 32).EndCell()
+	blockID := prepareBlockID(100, blockCell.Hash())
+
+	testnet := &tonclient.Fixtures{}
+	err := testnet.Add(
+		ton.LookupBlock{Mode: 1, ID: &ton.BlockInfoShort{Workchain: -1, Shard: 0, Seqno: 100}},
+		ton.BlockHeader{ID: blockID, HeaderProof: []byte{}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = testnet.Add(ton.GetBlockData{ID: blockID}, ton.BlockData{ID: blockID, Payload: blockCell.ToBOC()}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": testnet},
+		"block", "fetch", "--network", "testnet", "-s", "100", "-f", "bin", "-o", "json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got struct {
+		Command string
+		OK      bool
+		Result  struct {
+			Seqno uint32
+			BOC   string
+		}
+	}
+	if err = json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output is not a JSON object: %v: %s", err, out)
+	}
+	if !got.OK || got.Command != "trustless-bridge-cli block fetch" || got.Result.Seqno != 100 {
+		t.Fatalf("unexpected report: %s", out)
+	}
+	if got.Result.BOC != fmt.Sprintf("%x", blockCell.ToBOC()) {
+		t.Fatalf("unexpected boc: %s", got.Result.BOC)
+	}
+}
+
+func TestJSONOutputError(t *testing.T) {
+	out, err := runWithFixtures(t, map[string]*tonclient.Fixtures{
+		"testnet": {},
+		"fastnet": {},
+	}, "send", "check-block", "--network", "testnet", "-a", testLiteClientAddr, "-s", "100", "-o", "json")
+	if exitCodeOf(err) != 3 {
+		t.Fatalf("expected network exit code, got %d: %v", exitCodeOf(err), err)
+	}
+
+	var got commandReport
+	if err = json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output is not a JSON object: %v: %s", err, out)
+	}
+	if got.OK || got.Error == nil || got.Error.Code != codeNetwork || got.Error.ExitCode != 3 {
+		t.Fatalf("unexpected report: %s", out)
+	}
+}
+
+func TestInvalidInputExitCode(t *testing.T) {
+	_, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": {}},
+		"get", "lite-client-storage", "--network", "testnet", "-a", "not-an-address")
+	if errorCodeOf(err) != codeInvalidInput {
+		t.Fatalf("expected invalid input, got %s: %v", errorCodeOf(err), err)
+	}
+}
+
+func TestTxSelectorRequiresAccount(t *testing.T) {
+	_, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": {}},
+		"tx", "proof", "--network", "testnet", "--lt", "100")
+	if errorCodeOf(err) != codeInvalidInput {
+		t.Fatalf("expected invalid input, got %s: %v", errorCodeOf(err), err)
+	}
+}
+
+func TestTxSelectorByLT(t *testing.T) {
+	// the selector is valid, so the transaction is looked up on the network
+	_, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": {}, "fastnet": {}},
+		"tx", "proof", "--network", "testnet", "--account", testLiteClientAddr, "--lt", "100")
+	if !errors.Is(err, tonclient.ErrNoFixture) {
+		t.Fatalf("expected ErrNoFixture, got %v", err)
+	}
+}
+
+func TestCheckTxBatchRequiresHighloadWallet(t *testing.T) {
+	viper.Set("wallet_version", "v4r2")
+	t.Cleanup(func() { viper.Set("wallet_version", "") })
+
+	_, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": {}},
+		"send", "check-tx-batch", "--network", "testnet", "-a", testLiteClientAddr, "-f", "txs.txt")
+	if !errors.Is(err, tonclient.ErrWalletConfig) || errorCodeOf(err) != codeConfig {
+		t.Fatalf("expected wallet config error, got %s: %v", errorCodeOf(err), err)
+	}
+}
+
+func TestKeysExportPubkeyNotFound(t *testing.T) {
+	_, err := runWithFixtures(t, map[string]*tonclient.Fixtures{},
+		"keys", "export-pubkey", "relayer", "--keystore-dir", t.TempDir())
+	if !errors.Is(err, keystore.ErrNotFound) || errorCodeOf(err) != codeNotFound {
+		t.Fatalf("expected not found, got %s: %v", errorCodeOf(err), err)
+	}
+}
+
+func TestKeysImportAndExport(t *testing.T) {
+	dir := t.TempDir()
+	seed := wallet.NewSeed()
+	mnemonicFile := filepath.Join(dir, "mnemonic.txt")
+	passphraseFile := filepath.Join(dir, "passphrase.txt")
+	if err := os.WriteFile(mnemonicFile, []byte(strings.Join(seed, " ")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(passphraseFile, []byte("correct horse\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		rootCmd.PersistentFlags().Set("keystore-dir", "")
+		rootCmd.PersistentFlags().Set("passphrase-file", "")
+	})
+	keystoreArgs := []string{"--keystore-dir", filepath.Join(dir, "keystore"), "--passphrase-file", passphraseFile}
+
+	_, err := runWithFixtures(t, map[string]*tonclient.Fixtures{},
+		append([]string{"keys", "import", "relayer", "--mnemonic-file", mnemonicFile}, keystoreArgs...)...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = runWithFixtures(t, map[string]*tonclient.Fixtures{},
+		append([]string{"keys", "import", "relayer", "--mnemonic-file", mnemonicFile}, keystoreArgs...)...)
+	if !errors.Is(err, keystore.ErrExists) || errorCodeOf(err) != codeInvalidInput {
+		t.Fatalf("expected key exists, got %s: %v", errorCodeOf(err), err)
+	}
+	if _, err = runWithFixtures(t, map[string]*tonclient.Fixtures{},
+		append([]string{"keys", "new", "spare"}, keystoreArgs...)...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	key, err := wallet.SeedToPrivateKey(seed, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := runWithFixtures(t, map[string]*tonclient.Fixtures{},
+		append([]string{"keys", "export-pubkey", "relayer"}, keystoreArgs...)...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := hex.EncodeToString(key.Public().(ed25519.PublicKey)); strings.TrimSpace(out) != want {
+		t.Fatalf("unexpected public key %s, want %s", out, want)
+	}
+
+	out, err = runWithFixtures(t, map[string]*tonclient.Fixtures{},
+		append([]string{"keys", "list", "-o", "json"}, keystoreArgs...)...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var report struct {
+		Result struct {
+			Keys []struct {
+				Name      string `json:"name"`
+				PublicKey string `json:"public_key"`
+			}
+		}
+	}
+	if err = json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("output is not a JSON object: %v: %s", err, out)
+	}
+	if len(report.Result.Keys) != 2 || report.Result.Keys[0].Name != "relayer" || report.Result.Keys[1].Name != "spare" {
+		t.Fatalf("unexpected keys: %s", out)
+	}
+}
+
+func TestDeployPlanRequiresCode(t *testing.T) {
+	viper.Set("lite_client_code", "")
+	_, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": {}, "fastnet": {}},
+		"deploy", "plan", "--network", "testnet", "-s", "100", "-w", "-1")
+	if !errors.Is(err, tonclient.ErrContractConfig) || errorCodeOf(err) != codeConfig {
+		t.Fatalf("expected contract config error, got %s: %v", errorCodeOf(err), err)
+	}
+}
+
+func TestDeployTxCheckerRequiresLiteClient(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "deployment.json")
+	_, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": {}},
+		"deploy", "tx-checker", "--network", "testnet", "-w", "-1", "--manifest", manifest)
+	if errorCodeOf(err) != codeInvalidInput {
+		t.Fatalf("expected invalid input, got %s: %v", errorCodeOf(err), err)
+	}
+}
+
+func TestDeployManifestOfAnotherNetwork(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "deployment.json")
+	if err := os.WriteFile(manifest, []byte(`{"network": "fastnet", "workchain": -1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": {}},
+		"deploy", "all", "--network", "testnet", "-s", "100", "-w", "-1", "--manifest", manifest)
+	if errorCodeOf(err) != codeInvalidInput || !strings.Contains(err.Error(), "fastnet") {
+		t.Fatalf("expected invalid input, got %s: %v", errorCodeOf(err), err)
+	}
+}
+
+func TestBlockSignaturesInvalidShard(t *testing.T) {
+	_, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": {}},
+		"block", "signatures", "--network", "testnet", "-s", "5", "-w", "0", "--shard", "zz")
+	if errorCodeOf(err) != codeInvalidInput {
+		t.Fatalf("expected invalid input, got %s: %v", errorCodeOf(err), err)
+	}
+}
+
+func TestValidatorsDiffInvalidParam(t *testing.T) {
+	t.Cleanup(func() { validatorsDiffCmd.Flags().Set("param", "34") })
+	_, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": {}, "fastnet": {}},
+		"validators", "diff", "--network", "testnet", "-s", "100", "-a", "EQDKMJuFSh4fWyciNGzDZU243rmBG80-uMMkFCMWWk98lA1X", "--param", "33")
+	if errorCodeOf(err) != codeInvalidInput {
+		t.Fatalf("expected invalid input, got %s: %v", errorCodeOf(err), err)
+	}
+}
+
+func TestValidatorsShow(t *testing.T) {
+	current := blocktest.NewValidators(40, 30, 20, 10)
+	next := blocktest.NewValidators(50, 50)
+	keyBlock := &blocktest.Block{
+		Workchain: -1,
+		Seqno:     100,
+		Config: map[uint32]*cell.Cell{
+			34: blocktest.ValidatorSet(1000, current),
+			36: blocktest.ValidatorSet(2000, next),
+		},
+	}
+	testnet := &tonclient.Fixtures{}
+	// block 110 is not a key block, so the previous key block is shown
+	prepareBlock(testnet, keyBlock)
+	prepareBlock(testnet, &blocktest.Block{Workchain: -1, Seqno: 110, PrevKeyBlock: 100})
+
+	out, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": testnet},
+		"validators", "show", "--network", "testnet", "-s", "110", "-o", "json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var report struct {
+		Result struct {
+			Seqno         uint32
+			ValidatorSets []struct {
+				Param       int
+				UTimeSince  uint32 `json:"utime_since"`
+				Total       int
+				Main        int
+				TotalWeight uint64 `json:"total_weight"`
+				EpochHash   string `json:"epoch_hash"`
+				Validators  []struct {
+					PublicKey string `json:"public_key"`
+					Weight    uint64
+					Main      bool
+				}
+			} `json:"validator_sets"`
+		}
+	}
+	if err = json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("output is not a JSON object: %v: %s", err, out)
+	}
+	if report.Result.Seqno != 100 || len(report.Result.ValidatorSets) != 2 {
+		t.Fatalf("unexpected validator sets: %s", out)
+	}
+	for i, want := range []struct {
+		param      int
+		utimeSince uint32
+		validators []blocktest.Validator
+	}{
+		{34, 1000, current},
+		{36, 2000, next},
+	} {
+		set := report.Result.ValidatorSets[i]
+		if set.Param != want.param || set.UTimeSince != want.utimeSince || set.Total != len(want.validators) ||
+			set.Main != len(want.validators) || set.TotalWeight != 100 || len(set.Validators) != len(want.validators) {
+			t.Fatalf("unexpected validator set %d: %+v", want.param, set)
+		}
+		if set.EpochHash != hex.EncodeToString(keyBlock.Config[uint32(want.param)].Hash()) {
+			t.Fatalf("unexpected epoch hash of validator set %d: %s", want.param, set.EpochHash)
+		}
+		for j, v := range set.Validators {
+			if v.PublicKey != hex.EncodeToString(want.validators[j].PublicKey) || v.Weight != want.validators[j].Weight || !v.Main {
+				t.Fatalf("unexpected validator %d of set %d: %+v", j, want.param, v)
+			}
+		}
+	}
+}
+
+func TestValidatorsDiff(t *testing.T) {
+	addr := address.MustParseAddr(testLiteClientAddr)
+	validators := blocktest.NewValidators(40, 30, 20, 10)
+	keyBlock, _ := prepareSignedBlocks(validators)
+	fastnet := &tonclient.Fixtures{}
+	prepareBlock(fastnet, keyBlock)
+
+	// the LiteClient has the first validator, the second one with another weight and a removed one
+	removed := bytes.Repeat([]byte{0x11}, 32)
+	dict := cell.NewDict(256)
+	for key, weight := range map[string]uint64{
+		string(validators[0].PublicKey): 40,
+		string(validators[1].PublicKey): 35,
+		string(removed):                 10,
+	} {
+		err := dict.Set(
+			cell.BeginCell().MustStoreSlice([]byte(key), 256).EndCell(),
+			cell.BeginCell().MustStoreUInt(weight, 64).EndCell(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	testnet := &tonclient.Fixtures{}
+	block := prepareMasterchainInfo(testnet, 200)
+	prepareGetMethod(testnet, block, addr, "get_validators", dict.AsCell())
+
+	out, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": testnet, "fastnet": fastnet},
+		"validators", "diff", "--network", "testnet", "-s", "100", "-a", testLiteClientAddr, "-o", "json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	type entry struct {
+		PublicKey        string `json:"public_key"`
+		Weight           uint64
+		LiteClientWeight uint64 `json:"lite_client_weight"`
+	}
+	var report struct {
+		Result struct {
+			Added                 []entry
+			Removed               []entry
+			Reweighted            []entry
+			OverlapWeight         uint64 `json:"overlap_weight"`
+			LiteClientTotalWeight uint64 `json:"lite_client_total_weight"`
+			OverlapHolds          bool   `json:"overlap_holds"`
+		}
+	}
+	if err = json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("output is not a JSON object: %v: %s", err, out)
+	}
+	res := report.Result
+	added := map[string]uint64{}
+	for _, e := range res.Added {
+		added[e.PublicKey] = e.Weight
+	}
+	if len(added) != 2 || added[hex.EncodeToString(validators[2].PublicKey)] != 20 || added[hex.EncodeToString(validators[3].PublicKey)] != 10 {
+		t.Fatalf("unexpected added validators: %+v", res.Added)
+	}
+	if len(res.Removed) != 1 || res.Removed[0].PublicKey != hex.EncodeToString(removed) || res.Removed[0].Weight != 10 {
+		t.Fatalf("unexpected removed validators: %+v", res.Removed)
+	}
+	if len(res.Reweighted) != 1 || res.Reweighted[0].PublicKey != hex.EncodeToString(validators[1].PublicKey) ||
+		res.Reweighted[0].Weight != 30 || res.Reweighted[0].LiteClientWeight != 35 {
+		t.Fatalf("unexpected reweighted validators: %+v", res.Reweighted)
+	}
+	if res.OverlapWeight != 75 || res.LiteClientTotalWeight != 85 || !res.OverlapHolds {
+		t.Fatalf("unexpected overlap: %d/%d, holds: %t", res.OverlapWeight, res.LiteClientTotalWeight, res.OverlapHolds)
+	}
+}
+
+func TestRelayPendingKeyBlocksWithoutState(t *testing.T) {
+	addr := address.MustParseAddr(testLiteClientAddr)
+	first := blocktest.ValidatorSet(1000, blocktest.NewValidators(40, 30, 20, 10))
+	second := blocktest.ValidatorSet(2000, blocktest.NewValidators(50, 50))
+	third := blocktest.ValidatorSet(3000, blocktest.NewValidators(100))
+
+	// the key block 300 keeps the validator set
+	fastnet := &tonclient.Fixtures{}
+	for i, set := range []*cell.Cell{first, second, second, third} {
+		prepareBlock(fastnet, &blocktest.Block{
+			Workchain:    -1,
+			Seqno:        uint32(i+1) * 100,
+			PrevKeyBlock: uint32(i) * 100,
+			Config:       map[uint32]*cell.Cell{34: set},
+		})
+	}
+	pendingKeyBlocks := func(epoch *cell.Cell) []uint32 {
+		testnet := &tonclient.Fixtures{}
+		block := prepareMasterchainInfo(testnet, 200)
+		prepareGetMethod(testnet, block, addr, "get_storage",
+			prepareValidatorDict().AsCell(),
+			big.NewInt(100),
+			new(big.Int).SetBytes(epoch.Hash()),
+		)
+		r := &keyBlockRelay{
+			source:     tonclient.NewTonClientFixtures(fastnet),
+			liteClient: liteclient.New(addr, tonclient.NewTonClientFixtures(testnet)),
+			state:      &relayState{},
+		}
+		pending, err := r.pendingKeyBlocks(context.Background(), 400)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return pending
+	}
+
+	// the LiteClient missed two epochs
+	if pending := pendingKeyBlocks(first); len(pending) != 2 || pending[0] != 200 || pending[1] != 400 {
+		t.Fatalf("unexpected pending key blocks: %v", pending)
+	}
+	// the LiteClient is in sync, the latest key block is only stored as processed
+	if pending := pendingKeyBlocks(third); len(pending) != 1 || pending[0] != 400 {
+		t.Fatalf("unexpected pending key blocks: %v", pending)
+	}
+}
+
+func TestWatchAccountStateOfAnotherAccount(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(state, []byte(`{"account": "EQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAM9c", "last_lt": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": {}, "fastnet": {}},
+		"watch-account", "--network", "testnet", "-a", "EQDKMJuFSh4fWyciNGzDZU243rmBG80-uMMkFCMWWk98lA1X",
+		"--tx-checker", "EQDKMJuFSh4fWyciNGzDZU243rmBG80-uMMkFCMWWk98lA1X", "--state-file", state)
+	if errorCodeOf(err) != codeInvalidInput {
+		t.Fatalf("expected invalid input, got %s: %v", errorCodeOf(err), err)
+	}
+}
+
+func TestJobsRetryNotFound(t *testing.T) {
+	_, err := runWithFixtures(t, map[string]*tonclient.Fixtures{},
+		"jobs", "retry", "7", "--jobs-db", filepath.Join(t.TempDir(), "jobs.db"))
+	if !errors.Is(err, jobs.ErrNotFound) || errorCodeOf(err) != codeNotFound {
+		t.Fatalf("expected not found, got %s: %v", errorCodeOf(err), err)
+	}
+}
+
+// prepareBlock adds the lookup and the data of the masterchain block.
+func prepareBlock(fixtures *tonclient.Fixtures, block *blocktest.Block) {
+	id := block.ID()
+	err := fixtures.Add(
+		ton.LookupBlock{Mode: 1, ID: &ton.BlockInfoShort{Workchain: -1, Shard: 0, Seqno: int32(block.Seqno)}},
+		ton.BlockHeader{ID: id, HeaderProof: []byte{}},
+	)
+	if err != nil {
+		panic(err)
+	}
+	if err = fixtures.Add(ton.GetBlockData{ID: id}, ton.BlockData{ID: id, Payload: block.BOC()}); err != nil {
+		panic(err)
+	}
+}
+
+// prepareSignedBlock adds the exchanges that prove the masterchain block with the signatures
+// of the validators of the key block, and returns the dict of all signatures as sent to a LiteClient.
+func prepareSignedBlock(
+	fixtures *tonclient.Fixtures,
+	keyBlock, block *blocktest.Block,
+	validators []blocktest.Validator,
+) *cell.Dictionary {
+	prepareBlock(fixtures, keyBlock)
+	prepareBlock(fixtures, block)
+	err := fixtures.Add(
+		ton.GetBlockProof{Mode: 0x1001, KnownBlock: keyBlock.ID(), TargetBlock: block.ID()},
+		ton.PartialBlockProof{
+			Complete: true,
+			From:     keyBlock.ID(),
+			To:       block.ID(),
+			Steps:    []any{blocktest.ForwardLink(keyBlock, block, validators)},
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	signatures := cell.NewDict(256)
+	for i, s := range blocktest.Sign(block.ID(), validators) {
+		signatures.Set(
+			cell.BeginCell().MustStoreSlice(validators[i].PublicKey, 256).EndCell(),
+			cell.BeginCell().MustStoreSlice(s.Signature, 512).EndCell(),
+		)
+	}
+	return signatures
+}
+
+// prepareSignedBlocks returns a key block with the validators in its config
+// and the next masterchain block signed by them.
+func prepareSignedBlocks(validators []blocktest.Validator) (*blocktest.Block, *blocktest.Block) {
+	keyBlock := &blocktest.Block{
+		Workchain: -1,
+		Seqno:     100,
+		Config: map[uint32]*cell.Cell{
+			28: blocktest.CatchainConfig(),
+			34: blocktest.ValidatorSet(1000, validators),
+		},
+	}
+	block := &blocktest.Block{
+		Workchain:        -1,
+		Seqno:            110,
+		CatchainSeqno:    7,
+		ValidatorSetHash: blocktest.ValidatorSetHash(7, validators),
+		PrevKeyBlock:     100,
+	}
+	return keyBlock, block
+}
+
+// prepareWallet configures a new v4r2 wallet that is not deployed yet
+// and adds the exchanges with which it builds an external message.
+// It returns the last masterchain block.
+func prepareWallet(t *testing.T, fixtures *tonclient.Fixtures) *ton.BlockIDExt {
+	seed := wallet.NewSeed()
+	key, err := wallet.SeedToPrivateKey(seed, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := wallet.AddressFromPubKey(key.Public().(ed25519.PublicKey), wallet.V4R2, wallet.DefaultSubwallet, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("wallet_version", "v4r2")
+	viper.Set("wallet_mnemonic", strings.Join(seed, " "))
+	t.Cleanup(func() {
+		viper.Set("wallet_version", "")
+		viper.Set("wallet_mnemonic", "")
+	})
+
+	// the shard state of the proof has no wallet, so the wallet and
+	// the contracts to deploy are not initialized
+	accounts := cell.NewDict(256)
+	other := address.MustParseAddr(testLiteClientAddr)
+	err = accounts.Set(
+		cell.BeginCell().MustStoreSlice(other.Data(), 256).EndCell(),
+		blocktest.ShardAccount(blocktest.Account(other, 1, nil, nil), 1, 1, make([]byte, 32)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	master := &blocktest.Block{
+		Workchain: -1,
+		Seqno:     200,
+		NewState:  blocktest.MasterState(200, accounts, map[uint32]*cell.Cell{43: prepareMsgLimits(fees.DefaultMsgLimits)}),
+	}
+	block := master.ID()
+	prepareLastBlock(fixtures, block)
+	prepareConfigParams(fixtures, master, 43)
+	stateProof := cell.BeginCell().MustStoreRef(master.NewState).EndCell()
+	err = fixtures.AddMethod(
+		ton.GetAccountState{ID: block, Account: ton.AccountID{Workchain: addr.Workchain(), ID: addr.Data()}},
+		ton.AccountState{ID: block, Shard: block, Proof: []*cell.Cell{cell.BeginCell().EndCell(), stateProof}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prepareGetMethod(fixtures, block, addr, "seqno", big.NewInt(0))
+	return block
+}
+
+// dryRunReport is the JSON report of a command run with --dry-run.
+type dryRunReport struct {
+	OK     bool
+	Result struct {
+		LiteClient string `json:"lite_client"`
+		TxChecker  string `json:"tx_checker"`
+		Externals  []struct {
+			Messages []struct {
+				Destination string
+				Amount      string
+				Deploy      bool
+				Payload     string
+			}
+		}
+		// Transactions are the outcomes of check-tx-batch.
+		Transactions []struct {
+			Line   int
+			Status string
+		}
+	}
+}
+
+// runDryRun executes the CLI with --dry-run and the JSON output,
+// checks the exit code and returns the report.
+func runDryRun(t *testing.T, fixtures map[string]*tonclient.Fixtures, args ...string) *dryRunReport {
+	cmd, _, err := rootCmd.Find(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Flags().Set("dry-run", "false")
+		if f := cmd.Flags().Lookup("signature-strategy"); f != nil {
+			f.Value.Set(f.DefValue)
+		}
+	})
+
+	out, err := runWithFixtures(t, fixtures, append(args, "--dry-run", "-o", "json")...)
+	if err != nil {
+		t.Fatalf("expected exit code 0, got %d: %v", exitCodeOf(err), err)
+	}
+	var report dryRunReport
+	if err = json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("output is not a JSON object: %v: %s", err, out)
+	}
+	if !report.OK || len(report.Result.Externals) == 0 {
+		t.Fatalf("unexpected report: %s", out)
+	}
+	return &report
+}
+
+// blockMessageBody returns the body of a check_block or new_key_block message with the op.
+func blockMessageBody(op uint64, block *blocktest.Block, signatures *cell.Dictionary) string {
+	proof, err := blockutils.BuildBlockProof(block.BOC())
+	if err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(cell.BeginCell().
+		MustStoreUInt(op, 32).
+		MustStoreUInt(0, 64).
+		MustStoreRef(cell.BeginCell().MustStoreSlice(block.ID().FileHash, 256).MustStoreRef(proof).EndCell()).
+		MustStoreDict(signatures).
+		EndCell().ToBOC())
+}
+
+func TestSendCheckBlockDryRun(t *testing.T) {
+	validators := blocktest.NewValidators(40, 30, 20, 10)
+	keyBlock, block := prepareSignedBlocks(validators)
+	fastnet := &tonclient.Fixtures{}
+	signatures := prepareSignedBlock(fastnet, keyBlock, block, validators)
+	testnet := &tonclient.Fixtures{}
+	prepareWallet(t, testnet)
+
+	report := runDryRun(t, map[string]*tonclient.Fixtures{"testnet": testnet, "fastnet": fastnet},
+		"send", "check-block", "--network", "testnet", "-a", testLiteClientAddr, "-s", "110",
+		"--signature-strategy", "all")
+
+	msg := report.Result.Externals[0].Messages[0]
+	if msg.Destination != testLiteClientAddr || msg.Amount != "0.2" || msg.Deploy {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	if want := blockMessageBody(0x8eaa9d76, block, signatures); msg.Payload != want {
+		t.Fatalf("unexpected payload:\n%s\nwant:\n%s", msg.Payload, want)
+	}
+}
+
+func TestSendNewKeyBlockDryRun(t *testing.T) {
+	validators := blocktest.NewValidators(40, 30, 20, 10)
+	keyBlock, block := prepareSignedBlocks(validators)
+	block.Config = map[uint32]*cell.Cell{
+		28: blocktest.CatchainConfig(),
+		34: blocktest.ValidatorSet(2000, blocktest.NewValidators(50, 50)),
+	}
+	fastnet := &tonclient.Fixtures{}
+	signatures := prepareSignedBlock(fastnet, keyBlock, block, validators)
+	testnet := &tonclient.Fixtures{}
+	prepareWallet(t, testnet)
+
+	report := runDryRun(t, map[string]*tonclient.Fixtures{"testnet": testnet, "fastnet": fastnet},
+		"send", "new-key-block", "--network", "testnet", "-a", testLiteClientAddr, "-s", "110",
+		"--signature-strategy", "all")
+
+	msg := report.Result.Externals[0].Messages[0]
+	if msg.Destination != testLiteClientAddr || msg.Amount != "1" || msg.Deploy {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	if want := blockMessageBody(0x11a78ffe, block, signatures); msg.Payload != want {
+		t.Fatalf("unexpected payload:\n%s\nwant:\n%s", msg.Payload, want)
+	}
+}
+
+func TestSendCheckTxDryRun(t *testing.T) {
+	validators := blocktest.NewValidators(40, 30, 20, 10)
+	keyBlock, block := prepareSignedBlocks(validators)
+	txCell := blocktest.Transaction(bytes.Repeat([]byte{0x33}, 32), 5000, nil)
+	block.Accounts = blocktest.AccountBlocks(txCell)
+	fastnet := &tonclient.Fixtures{}
+	signatures := prepareSignedBlock(fastnet, keyBlock, block, validators)
+	testnet := &tonclient.Fixtures{}
+	prepareWallet(t, testnet)
+
+	report := runDryRun(t, map[string]*tonclient.Fixtures{"testnet": testnet, "fastnet": fastnet},
+		"send", "check-tx", "--network", "testnet", "-a", testLiteClientAddr, "-s", "110",
+		"-t", hex.EncodeToString(txCell.Hash()), "--signature-strategy", "all")
+
+	msg := report.Result.Externals[0].Messages[0]
+	if msg.Destination != testLiteClientAddr || msg.Amount != "1" || msg.Deploy {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	if want := checkTxMessageBody(block, signatures, txCell); msg.Payload != want {
+		t.Fatalf("unexpected payload:\n%s\nwant:\n%s", msg.Payload, want)
+	}
+}
+
+// checkTxMessageBody returns the body of a check_transaction message of the transaction of the block.
+func checkTxMessageBody(block *blocktest.Block, signatures *cell.Dictionary, txCell *cell.Cell) string {
+	txProof, tx, err := txutils.BuildTxProof(block.Cell(), txCell.Hash())
+	if err != nil {
+		panic(err)
+	}
+	tx.Hash = txCell.Hash()
+	blockProof, err := blockutils.BuildBlockProof(block.BOC())
+	if err != nil {
+		panic(err)
+	}
+	currentBlock := cell.BeginCell().
+		MustStoreRef(cell.BeginCell().MustStoreSlice(block.ID().FileHash, 256).MustStoreRef(blockProof).EndCell()).
+		MustStoreRef(signatures.AsCell()).
+		EndCell()
+	msg := txchecker.New(address.MustParseAddr(testLiteClientAddr), nil).
+		CheckTxMessage(txchecker.TxToCell(tx), txProof, currentBlock)
+	return hex.EncodeToString(msg.InternalMessage.Body.ToBOC())
+}
+
+func TestSendCheckTxBatchDryRun(t *testing.T) {
+	validators := blocktest.NewValidators(40, 30, 20, 10)
+	keyBlock, block := prepareSignedBlocks(validators)
+	txCells := []*cell.Cell{
+		blocktest.Transaction(bytes.Repeat([]byte{0x33}, 32), 5000, nil),
+		blocktest.Transaction(bytes.Repeat([]byte{0x34}, 32), 5010, nil),
+	}
+	block.Accounts = blocktest.AccountBlocks(txCells...)
+	fastnet := &tonclient.Fixtures{}
+	signatures := prepareSignedBlock(fastnet, keyBlock, block, validators)
+	testnet := &tonclient.Fixtures{}
+	prepareWallet(t, testnet)
+	viper.Set("wallet_version", "highloadv3")
+
+	path := filepath.Join(t.TempDir(), "txs.txt")
+	lines := fmt.Sprintf("110 %x\n# comment\n110 %x -1\n", txCells[0].Hash(), txCells[1].Hash())
+	if err := os.WriteFile(path, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report := runDryRun(t, map[string]*tonclient.Fixtures{"testnet": testnet, "fastnet": fastnet},
+		"send", "check-tx-batch", "--network", "testnet", "-a", testLiteClientAddr, "-f", path,
+		"--signature-strategy", "all")
+
+	// both messages share the block proof, so they are packed into one external message
+	if len(report.Result.Externals) != 1 || len(report.Result.Externals[0].Messages) != 2 {
+		t.Fatalf("expected one external message with 2 messages, got %+v", report.Result.Externals)
+	}
+	for i, msg := range report.Result.Externals[0].Messages {
+		if msg.Destination != testLiteClientAddr || msg.Amount != "1" || msg.Deploy {
+			t.Fatalf("unexpected message %d: %+v", i, msg)
+		}
+		if want := checkTxMessageBody(block, signatures, txCells[i]); msg.Payload != want {
+			t.Fatalf("unexpected payload of message %d:\n%s\nwant:\n%s", i, msg.Payload, want)
+		}
+	}
+	if len(report.Result.Transactions) != 2 {
+		t.Fatalf("unexpected transactions %+v", report.Result.Transactions)
+	}
+	for _, tx := range report.Result.Transactions {
+		if tx.Status != batchDryRun {
+			t.Fatalf("unexpected status of line %d: %s", tx.Line, tx.Status)
+		}
+	}
+}
+
+// prepareInternal returns the cell of an internal message created at the lt.
+func prepareInternal(src, dst *address.Address, lt uint64, body *cell.Cell) *cell.Cell {
+	msg, err := tlb.ToCell(&tlb.InternalMessage{
+		IHRDisabled: true,
+		SrcAddr:     src,
+		DstAddr:     dst,
+		Amount:      tlb.MustFromTON("1"),
+		CreatedLT:   lt,
+		Body:        body,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return msg
+}
+
+// prepareHistories adds the states of the accounts with their transactions, oldest first,
+// each returned by a single ListTransactions page of the limit.
+func prepareHistories(fixtures *tonclient.Fixtures, limit int32, histories map[*address.Address][]*cell.Cell) {
+	block := prepareMasterchainInfo(fixtures, 200)
+	accounts := cell.NewDict(256)
+	for addr, txs := range histories {
+		last := txs[len(txs)-1]
+		var tx tlb.Transaction
+		if err := tlb.LoadFromCell(&tx, last.BeginParse()); err != nil {
+			panic(err)
+		}
+		err := accounts.Set(
+			cell.BeginCell().MustStoreSlice(addr.Data(), 256).EndCell(),
+			blocktest.ShardAccount(blocktest.Account(addr, 1, nil, nil), 1, tx.LT, last.Hash()),
+		)
+		if err != nil {
+			panic(err)
+		}
+
+		var page []*cell.Cell
+		for i := len(txs) - 1; i >= 0; i-- {
+			page = append(page, txs[i])
+		}
+		err = fixtures.Add(
+			ton.GetTransactions{
+				Limit:  limit,
+				AccID:  &ton.AccountID{Workchain: addr.Workchain(), ID: addr.Data()},
+				LT:     int64(tx.LT),
+				TxHash: last.Hash(),
+			},
+			ton.TransactionList{IDs: []*ton.BlockIDExt{}, Transactions: cell.ToBOCWithFlags(page, false)},
+		)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	stateProof := cell.BeginCell().MustStoreRef(blocktest.ShardState(0, 200, accounts)).EndCell()
+	for addr := range histories {
+		account, err := accounts.LoadValue(cell.BeginCell().MustStoreSlice(addr.Data(), 256).EndCell())
+		if err != nil {
+			panic(err)
+		}
+		err = fixtures.Add(
+			ton.GetAccountState{ID: block, Account: ton.AccountID{Workchain: addr.Workchain(), ID: addr.Data()}},
+			ton.AccountState{ID: block, Shard: block, Proof: []*cell.Cell{cell.BeginCell().EndCell(), stateProof}, State: account.MustLoadRef().MustToCell()},
+		)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestTrackBatches(t *testing.T) {
+	walletAddr := address.NewAddress(0, 0, bytes.Repeat([]byte{0x44}, 32))
+	txCheckerAddr := address.MustParseAddr(testLiteClientAddr)
+	var messages []*wallet.Message
+	for i := 0; i < 3; i++ {
+		body := cell.BeginCell().MustStoreUInt(0x91d555f7, 32).MustStoreUInt(uint64(i), 64).EndCell()
+		messages = append(messages, wallet.SimpleMessage(txCheckerAddr, tlb.MustFromTON("1"), body))
+	}
+	answer := func(lt uint64) *cell.Cell {
+		return prepareInternal(txCheckerAddr, walletAddr, lt, cell.BeginCell().MustStoreUInt(0x756adff1, 32).EndCell())
+	}
+
+	// the highload wallet sends the batch to itself and then sends the first two messages,
+	// the TxChecker answers both of them
+	sendTx := blocktest.Transaction(walletAddr.Data(), 1000, nil, prepareInternal(walletAddr, walletAddr, 1001, nil))
+	selfTx := blocktest.NextTransaction(sendTx, 1010, prepareInternal(walletAddr, walletAddr, 1001, nil),
+		prepareInternal(walletAddr, txCheckerAddr, 1011, messages[1].InternalMessage.Body),
+		prepareInternal(walletAddr, txCheckerAddr, 1012, messages[0].InternalMessage.Body),
+	)
+	checkTx1 := blocktest.Transaction(txCheckerAddr.Data(), 2000, prepareInternal(walletAddr, txCheckerAddr, 1011, messages[1].InternalMessage.Body), answer(2001))
+	checkTx2 := blocktest.NextTransaction(checkTx1, 2010, prepareInternal(walletAddr, txCheckerAddr, 1012, messages[0].InternalMessage.Body), answer(2011))
+	answerTx1 := blocktest.NextTransaction(selfTx, 3000, answer(2001))
+	answerTx2 := blocktest.NextTransaction(answerTx1, 3010, answer(2011))
+	fixtures := &tonclient.Fixtures{}
+	// msgtrace lists transactions by 10
+	prepareHistories(fixtures, 10, map[*address.Address][]*cell.Cell{
+		walletAddr:    {sendTx, selfTx, answerTx1, answerTx2},
+		txCheckerAddr: {checkTx1, checkTx2},
+	})
+
+	tonClient = tonclient.NewTonClientFixtures(fixtures)
+	t.Cleanup(func() { tonClient = nil })
+	var tx tlb.Transaction
+	if err := tlb.LoadFromCell(&tx, sendTx.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	tx.Hash = sendTx.Hash()
+	var items []*batchItem
+	itemOf := map[*wallet.Message]*batchItem{}
+	for i, msg := range messages {
+		item := &batchItem{entry: &batch.Entry{Line: i + 1, Seqno: 110, TxHash: make([]byte, 32)}, msg: msg, status: batchSent, sendTx: &tx}
+		items = append(items, item)
+		itemOf[msg] = item
+	}
+
+	trackBatches(context.Background(), txchecker.New(txCheckerAddr, tonClient), [][]*wallet.Message{messages}, itemOf, 2)
+	for i, want := range []string{batchAccepted, batchAccepted, batchUnknown} {
+		if items[i].status != want {
+			t.Fatalf("unexpected status of message %d: %s, %v", i, items[i].status, items[i].err)
+		}
+	}
+	if err := reportBatchItems(items); !errors.Is(err, batch.ErrIncomplete) {
+		t.Fatalf("expected ErrIncomplete, got %v", err)
+	}
+}
+
+// prepareContractCode configures the code of the LiteClient and the TxChecker.
+func prepareContractCode(t *testing.T) (liteClientCode, txCheckerCode *cell.Cell) {
+	liteClientCode = cell.BeginCell().MustStoreUInt(0x1c, 8).EndCell()
+	txCheckerCode = cell.BeginCell().MustStoreUInt(0x7c, 8).EndCell()
+	viper.Set("lite_client_code", hex.EncodeToString(liteClientCode.ToBOC()))
+	viper.Set("tx_checker_code", hex.EncodeToString(txCheckerCode.ToBOC()))
+	t.Cleanup(func() {
+		viper.Set("lite_client_code", "")
+		viper.Set("tx_checker_code", "")
+	})
+	return liteClientCode, txCheckerCode
+}
+
+// plannedAddresses returns the masterchain addresses of the LiteClient initialized
+// with the validators of the key block and of its TxChecker.
+func plannedAddresses(keyBlock *blocktest.Block, validators []blocktest.Validator, liteClientCode, txCheckerCode *cell.Cell) (liteClientAddr, txCheckerAddr *address.Address) {
+	validatorDict := cell.NewDict(256)
+	var totalWeight uint64
+	for _, v := range validators {
+		validatorDict.Set(
+			cell.BeginCell().MustStoreSlice(v.PublicKey, 256).EndCell(),
+			cell.BeginCell().MustStoreUInt(v.Weight, 64).EndCell(),
+		)
+		totalWeight += v.Weight
+	}
+	liteClientData := liteclient.InitDataToCell(&liteclient.InitData{
+		EpochHash:             keyBlock.Config[34].Hash(),
+		ValidatorsTotalWeight: totalWeight,
+		ValidatorDict:         validatorDict,
+	})
+	_, liteClientAddr, err := tonclient.ContractStateInit(0xff, liteClientCode, liteClientData)
+	if err != nil {
+		panic(err)
+	}
+	txCheckerData := txchecker.InitDataToCell(&txchecker.InitData{LiteClientAddr: liteClientAddr})
+	_, txCheckerAddr, err = tonclient.ContractStateInit(0xff, txCheckerCode, txCheckerData)
+	if err != nil {
+		panic(err)
+	}
+	return liteClientAddr, txCheckerAddr
+}
+
+func TestDeployAllDryRun(t *testing.T) {
+	liteClientCode, txCheckerCode := prepareContractCode(t)
+	validators := blocktest.NewValidators(40, 30, 20, 10)
+	keyBlock, _ := prepareSignedBlocks(validators)
+	fastnet := &tonclient.Fixtures{}
+	prepareBlock(fastnet, keyBlock)
+	testnet := &tonclient.Fixtures{}
+	prepareWallet(t, testnet)
+
+	manifest := filepath.Join(t.TempDir(), "deployment.json")
+	report := runDryRun(t, map[string]*tonclient.Fixtures{"testnet": testnet, "fastnet": fastnet},
+		"deploy", "all", "--network", "testnet", "-s", "100", "-w", "-1", "--manifest", manifest)
+
+	liteClientAddr, txCheckerAddr := plannedAddresses(keyBlock, validators, liteClientCode, txCheckerCode)
+	if report.Result.LiteClient != liteClientAddr.String() || report.Result.TxChecker != txCheckerAddr.String() {
+		t.Fatalf("unexpected addresses: %s, %s", report.Result.LiteClient, report.Result.TxChecker)
+	}
+	if len(report.Result.Externals) != 2 {
+		t.Fatalf("expected 2 external messages, got %d", len(report.Result.Externals))
+	}
+	for i, addr := range []*address.Address{liteClientAddr, txCheckerAddr} {
+		msg := report.Result.Externals[i].Messages[0]
+		if msg.Destination != addr.String() || !msg.Deploy {
+			t.Fatalf("unexpected deploy message %d: %+v", i, msg)
+		}
+	}
+}
+
+func TestDeployAllResume(t *testing.T) {
+	liteClientCode, txCheckerCode := prepareContractCode(t)
+	validators := blocktest.NewValidators(40, 30, 20, 10)
+	keyBlock, _ := prepareSignedBlocks(validators)
+	fastnet := &tonclient.Fixtures{}
+	prepareBlock(fastnet, keyBlock)
+	liteClientAddr, txCheckerAddr := plannedAddresses(keyBlock, validators, liteClientCode, txCheckerCode)
+
+	// the previous run deployed the LiteClient and failed to deploy the TxChecker
+	testnet := &tonclient.Fixtures{}
+	master := prepareWallet(t, testnet)
+	accounts := cell.NewDict(256)
+	liteClient := blocktest.Account(liteClientAddr, 1, liteClientCode, cell.BeginCell().EndCell())
+	err := accounts.Set(
+		cell.BeginCell().MustStoreSlice(liteClientAddr.Data(), 256).EndCell(),
+		blocktest.ShardAccount(liteClient, 1, 1, make([]byte, 32)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = testnet.Add(
+		ton.GetAccountState{ID: master, Account: ton.AccountID{Workchain: -1, ID: liteClientAddr.Data()}},
+		ton.AccountState{
+			ID:    master,
+			Shard: master,
+			Proof: []*cell.Cell{cell.BeginCell().EndCell(), cell.BeginCell().MustStoreRef(blocktest.ShardState(-1, 200, accounts)).EndCell()},
+			State: liteClient,
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := filepath.Join(t.TempDir(), "deployment.json")
+	data := fmt.Sprintf(`{"network": "testnet", "workchain": -1, "trusted_block_seqno": 100, "lite_client": {"address": %q}}`, liteClientAddr)
+	if err = os.WriteFile(manifest, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the trusted block and the workchain are taken from the manifest
+	report := runDryRun(t, map[string]*tonclient.Fixtures{"testnet": testnet, "fastnet": fastnet},
+		"deploy", "all", "--network", "testnet", "--manifest", manifest)
+
+	if report.Result.LiteClient != liteClientAddr.String() || report.Result.TxChecker != txCheckerAddr.String() {
+		t.Fatalf("unexpected addresses: %s, %s", report.Result.LiteClient, report.Result.TxChecker)
+	}
+	if len(report.Result.Externals) != 1 {
+		t.Fatalf("expected only the TxChecker deploy, got %d external messages", len(report.Result.Externals))
+	}
+	if msg := report.Result.Externals[0].Messages[0]; msg.Destination != txCheckerAddr.String() || !msg.Deploy {
+		t.Fatalf("unexpected deploy message: %+v", msg)
+	}
+	// a dry run does not change the manifest
+	if saved, err := os.ReadFile(manifest); err != nil || string(saved) != data {
+		t.Fatalf("manifest is changed: %s, %v", saved, err)
+	}
+}
+
+func TestDeployPlan(t *testing.T) {
+	liteClientCode, txCheckerCode := prepareContractCode(t)
+	validators := blocktest.NewValidators(40, 30, 20, 10)
+	keyBlock, block := prepareSignedBlocks(validators)
+	fastnet := &tonclient.Fixtures{}
+	// block 110 is not a key block, so the previous key block is trusted
+	prepareBlock(fastnet, keyBlock)
+	prepareBlock(fastnet, block)
+	liteClientAddr, txCheckerAddr := plannedAddresses(keyBlock, validators, liteClientCode, txCheckerCode)
+
+	// the LiteClient is already deployed, the TxChecker is not
+	testnet := &tonclient.Fixtures{}
+	master := prepareMasterchainInfo(testnet, 200)
+	accounts := cell.NewDict(256)
+	liteClient := blocktest.Account(liteClientAddr, 1, liteClientCode, cell.BeginCell().EndCell())
+	err := accounts.Set(
+		cell.BeginCell().MustStoreSlice(liteClientAddr.Data(), 256).EndCell(),
+		blocktest.ShardAccount(liteClient, 1, 1, make([]byte, 32)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateProof := cell.BeginCell().MustStoreRef(blocktest.ShardState(-1, 200, accounts)).EndCell()
+	for addr, state := range map[*address.Address]*cell.Cell{liteClientAddr: liteClient, txCheckerAddr: nil} {
+		err = testnet.Add(
+			ton.GetAccountState{ID: master, Account: ton.AccountID{Workchain: addr.Workchain(), ID: addr.Data()}},
+			ton.AccountState{ID: master, Shard: master, Proof: []*cell.Cell{cell.BeginCell().EndCell(), stateProof}, State: state},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := runWithFixtures(t, map[string]*tonclient.Fixtures{"testnet": testnet, "fastnet": fastnet},
+		"deploy", "plan", "--network", "testnet", "-s", "110", "-w", "-1", "-o", "json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	type contract struct {
+		Address   string
+		CodeHash  string `json:"code_hash"`
+		Status    string
+		StateInit string `json:"state_init"`
+	}
+	var report struct {
+		Result struct {
+			TrustedBlockSeqno uint32   `json:"trusted_block_seqno"`
+			LiteClient        contract `json:"lite_client"`
+			TxChecker         contract `json:"tx_checker"`
+		}
+	}
+	if err = json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("output is not a JSON object: %v: %s", err, out)
+	}
+	if report.Result.TrustedBlockSeqno != 100 {
+		t.Fatalf("unexpected trusted block %d", report.Result.TrustedBlockSeqno)
+	}
+
+	for _, c := range []struct {
+		report contract
+		addr   *address.Address
+		code   *cell.Cell
+		status tlb.AccountStatus
+	}{
+		{report.Result.LiteClient, liteClientAddr, liteClientCode, tlb.AccountStatusActive},
+		{report.Result.TxChecker, txCheckerAddr, txCheckerCode, tlb.AccountStatusNonExist},
+	} {
+		if c.report.Address != c.addr.String() || c.report.CodeHash != hex.EncodeToString(c.code.Hash()) || c.report.Status != string(c.status) {
+			t.Fatalf("unexpected plan of %s: %+v", c.addr, c.report)
+		}
+		// the address is the hash of the reported StateInit
+		boc, err := hex.DecodeString(c.report.StateInit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stateInit, err := cell.FromBOC(boc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(stateInit.Hash(), c.addr.Data()) {
+			t.Fatalf("state init of %s does not match its address", c.addr)
+		}
+	}
+}
+
+func TestWatchAccountRetriesUncommittedTx(t *testing.T) {
+	account := address.NewAddress(0, 0, bytes.Repeat([]byte{0x22}, 32))
+	tx := blocktest.Transaction(account.Data(), 1000, nil)
+	fastnet := &tonclient.Fixtures{}
+	// txresolver lists transactions by 16
+	prepareHistories(fastnet, 16, map[*address.Address][]*cell.Cell{account: {tx}})
+
+	// masterchain block 101 registers the next block of the shard instead of the block of the transaction
+	shardBlock := &blocktest.Block{Workchain: 0, Seqno: 10, MasterRef: 100}
+	err := fastnet.Add(
+		ton.LookupBlock{
+			Mode: 2,
+			ID:   &ton.BlockInfoShort{Workchain: 0, Shard: int64(binary.BigEndian.Uint64(account.Data()[:8]))},
+			LT:   1000,
+		},
+		ton.BlockHeader{ID: shardBlock.ID(), HeaderProof: []byte{}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fastnet.Add(ton.GetBlockData{ID: shardBlock.ID()}, ton.BlockData{ID: shardBlock.ID(), Payload: shardBlock.BOC()}); err != nil {
+		t.Fatal(err)
+	}
+	master := (&blocktest.Block{Workchain: -1, Seqno: 101}).ID()
+	err = fastnet.Add(
+		ton.LookupBlock{Mode: 1, ID: &ton.BlockInfoShort{Workchain: -1, Shard: 0, Seqno: 101}},
+		ton.BlockHeader{ID: master, HeaderProof: []byte{}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fastnet.Add(ton.GetAllShardsInfo{ID: master}, ton.AllShardsInfo{
+		ID:    master,
+		Proof: []*cell.Cell{cell.BeginCell().EndCell()},
+		Data: cell.BeginCell().MustStoreDict(blocktest.ShardHashes(map[int32]*cell.Cell{
+			0: blocktest.ShardDescr(11, bytes.Repeat([]byte{0x0B}, 32)),
+		})).EndCell(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := jobs.Open(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	txCheckerAddr := address.MustParseAddr(testLiteClientAddr)
+	w := &accountWatcher{
+		source:    tonclient.NewTonClientFixtures(fastnet),
+		txChecker: txchecker.New(txCheckerAddr, nil),
+		account:   account,
+	}
+	runner, err := newJobRunner(watchAccountCmd, store, w.sendCheckTx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, _, err := store.Add(&jobs.Job{
+		Kind:    jobs.KindCheckTx,
+		Network: "testnet",
+		Target:  txCheckerAddr.String(),
+		Key:     hex.EncodeToString(tx.Hash()),
+		Account: account.String(),
+		LT:      1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job, err = runner.Step(context.Background(), job)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.State != jobs.StatePending || job.Attempts != 1 || !job.NextAttempt.After(time.Now()) ||
+		!strings.Contains(job.LastError, blockutils.ErrNotRegistered.Error()) {
+		t.Fatalf("expected the job to be retried, got %+v", job)
+	}
+}
+
+func TestSendJobMessage(t *testing.T) {
+	testnet := &tonclient.Fixtures{}
+	prepareWallet(t, testnet)
+	tonClient = tonclient.NewTonClientFixtures(testnet)
+	t.Cleanup(func() { tonClient = nil })
+	message := wallet.SimpleMessage(address.MustParseAddr(testLiteClientAddr), tlb.MustFromTON("1"), cell.BeginCell().EndCell())
+
+	// the message is not sent if its hash can not be saved
+	errSave := errors.New("database is locked")
+	var saved []byte
+	err := sendJobMessage(context.Background(), message, func(inMsgHash []byte) error {
+		saved = inMsgHash
+		return errSave
+	})
+	if !errors.Is(err, errSave) || len(saved) != 32 {
+		t.Fatalf("expected the save error, got %v", err)
+	}
+
+	// the message is sent after its hash is saved, and the liteserver has no fixture for it
+	var sent []byte
+	err = sendJobMessage(context.Background(), message, func(inMsgHash []byte) error {
+		sent = inMsgHash
+		return nil
+	})
+	if !errors.Is(err, tonclient.ErrNoFixture) || len(sent) != 32 {
+		t.Fatalf("expected the message to be sent, got %v", err)
+	}
+}
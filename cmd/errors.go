@@ -0,0 +1,153 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/accountproof"
+	"github.com/rsquad/trustless-bridge-cli/internal/batch"
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/jobs"
+	"github.com/rsquad/trustless-bridge-cli/internal/keystore"
+	"github.com/rsquad/trustless-bridge-cli/internal/msgtrace"
+	"github.com/rsquad/trustless-bridge-cli/internal/signer"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/txutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/verifier"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+// errorCode classifies a failure of a command. It is reported in json output
+// and determines the exit code of the process.
+type errorCode string
+
+const (
+	codeInternal     errorCode = "internal"
+	codeInvalidInput errorCode = "invalid_input"
+	codeConfig       errorCode = "config"
+	codeNetwork      errorCode = "network"
+	codeNotFound     errorCode = "not_found"
+	codeVerification errorCode = "verification_failed"
+	codeRejected     errorCode = "rejected"
+)
+
+var exitCodes = map[errorCode]int{
+	codeInternal:     1,
+	codeInvalidInput: 2,
+	codeConfig:       2,
+	codeNetwork:      3,
+	codeNotFound:     4,
+	codeVerification: 5,
+	codeRejected:     6,
+}
+
+// cliError attaches a code to an error that is not recognized by errorCodeOf.
+type cliError struct {
+	code errorCode
+	err  error
+}
+
+func (e *cliError) Error() string {
+	return e.err.Error()
+}
+
+func (e *cliError) Unwrap() error {
+	return e.err
+}
+
+// inputError formats an error caused by invalid flags or input files.
+func inputError(format string, args ...any) error {
+	return &cliError{code: codeInvalidInput, err: fmt.Errorf(format, args...)}
+}
+
+var sentinelCodes = []struct {
+	err  error
+	code errorCode
+}{
+	{msgtrace.ErrRejected, codeRejected},
+	{ton.ErrMessageNotAccepted, codeRejected},
+	{batch.ErrIncomplete, codeRejected},
+	{batch.ErrTooLarge, codeInvalidInput},
+	{tonclient.ErrUnknownNetwork, codeConfig},
+	{tonclient.ErrWalletConfig, codeConfig},
+	{tonclient.ErrContractConfig, codeConfig},
+	{keystore.ErrWrongPassphrase, codeConfig},
+	{keystore.ErrNoPassphrase, codeConfig},
+	{keystore.ErrNotFound, codeNotFound},
+	{keystore.ErrExists, codeInvalidInput},
+	{keystore.ErrInvalidName, codeInvalidInput},
+	{jobs.ErrNotFound, codeNotFound},
+	{jobs.ErrInvalidState, codeInvalidInput},
+	{signer.ErrUnavailable, codeNetwork},
+	{signer.ErrInvalidEndpoint, codeConfig},
+	{ton.ErrBlockNotFound, codeNotFound},
+	{ton.ErrTxWasNotFound, codeNotFound},
+	{txutils.ErrTxNotFound, codeNotFound},
+	{txutils.ErrOutMsgNotFound, codeNotFound},
+	{ton.ErrNoProof, codeNotFound},
+	{blockutils.ErrNotCommitted, codeNotFound},
+	{blockutils.ErrNotRegistered, codeNotFound},
+	{blockutils.ErrZerostate, codeInvalidInput},
+	{accountproof.ErrAccountNotFound, codeNotFound},
+	{tonclient.ErrNoFixture, codeNetwork},
+	{tonclient.ErrOffline, codeNetwork},
+	{liteclient.ErrNoConnections, codeNetwork},
+	{liteclient.ErrNoActiveConnections, codeNetwork},
+	{liteclient.ErrADNLReqTimeout, codeNetwork},
+	{liteclient.ErrNoNodesLeft, codeNetwork},
+	{ton.ErrTxWasNotConfirmed, codeNetwork},
+	{context.DeadlineExceeded, codeNetwork},
+	{verifier.ErrUnknownStrategy, codeInvalidInput},
+	{verifier.ErrInvalidMargin, codeInvalidInput},
+	{verifier.ErrNotMerkleProof, codeVerification},
+	{verifier.ErrInvalidProof, codeVerification},
+	{verifier.ErrInvalidFileHash, codeVerification},
+	{verifier.ErrNoSignatures, codeVerification},
+	{verifier.ErrMalformedSignature, codeVerification},
+	{verifier.ErrUnknownValidator, codeVerification},
+	{verifier.ErrInvalidSignature, codeVerification},
+	{verifier.ErrInsufficientWeight, codeVerification},
+	{verifier.ErrNotKeyBlock, codeVerification},
+	{verifier.ErrNoValidatorsInProof, codeVerification},
+	{blockutils.ErrInvalidSignatures, codeVerification},
+}
+
+func errorCodeOf(err error) errorCode {
+	var ce *cliError
+	if errors.As(err, &ce) {
+		return ce.code
+	}
+	for _, s := range sentinelCodes {
+		if errors.Is(err, s.err) {
+			return s.code
+		}
+	}
+	var lsErr ton.LSError
+	if errors.As(err, &lsErr) {
+		if lsErr.Code == 651 {
+			return codeNotFound
+		}
+		return codeNetwork
+	}
+	return codeInternal
+}
+
+func exitCodeOf(err error) int {
+	return exitCodes[errorCodeOf(err)]
+}
@@ -17,6 +17,7 @@ package cmd
 import (
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 
 	"github.com/rsquad/trustless-bridge-cli/internal/liteclient"
@@ -34,7 +35,7 @@ var getLiteClientStorageCmd = &cobra.Command{
 		}
 		addr, err := address.ParseAddr(addrStr)
 		if err != nil {
-			return fmt.Errorf("failed to parse address: %w", err)
+			return inputError("failed to parse address: %w", err)
 		}
 
 		liteClient := liteclient.New(addr, tonClient)
@@ -42,13 +43,16 @@ var getLiteClientStorageCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to get storage: %w", err)
 		}
-		fmt.Printf("Epoch hash: %s\n", hex.EncodeToString(storage.EpochHash))
-		fmt.Printf("Validators total weight: %d\n", storage.ValidatorsTotalWeight)
-		json, err := liteclient.ValidatorDictToJSON(storage.ValidatorDict)
+		setResult("epoch_hash", hex.EncodeToString(storage.EpochHash))
+		setResult("validators_total_weight", storage.ValidatorsTotalWeight)
+		printf("Epoch hash: %s\n", hex.EncodeToString(storage.EpochHash))
+		printf("Validators total weight: %d\n", storage.ValidatorsTotalWeight)
+		validatorsJSON, err := liteclient.ValidatorDictToJSON(storage.ValidatorDict)
 		if err != nil {
 			return fmt.Errorf("failed to marshal validator dict: %w", err)
 		}
-		fmt.Printf("Validator dict: %s\n", json)
+		setResult("validator_dict", json.RawMessage(validatorsJSON))
+		printf("Validator dict: %s\n", validatorsJSON)
 		return nil
 	},
 }
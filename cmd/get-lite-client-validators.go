@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/rsquad/trustless-bridge-cli/internal/liteclient"
@@ -33,7 +34,7 @@ var getLiteClientValidatorsCmd = &cobra.Command{
 		}
 		addr, err := address.ParseAddr(addrStr)
 		if err != nil {
-			return fmt.Errorf("failed to parse address: %w", err)
+			return inputError("failed to parse address: %w", err)
 		}
 
 		liteClient := liteclient.New(addr, tonClient)
@@ -41,11 +42,12 @@ var getLiteClientValidatorsCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to get validators: %w", err)
 		}
-		json, err := liteclient.ValidatorDictToJSON(validatorDict)
+		validatorsJSON, err := liteclient.ValidatorDictToJSON(validatorDict)
 		if err != nil {
 			return fmt.Errorf("failed to marshal validator dict: %w", err)
 		}
-		fmt.Printf("Validator dict: %s\n", json)
+		setResult("validator_dict", json.RawMessage(validatorsJSON))
+		printf("Validator dict: %s\n", validatorsJSON)
 		return nil
 	},
 }
@@ -21,9 +21,10 @@ import (
 var getCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Run a contract getter",
-	Run: func(cmd *cobra.Command, args []string) {
-		cmd.Help()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
 	},
+	Annotations: map[string]string{offlineAnnotation: "true"},
 }
 
 func init() {
@@ -0,0 +1,205 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/jobs"
+	"github.com/rsquad/trustless-bridge-cli/internal/msgtrace"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Inspect and manage the messages submitted by relay and watch-account",
+	Long: `Every key block sent by relay and every transaction proven by watch-account is a job
+in the jobs database (jobs_db, --jobs-db). A job is pending until its external message is built,
+sending from the moment the hash of the message is saved until it is sent, sent until
+the wallet transaction of the external message is found, and then confirmed, rejected or bounced
+by the answer of the contract. A job that was sending when the command stopped is confirmed
+by the hash of its message, and sent again only if the message expires.
+
+Failed sends and external messages that expire before the wallet processes them are sent
+again with exponential backoff. Retried jobs are sent by the next poll of relay or watch-account
+of the same contract.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+	Annotations: map[string]string{offlineAnnotation: "true"},
+}
+
+func init() {
+	rootCmd.AddCommand(jobsCmd)
+}
+
+const (
+	// expiryMargin is added to the TTL of wallet messages before a sent message is considered expired,
+	// as the wallet transaction is found a few blocks after it is executed.
+	expiryMargin = time.Minute
+	// jobPollInterval is the delay between the attempts to confirm a sent job.
+	jobPollInterval = 5 * time.Second
+)
+
+func defaultJobsDB() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "trustless-bridge-cli", "jobs.db")
+}
+
+func openJobs() (*jobs.Store, error) {
+	path := viper.GetString("jobs_db")
+	if path == "" {
+		return nil, &cliError{code: codeConfig, err: fmt.Errorf("jobs_db is not set")}
+	}
+	return jobs.Open(path)
+}
+
+// parseJobID parses the id argument of the jobs commands.
+func parseJobID(arg string) (uint64, error) {
+	id, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil {
+		return 0, inputError("failed to parse job id: %w", err)
+	}
+	return id, nil
+}
+
+// addJobFlags adds the flags of the retry schedule of the jobs of a command.
+func addJobFlags(cmd *cobra.Command) {
+	cmd.Flags().Duration("retry-interval", 10*time.Second, "Delay before a failed job is sent again, doubled with every attempt")
+	cmd.Flags().Duration("max-retry-interval", 10*time.Minute, "Maximum delay before a failed job is sent again")
+	cmd.Flags().Duration("answer-timeout", time.Minute, "How long to wait for the answer of the contract before checking a sent job again")
+}
+
+// newJobRunner creates the runner of the jobs of the command. confirm is called with
+// the wallet transaction of a sent job and judges the answer of the contract.
+func newJobRunner(
+	cmd *cobra.Command,
+	store *jobs.Store,
+	send func(ctx context.Context, job *jobs.Job, sending func(inMsgHash []byte) error) error,
+	follow func(ctx context.Context, tx *tlb.Transaction) (*msgtrace.Verdict, error),
+) (*jobs.Runner, error) {
+	retryInterval, err := cmd.Flags().GetDuration("retry-interval")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retry interval: %w", err)
+	}
+	maxRetryInterval, err := cmd.Flags().GetDuration("max-retry-interval")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get max retry interval: %w", err)
+	}
+	answerTimeout, err := cmd.Flags().GetDuration("answer-timeout")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answer timeout: %w", err)
+	}
+	if retryInterval <= 0 || maxRetryInterval < retryInterval {
+		return nil, inputError("retry interval must be positive and not exceed the max retry interval")
+	}
+	if answerTimeout <= 0 {
+		return nil, inputError("answer timeout must be positive: %s", answerTimeout)
+	}
+
+	return &jobs.Runner{
+		Store:        store,
+		Backoff:      jobs.Backoff{Initial: retryInterval, Max: maxRetryInterval},
+		Expiry:       tonclient.MessageTTL() + expiryMargin,
+		PollInterval: jobPollInterval,
+		Send:         send,
+		Confirm: func(ctx context.Context, job *jobs.Job, inMsgHash []byte) (*jobs.Outcome, error) {
+			ctx, cancel := context.WithTimeout(ctx, answerTimeout)
+			defer cancel()
+			return confirmJob(ctx, inMsgHash, job.SentAt, follow)
+		},
+		Fatal: func(err error) bool {
+			return errors.Is(err, tonclient.ErrWalletConfig)
+		},
+	}, nil
+}
+
+// sendJobMessage builds the external message of the wallet with the message of a job
+// and sends it once sending has saved the hash of the message.
+func sendJobMessage(ctx context.Context, message *wallet.Message, sending func(inMsgHash []byte) error) error {
+	ext, err := tonClient.BuildExternal(ctx, []*wallet.Message{message})
+	if err != nil {
+		return err
+	}
+	if err = sending(ext.Body.Hash()); err != nil {
+		return err
+	}
+	return tonClient.SendExternal(ctx, ext)
+}
+
+// confirmJob finds the wallet transaction of the external message sent at sentAt and turns
+// the answer of the contract into the outcome of the job.
+func confirmJob(
+	ctx context.Context,
+	inMsgHash []byte,
+	sentAt time.Time,
+	follow func(ctx context.Context, tx *tlb.Transaction) (*msgtrace.Verdict, error),
+) (*jobs.Outcome, error) {
+	tx, err := tonClient.FindWalletTransaction(ctx, inMsgHash, sentAt)
+	if errors.Is(err, ton.ErrTxWasNotFound) {
+		return nil, jobs.ErrNotLanded
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find wallet transaction: %w", err)
+	}
+
+	verdict, err := follow(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the answer: %w", err)
+	}
+	outcome := &jobs.Outcome{State: jobs.StateConfirmed, WalletTx: tx.Hash}
+	if !verdict.Accepted {
+		outcome.State = jobs.StateRejected
+		if verdict.Bounced {
+			outcome.State = jobs.StateBounced
+		}
+		outcome.Reason = verdict.Reason
+	}
+	return outcome, nil
+}
+
+// matchJobs matches the jobs of the kind sent to the contract in the target network.
+func matchJobs(kind jobs.Kind, target *address.Address) func(*jobs.Job) bool {
+	return func(job *jobs.Job) bool {
+		return job.Kind == kind && job.Network == network && job.Target == target.String()
+	}
+}
+
+// printJob prints the job as a line of jobs list.
+func printJob(job *jobs.Job) {
+	printf("%d\t%s\t%s\t%s\t%s\t%s\tattempts: %d", job.ID, job.Kind, job.Key, job.State, job.Network, job.Target, job.Attempts)
+	if job.State == jobs.StatePending && job.Attempts > 0 {
+		printf("\tnext: %s", job.NextAttempt.Format(time.RFC3339))
+	}
+	if job.LastError != "" {
+		printf("\t%s", job.LastError)
+	}
+	printf("\n")
+}
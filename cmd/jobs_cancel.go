@@ -0,0 +1,62 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+var jobsCancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "Stop sending or confirming a pending or sent job",
+	Long: `Cancels the job, so that relay and watch-account neither send nor confirm it.
+The message of a sent job may still be processed by the contract.
+Key blocks are relayed in order, so relay does not pass a cancelled key block until it is retried.`,
+	Args:        cobra.ExactArgs(1),
+	RunE:        runJobsCancel,
+	Annotations: map[string]string{offlineAnnotation: "true"},
+}
+
+func init() {
+	jobsCmd.AddCommand(jobsCancelCmd)
+}
+
+func runJobsCancel(cmd *cobra.Command, args []string) error {
+	id, err := parseJobID(args[0])
+	if err != nil {
+		return err
+	}
+	store, err := openJobs()
+	if err != nil {
+		return err
+	}
+	previous, err := store.Get(id)
+	if err != nil {
+		return err
+	}
+	job, err := store.Cancel(id)
+	if err != nil {
+		return err
+	}
+	if previous.State.InFlight() {
+		log.Printf("Attention: external message %s of job %d was sent and may still be processed", previous.InMsgHash, job.ID)
+	}
+
+	printf("Job %d (%s %s) is cancelled\n", job.ID, job.Kind, job.Key)
+	setResult("job", job)
+	return nil
+}
@@ -0,0 +1,88 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/jobs"
+	"github.com/spf13/cobra"
+)
+
+var jobsListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List the jobs in the jobs database",
+	Args:        cobra.NoArgs,
+	RunE:        runJobsList,
+	Annotations: map[string]string{offlineAnnotation: "true"},
+}
+
+func init() {
+	jobsCmd.AddCommand(jobsListCmd)
+	jobsListCmd.Flags().StringSlice("state", nil, fmt.Sprintf("List only the jobs in these states: %v", jobs.States))
+	jobsListCmd.Flags().String("kind", "", fmt.Sprintf("List only the jobs of this kind: %s or %s", jobs.KindKeyBlock, jobs.KindCheckTx))
+}
+
+func runJobsList(cmd *cobra.Command, args []string) error {
+	stateNames, err := cmd.Flags().GetStringSlice("state")
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+	kind, err := cmd.Flags().GetString("kind")
+	if err != nil {
+		return fmt.Errorf("failed to get kind: %w", err)
+	}
+	if kind != "" && kind != string(jobs.KindKeyBlock) && kind != string(jobs.KindCheckTx) {
+		return inputError("unknown job kind: %s", kind)
+	}
+
+	var states []jobs.State
+	for _, name := range stateNames {
+		state, err := parseJobState(name)
+		if err != nil {
+			return err
+		}
+		states = append(states, state)
+	}
+
+	store, err := openJobs()
+	if err != nil {
+		return err
+	}
+	list, err := store.List(states...)
+	if err != nil {
+		return err
+	}
+
+	result := make([]*jobs.Job, 0, len(list))
+	for _, job := range list {
+		if kind != "" && job.Kind != jobs.Kind(kind) {
+			continue
+		}
+		printJob(job)
+		result = append(result, job)
+	}
+	setResult("jobs", result)
+	return nil
+}
+
+func parseJobState(name string) (jobs.State, error) {
+	for _, state := range jobs.States {
+		if string(state) == name {
+			return state, nil
+		}
+	}
+	return "", inputError("unknown job state: %s", name)
+}
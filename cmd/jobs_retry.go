@@ -0,0 +1,53 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var jobsRetryCmd = &cobra.Command{
+	Use:   "retry <id>",
+	Short: "Send a pending, rejected, bounced or cancelled job again",
+	Long: `Makes the job pending and due now. It is sent by the next poll of relay or watch-account
+of the same contract. Sent jobs can not be retried, as their message may still be processed;
+they become pending by themselves if their external message expires.`,
+	Args:        cobra.ExactArgs(1),
+	RunE:        runJobsRetry,
+	Annotations: map[string]string{offlineAnnotation: "true"},
+}
+
+func init() {
+	jobsCmd.AddCommand(jobsRetryCmd)
+}
+
+func runJobsRetry(cmd *cobra.Command, args []string) error {
+	id, err := parseJobID(args[0])
+	if err != nil {
+		return err
+	}
+	store, err := openJobs()
+	if err != nil {
+		return err
+	}
+	job, err := store.Retry(id)
+	if err != nil {
+		return err
+	}
+
+	printf("Job %d (%s %s) is pending\n", job.ID, job.Kind, job.Key)
+	setResult("job", job)
+	return nil
+}
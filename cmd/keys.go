@@ -0,0 +1,111 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/keystore"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage wallet keys in the encrypted keystore",
+	Long: `Wallet keys are kept in the keystore directory (keystore_dir, --keystore-dir), encrypted
+with a passphrase. Commands that send messages use the key named by wallet_name (--wallet)
+instead of the plaintext wallet_mnemonic.
+
+The passphrase is read from the file of wallet_passphrase_file (--passphrase-file),
+the WALLET_PASSPHRASE environment variable, or a terminal prompt.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+	Annotations: map[string]string{offlineAnnotation: "true"},
+}
+
+func init() {
+	rootCmd.AddCommand(keysCmd)
+}
+
+func defaultKeystoreDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "trustless-bridge-cli", "keystore")
+}
+
+func openKeystore() (*keystore.Keystore, error) {
+	dir := viper.GetString("keystore_dir")
+	if dir == "" {
+		return nil, &cliError{code: codeConfig, err: fmt.Errorf("keystore_dir is not set")}
+	}
+	return keystore.Open(dir), nil
+}
+
+// addMnemonicPasswordFlags adds the flags of the optional password of a mnemonic.
+func addMnemonicPasswordFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("with-password", false, "The mnemonic has a password, read it from a terminal prompt")
+	cmd.Flags().String("mnemonic-password-file", "", "File with the password of the mnemonic")
+}
+
+// readMnemonicPassword returns the password of the mnemonic, empty if there is none.
+func readMnemonicPassword(cmd *cobra.Command) (string, error) {
+	path, err := cmd.Flags().GetString("mnemonic-password-file")
+	if err != nil {
+		return "", fmt.Errorf("failed to get mnemonic password file: %w", err)
+	}
+	withPassword, err := cmd.Flags().GetBool("with-password")
+	if err != nil {
+		return "", fmt.Errorf("failed to get with password: %w", err)
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", inputError("failed to read mnemonic password file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	if !withPassword {
+		return "", nil
+	}
+	password, err := keystore.ReadSecret("Mnemonic password")
+	if err != nil {
+		return "", err
+	}
+	again, err := keystore.ReadSecret("Repeat mnemonic password")
+	if err != nil {
+		return "", err
+	}
+	if !bytes.Equal(password, again) {
+		return "", inputError("mnemonic passwords do not match")
+	}
+	return string(password), nil
+}
+
+// reportKey prints the public part of a key.
+func reportKey(key *keystore.Key) {
+	printf("Name: %s\n", key.Name)
+	printf("Public key: %x\n", key.PublicKey)
+	setResult("name", key.Name)
+	setResult("public_key", fmt.Sprintf("%x", key.PublicKey))
+}
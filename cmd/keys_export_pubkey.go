@@ -0,0 +1,64 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var keysExportPubkeyCmd = &cobra.Command{
+	Use:   "export-pubkey <name>",
+	Short: "Print the public key of a key in the keystore",
+	Long: `This command prints the public key of a key. It does not need the passphrase,
+the public key is stored in the clear.`,
+	Args:        cobra.ExactArgs(1),
+	RunE:        runKeysExportPubkey,
+	Annotations: map[string]string{offlineAnnotation: "true"},
+}
+
+func init() {
+	keysCmd.AddCommand(keysExportPubkeyCmd)
+	keysExportPubkeyCmd.Flags().StringP("format", "f", "hex", "Output format: hex or base64")
+}
+
+func runKeysExportPubkey(cmd *cobra.Command, args []string) error {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return fmt.Errorf("failed to get format: %w", err)
+	}
+	if format != "hex" && format != "base64" {
+		return inputError("invalid format %q, expected hex or base64", format)
+	}
+	ks, err := openKeystore()
+	if err != nil {
+		return err
+	}
+	key, err := ks.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	setResult("name", key.Name)
+	setResult("public_key", fmt.Sprintf("%x", key.PublicKey))
+	if format == "base64" {
+		printf("%s\n", base64.StdEncoding.EncodeToString(key.PublicKey))
+	} else {
+		printf("%x\n", key.PublicKey)
+	}
+	return nil
+}
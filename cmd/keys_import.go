@@ -0,0 +1,81 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/keystore"
+	"github.com/spf13/cobra"
+)
+
+var keysImportCmd = &cobra.Command{
+	Use:   "import <name>",
+	Short: "Store an existing wallet mnemonic in the keystore",
+	Long: `This command reads a mnemonic from --mnemonic-file or a terminal prompt and stores it
+in the keystore encrypted with a passphrase.`,
+	Args:        cobra.ExactArgs(1),
+	RunE:        runKeysImport,
+	Annotations: map[string]string{offlineAnnotation: "true"},
+}
+
+func init() {
+	keysCmd.AddCommand(keysImportCmd)
+	addMnemonicPasswordFlags(keysImportCmd)
+	keysImportCmd.Flags().String("mnemonic-file", "", "File with the mnemonic words separated by spaces")
+}
+
+func runKeysImport(cmd *cobra.Command, args []string) error {
+	path, err := cmd.Flags().GetString("mnemonic-file")
+	if err != nil {
+		return fmt.Errorf("failed to get mnemonic file: %w", err)
+	}
+	ks, err := openKeystore()
+	if err != nil {
+		return err
+	}
+
+	var mnemonic []byte
+	if path != "" {
+		if mnemonic, err = os.ReadFile(path); err != nil {
+			return inputError("failed to read mnemonic file: %w", err)
+		}
+	} else if mnemonic, err = keystore.ReadSecret("Mnemonic"); err != nil {
+		return err
+	}
+	password, err := readMnemonicPassword(cmd)
+	if err != nil {
+		return err
+	}
+
+	secret := &keystore.Secret{Mnemonic: strings.Fields(string(mnemonic)), Password: password}
+	if _, err = secret.PrivateKey(); err != nil {
+		return inputError("invalid mnemonic: %w", err)
+	}
+
+	passphrase, err := keystore.ReadPassphrase(fmt.Sprintf("Passphrase of key %s", args[0]), true)
+	if err != nil {
+		return err
+	}
+	key, err := ks.Add(args[0], secret, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to add key: %w", err)
+	}
+
+	reportKey(key)
+	return nil
+}
@@ -0,0 +1,57 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var keysListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List the keys in the keystore",
+	Args:        cobra.NoArgs,
+	RunE:        runKeysList,
+	Annotations: map[string]string{offlineAnnotation: "true"},
+}
+
+func init() {
+	keysCmd.AddCommand(keysListCmd)
+}
+
+func runKeysList(cmd *cobra.Command, args []string) error {
+	ks, err := openKeystore()
+	if err != nil {
+		return err
+	}
+	keys, err := ks.List()
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	result := make([]map[string]any, 0, len(keys))
+	for _, key := range keys {
+		printf("%s\t%x\t%s\n", key.Name, key.PublicKey, key.CreatedAt.Format(time.RFC3339))
+		result = append(result, map[string]any{
+			"name":       key.Name,
+			"public_key": fmt.Sprintf("%x", key.PublicKey),
+			"created_at": key.CreatedAt,
+		})
+	}
+	setResult("keys", result)
+	return nil
+}
@@ -0,0 +1,79 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/keystore"
+	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
+	"github.com/spf13/cobra"
+)
+
+var keysNewCmd = &cobra.Command{
+	Use:   "new <name>",
+	Short: "Generate a new wallet mnemonic and store it in the keystore",
+	Long: `This command generates a 24-word mnemonic, optionally with a password, and stores it
+in the keystore encrypted with a passphrase. The mnemonic is printed only with --show-mnemonic,
+write it down to restore the wallet.`,
+	Args:        cobra.ExactArgs(1),
+	RunE:        runKeysNew,
+	Annotations: map[string]string{offlineAnnotation: "true"},
+}
+
+func init() {
+	keysCmd.AddCommand(keysNewCmd)
+	addMnemonicPasswordFlags(keysNewCmd)
+	keysNewCmd.Flags().Bool("show-mnemonic", false, "Print the generated mnemonic")
+}
+
+func runKeysNew(cmd *cobra.Command, args []string) error {
+	showMnemonic, err := cmd.Flags().GetBool("show-mnemonic")
+	if err != nil {
+		return fmt.Errorf("failed to get show mnemonic: %w", err)
+	}
+	ks, err := openKeystore()
+	if err != nil {
+		return err
+	}
+	password, err := readMnemonicPassword(cmd)
+	if err != nil {
+		return err
+	}
+
+	var seed []string
+	if password != "" {
+		seed = wallet.NewSeedWithPassword(password)
+	} else {
+		seed = wallet.NewSeed()
+	}
+
+	passphrase, err := keystore.ReadPassphrase(fmt.Sprintf("Passphrase of key %s", args[0]), true)
+	if err != nil {
+		return err
+	}
+	key, err := ks.Add(args[0], &keystore.Secret{Mnemonic: seed, Password: password}, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to add key: %w", err)
+	}
+
+	reportKey(key)
+	if showMnemonic {
+		printf("Mnemonic: %s\n", strings.Join(seed, " "))
+		setResult("mnemonic", strings.Join(seed, " "))
+	}
+	return nil
+}
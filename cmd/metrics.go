@@ -0,0 +1,110 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/liteclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/metrics"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/spf13/cobra"
+)
+
+// maxLagKeyBlocks limits how far back the key block chain is walked to measure the epoch lag.
+const maxLagKeyBlocks = 100
+
+// metricsEnabled is set when the metrics server is running, so that long-running
+// commands only make the extra requests for metrics that are exposed.
+var metricsEnabled bool
+
+// addMetricsFlags adds the flags of the metrics server to a long-running command.
+func addMetricsFlags(cmd *cobra.Command) {
+	cmd.Flags().String("metrics-addr", "", "Listen address of the HTTP server with /metrics and /healthz, e.g. :9100")
+	cmd.Flags().Int("max-epoch-lag", 1, "Key blocks the LiteClient may be behind the source network before /healthz fails")
+}
+
+// startMetricsServer starts the metrics server if --metrics-addr is set.
+// The returned function stops the server.
+func startMetricsServer(cmd *cobra.Command) (func(), error) {
+	addr, err := cmd.Flags().GetString("metrics-addr")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metrics address: %w", err)
+	}
+	maxLag, err := cmd.Flags().GetInt("max-epoch-lag")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get max epoch lag: %w", err)
+	}
+	if addr == "" {
+		return func() {}, nil
+	}
+	if maxLag < 0 {
+		return nil, inputError("max epoch lag must not be negative")
+	}
+
+	server, err := metrics.Serve(addr, metrics.EpochLagCheck(maxLag))
+	if err != nil {
+		return nil, inputError("failed to start metrics server: %w", err)
+	}
+	metricsEnabled = true
+	log.Printf("Serving metrics on %s", addr)
+
+	return func() {
+		metricsEnabled = false
+		server.Close()
+	}, nil
+}
+
+// updateEpochLag measures the epoch lag of the LiteClient behind the latest key block of the source network.
+func updateEpochLag(
+	ctx context.Context,
+	source *tonclient.TonClient,
+	liteClient *liteclient.LiteClientContract,
+	latestKeySeqno uint32,
+) error {
+	storage, err := liteClient.GetStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get LiteClient storage: %w", err)
+	}
+	steps, err := epochSteps(ctx, source, storage.EpochHash, latestKeySeqno, maxLagKeyBlocks)
+	if err != nil {
+		return err
+	}
+	metrics.EpochLag.Set(float64(len(steps)))
+	return nil
+}
+
+// updateWalletBalance sets the balance of the wallet of the current network.
+func updateWalletBalance(ctx context.Context) error {
+	w, err := tonClient.GetWallet()
+	if err != nil {
+		return err
+	}
+	master, err := tonClient.API.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	balance, err := w.GetBalance(ctx, master)
+	if err != nil {
+		return fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+
+	ton, _ := new(big.Float).Quo(new(big.Float).SetInt(balance.Nano()), big.NewFloat(1e9)).Float64()
+	metrics.WalletBalance.Set(ton)
+	return nil
+}
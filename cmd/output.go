@@ -0,0 +1,123 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+var outputMode string
+
+// commandReport is the single JSON object printed to stdout in json output mode.
+type commandReport struct {
+	Command string         `json:"command"`
+	OK      bool           `json:"ok"`
+	Result  map[string]any `json:"result,omitempty"`
+	Error   *reportError   `json:"error,omitempty"`
+
+	// started is set once the command passed flag validation,
+	// errors before it are usage errors.
+	started bool
+}
+
+type reportError struct {
+	Code     errorCode `json:"code"`
+	Message  string    `json:"message"`
+	ExitCode int       `json:"exit_code"`
+}
+
+var report = &commandReport{}
+
+func jsonOutput() bool {
+	return outputMode == outputJSON
+}
+
+// printf prints human-readable output. It prints nothing in json mode,
+// where the same data is reported with setResult.
+func printf(format string, args ...any) {
+	if !jsonOutput() {
+		fmt.Printf(format, args...)
+	}
+}
+
+// setResult adds a field to the result of the command in json mode.
+func setResult(key string, value any) {
+	if report.Result == nil {
+		report.Result = make(map[string]any)
+	}
+	report.Result[key] = value
+}
+
+// writeBOC prints a BOC in the output format of the command: a hex string or raw bytes.
+// In json mode the BOC is added to the result as a hex string instead.
+func writeBOC(key, format string, boc []byte) {
+	setResult(key, hex.EncodeToString(boc))
+	switch {
+	case jsonOutput():
+	case format == "hex":
+		fmt.Printf("%x\n", boc)
+	default:
+		os.Stdout.Write(boc)
+	}
+}
+
+// execute runs the root command and reports its outcome: the JSON report in json mode,
+// the error message on stderr otherwise.
+func execute() error {
+	report = &commandReport{}
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil && !report.started {
+		err = &cliError{code: codeInvalidInput, err: err}
+	}
+
+	if !jsonOutput() {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+		return err
+	}
+
+	report.Command = cmd.CommandPath()
+	report.OK = err == nil
+	if err != nil {
+		report.Error = &reportError{
+			Code:     errorCodeOf(err),
+			Message:  err.Error(),
+			ExitCode: exitCodeOf(err),
+		}
+	}
+	data, marshalErr := json.Marshal(report)
+	if marshalErr != nil {
+		return fmt.Errorf("failed to marshal report: %w", marshalErr)
+	}
+	fmt.Println(string(data))
+	return err
+}
+
+func validateOutputMode() error {
+	switch outputMode {
+	case outputText, outputJSON:
+		return nil
+	}
+	return inputError("invalid output mode %q, expected %s or %s", outputMode, outputText, outputJSON)
+}
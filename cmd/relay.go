@@ -0,0 +1,393 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/jobs"
+	"github.com/rsquad/trustless-bridge-cli/internal/liteclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/metrics"
+	"github.com/rsquad/trustless-bridge-cli/internal/msgtrace"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/spf13/cobra"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var relayCmd = &cobra.Command{
+	Use:   "relay",
+	Short: "Keep a LiteClient in sync with new key blocks",
+	Long: `This command runs until interrupted and follows the masterchain of the source network.
+Every new key block that changes the validator set (config param 34) is proven and sent
+to the LiteClient as a new_key_block message.
+If the network is specified as testnet, the system will follow fastnet
+and send new_key_block messages to LiteClient in testnet.
+
+The last processed key block is stored in the state file, so the relay can be restarted
+at any time without sending the same key block twice. Without a state file the relay
+starts from the epoch of the LiteClient and first sends the key blocks it missed. Every sent key block is a job
+in the jobs database: failed sends are retried with backoff, and a key block sent before
+a restart is confirmed by the hash of its external message instead of being sent again.
+See "jobs --help".
+
+With --metrics-addr the relay serves Prometheus metrics on /metrics: the epoch lag of the
+LiteClient, the last sent key block, proof build latency, liteserver errors and the wallet balance.
+/healthz fails while the LiteClient is more than --max-epoch-lag key blocks behind.`,
+	RunE: runRelay,
+}
+
+func init() {
+	rootCmd.AddCommand(relayCmd)
+	relayCmd.Flags().StringP("address", "a", "", "Address of the LiteClient contract")
+	addSignatureFlags(relayCmd.Flags())
+	relayCmd.Flags().Duration("interval", 30*time.Second, "Polling interval of the source masterchain")
+	relayCmd.Flags().Duration("confirm-timeout", 2*time.Minute, "How long to wait for the LiteClient to accept a key block")
+	relayCmd.Flags().String("state-file", "relay-state.json", "Path to the file with the relay progress")
+	relayCmd.MarkFlagRequired("address")
+	addJobFlags(relayCmd)
+	addMetricsFlags(relayCmd)
+}
+
+type relayState struct {
+	LastKeyBlockSeqno uint32 `json:"last_key_block_seqno"`
+	EpochHash         string `json:"epoch_hash"`
+}
+
+func loadRelayState(path string) (*relayState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &relayState{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var state relayState
+	if err = json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+	return &state, nil
+}
+
+func saveRelayState(path string, state *relayState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func runRelay(cmd *cobra.Command, args []string) error {
+	addrStr, err := cmd.Flags().GetString("address")
+	if err != nil {
+		return fmt.Errorf("failed to get address: %w", err)
+	}
+	addr, err := address.ParseAddr(addrStr)
+	if err != nil {
+		return inputError("failed to parse address: %w", err)
+	}
+	interval, err := cmd.Flags().GetDuration("interval")
+	if err != nil {
+		return fmt.Errorf("failed to get interval: %w", err)
+	}
+	confirmTimeout, err := cmd.Flags().GetDuration("confirm-timeout")
+	if err != nil {
+		return fmt.Errorf("failed to get confirm timeout: %w", err)
+	}
+	statePath, err := cmd.Flags().GetString("state-file")
+	if err != nil {
+		return fmt.Errorf("failed to get state file: %w", err)
+	}
+
+	sourceTonClient, sourceName, err := connectSource()
+	if err != nil {
+		return err
+	}
+
+	state, err := loadRelayState(statePath)
+	if err != nil {
+		return err
+	}
+	store, err := openJobs()
+	if err != nil {
+		return err
+	}
+
+	stopMetrics, err := startMetricsServer(cmd)
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+
+	log.Printf("Attention: You are relaying key blocks from %s network to LiteClient %s in %s network", sourceName, addr, network)
+	if state.LastKeyBlockSeqno != 0 {
+		log.Printf("Resuming after key block %d", state.LastKeyBlockSeqno)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	r := &keyBlockRelay{
+		source:         sourceTonClient,
+		liteClient:     liteclient.New(addr, tonClient),
+		state:          state,
+		statePath:      statePath,
+		confirmTimeout: confirmTimeout,
+	}
+	r.runner, err = newJobRunner(cmd, store, r.sendKeyBlock, r.liteClient.NewKeyBlockVerdict)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.poll(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("relay iteration failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Relay stopped")
+			setResult("last_key_block_seqno", r.state.LastKeyBlockSeqno)
+			setResult("epoch_hash", r.state.EpochHash)
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+type keyBlockRelay struct {
+	source         *tonclient.TonClient
+	liteClient     *liteclient.LiteClientContract
+	state          *relayState
+	statePath      string
+	confirmTimeout time.Duration
+	runner         *jobs.Runner
+}
+
+// poll looks for key blocks that appeared in the source masterchain since the last
+// processed one and relays them in ascending order.
+func (r *keyBlockRelay) poll(ctx context.Context) error {
+	if err := r.runner.RunDue(ctx, matchJobs(jobs.KindKeyBlock, r.liteClient.Addr)); err != nil {
+		return err
+	}
+
+	latestKeySeqno, err := latestKeyBlockSeqno(ctx, r.source)
+	if err != nil {
+		return err
+	}
+	if metricsEnabled {
+		defer r.updateMetrics(ctx, latestKeySeqno)
+	}
+	if latestKeySeqno <= r.state.LastKeyBlockSeqno {
+		return nil
+	}
+
+	pending, err := r.pendingKeyBlocks(ctx, latestKeySeqno)
+	if err != nil {
+		return err
+	}
+
+	for _, seqno := range pending {
+		epochHash, err := r.relayKeyBlock(ctx, seqno)
+		if err != nil {
+			return fmt.Errorf("failed to relay key block %d: %w", seqno, err)
+		}
+
+		r.state.LastKeyBlockSeqno = seqno
+		r.state.EpochHash = fmt.Sprintf("%x", epochHash)
+		if err = saveRelayState(r.statePath, r.state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pendingKeyBlocks walks the key block chain back from latestSeqno until the last
+// processed key block. Without a saved state it walks back to the epoch of the LiteClient
+// like sync does, so a LiteClient that missed several epochs catches up.
+func (r *keyBlockRelay) pendingKeyBlocks(ctx context.Context, latestSeqno uint32) ([]uint32, error) {
+	if r.state.LastKeyBlockSeqno == 0 {
+		return r.missedKeyBlocks(ctx, latestSeqno)
+	}
+
+	chain, err := blockutils.WalkKeyBlocks(ctx, r.source, latestSeqno, 0, func(keyBlock *blockutils.KeyBlock) bool {
+		return keyBlock.ID.SeqNo <= r.state.LastKeyBlockSeqno
+	})
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]uint32, len(chain))
+	for i, keyBlock := range chain {
+		pending[i] = keyBlock.ID.SeqNo
+	}
+	return pending, nil
+}
+
+// missedKeyBlocks returns the key blocks that rotated the validator set since the epoch
+// of the LiteClient. If the LiteClient is in sync, the latest key block is returned,
+// so it is stored as processed without being sent.
+func (r *keyBlockRelay) missedKeyBlocks(ctx context.Context, latestSeqno uint32) ([]uint32, error) {
+	storage, err := r.liteClient.GetStorage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get LiteClient storage: %w", err)
+	}
+	steps, err := epochSteps(ctx, r.source, storage.EpochHash, latestSeqno, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return []uint32{latestSeqno}, nil
+	}
+	pending := make([]uint32, len(steps))
+	for i, step := range steps {
+		pending[i] = step.ID.SeqNo
+	}
+	return pending, nil
+}
+
+// relayKeyBlock sends the key block to the LiteClient if its validator set differs from
+// the stored one and waits until the LiteClient switches to the new epoch.
+func (r *keyBlockRelay) relayKeyBlock(ctx context.Context, seqno uint32) ([]byte, error) {
+	_, blockBOC, err := blockutils.FetchMasterchainBlockBOC(ctx, r.source, seqno)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch masterchain block: %w", err)
+	}
+	blockCell, err := cell.FromBOC(blockBOC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse block BOC: %w", err)
+	}
+	var block tlb.Block
+	if err = tlb.LoadFromCell(&block, blockCell.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse block: %w", err)
+	}
+	if !block.BlockInfo.KeyBlock {
+		return nil, fmt.Errorf("block %d is not a key block", seqno)
+	}
+
+	_, _, epochHash, err := blockutils.ExtractMainValidators(&block, r.source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract main validators: %w", err)
+	}
+
+	storage, err := r.liteClient.GetStorage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get LiteClient storage: %w", err)
+	}
+	if bytes.Equal(storage.EpochHash, epochHash) {
+		log.Printf("Key block %d does not change the epoch %x, skipping", seqno, epochHash)
+		return epochHash, nil
+	}
+
+	job, _, err := r.runner.Store.Add(&jobs.Job{
+		Kind:    jobs.KindKeyBlock,
+		Network: network,
+		Target:  r.liteClient.Addr.String(),
+		Key:     strconv.FormatUint(uint64(seqno), 10),
+		Seqno:   seqno,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if job, err = r.runner.Run(ctx, job); err != nil {
+		return nil, err
+	}
+	if job.State != jobs.StateConfirmed {
+		return nil, fmt.Errorf("%w: job %d is %s: %s", msgtrace.ErrRejected, job.ID, job.State, job.LastError)
+	}
+	log.Printf("NewKeyBlock %d confirmed in wallet transaction %s", seqno, job.WalletTx)
+	metrics.LastKeyBlockSeqno.Set(float64(seqno))
+
+	if err = r.waitEpoch(ctx, epochHash); err != nil {
+		return nil, err
+	}
+	log.Printf("LiteClient switched to epoch %x", epochHash)
+
+	return epochHash, nil
+}
+
+// sendKeyBlock proves the key block of the job and sends it to the LiteClient.
+func (r *keyBlockRelay) sendKeyBlock(ctx context.Context, job *jobs.Job, sending func(inMsgHash []byte) error) error {
+	blockIDExt, blockBOC, err := blockutils.FetchMasterchainBlockBOC(ctx, r.source, job.Seqno)
+	if err != nil {
+		return fmt.Errorf("failed to fetch masterchain block: %w", err)
+	}
+
+	started := time.Now()
+	signaturesMap, err := GetBlockSignatures(job.Seqno, r.source)
+	if err != nil {
+		return fmt.Errorf("failed to get block signatures: %w", err)
+	}
+	blockProof, err := blockutils.BuildBlockProof(blockBOC)
+	if err != nil {
+		return fmt.Errorf("failed to build block proof: %w", err)
+	}
+	metrics.ProofBuildSeconds.Observe(time.Since(started).Seconds())
+
+	message := r.liteClient.NewKeyBlockMessage(blockIDExt.FileHash, blockProof, SignaturesMapToDict(signaturesMap))
+	if err = sendJobMessage(ctx, message, sending); err != nil {
+		return fmt.Errorf("failed to send new key block: %w", err)
+	}
+	return nil
+}
+
+// updateMetrics measures the epoch lag of the LiteClient behind the latest key block
+// and the wallet balance. Failures are only logged, the lag keeps its last value.
+func (r *keyBlockRelay) updateMetrics(ctx context.Context, latestKeySeqno uint32) {
+	if err := updateEpochLag(ctx, r.source, r.liteClient, latestKeySeqno); err != nil {
+		log.Printf("failed to measure epoch lag: %v", err)
+	}
+	if err := updateWalletBalance(ctx); err != nil {
+		log.Printf("failed to update wallet balance: %v", err)
+	}
+}
+
+func (r *keyBlockRelay) waitEpoch(ctx context.Context, epochHash []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, r.confirmTimeout)
+	defer cancel()
+
+	for {
+		storage, err := r.liteClient.GetStorage(ctx)
+		if err == nil && bytes.Equal(storage.EpochHash, epochHash) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("LiteClient did not accept epoch %x in %s", epochHash, r.confirmTimeout)
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
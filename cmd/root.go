@@ -16,26 +16,51 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 var cfgFile string
 var tonClient *tonclient.TonClient
 var network string
+var sourceNetwork string
 var rootCmd = &cobra.Command{
 	Use:   "trustless-bridge-cli",
 	Short: "A CLI tool for data preparation and retrieval for the Trustless Bridge",
+	// Errors are printed by execute, either as text or as a part of the JSON report.
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		report.started = true
+		cmd.SilenceUsage = true
+		if err := validateOutputMode(); err != nil {
+			return err
+		}
+		if cmd.Annotations[offlineAnnotation] == "true" {
+			return nil
+		}
+		if err := connect(); err != nil {
+			return err
+		}
+		if cmd.Flags().Lookup("dry-run") != nil {
+			return applySendOptions(cmd)
+		}
+		return nil
+	},
 }
 
+// offlineAnnotation marks commands that work with local files only
+// and must not require a connection to the network.
+const offlineAnnotation = "offline"
+
+// Execute runs the command and exits with the exit code of its error class.
 func Execute() {
-	err := rootCmd.Execute()
-	if err != nil {
-		os.Exit(1)
+	if err := execute(); err != nil {
+		os.Exit(exitCodeOf(err))
 	}
 }
 
@@ -49,7 +74,84 @@ func init() {
 		"config file (default is $HOME/.trustless-bridge-cli.yaml)",
 	)
 
-	rootCmd.PersistentFlags().StringVar(&network, "network", "testnet", "TON network (testnet or mainnet)")
+	rootCmd.PersistentFlags().StringVar(
+		&network,
+		"network",
+		"testnet",
+		"TON network to connect to: testnet, fastnet or a network profile from the config file (alias --target-network)",
+	)
+	rootCmd.SetGlobalNormalizationFunc(normalizeFlagName)
+	rootCmd.PersistentFlags().String(
+		"cache-dir",
+		defaultCacheDir(),
+		"Directory of the cache of blocks, block proofs and validator sets, empty to disable the cache",
+	)
+	rootCmd.PersistentFlags().Bool(
+		"offline",
+		false,
+		"Do not connect to liteservers and use only the data in the cache",
+	)
+	rootCmd.PersistentFlags().String(
+		"keystore-dir",
+		defaultKeystoreDir(),
+		"Directory of the encrypted wallet keys",
+	)
+	rootCmd.PersistentFlags().String(
+		"jobs-db",
+		defaultJobsDB(),
+		"Database of the messages submitted by relay and watch-account",
+	)
+	rootCmd.PersistentFlags().String(
+		"wallet",
+		"",
+		"Name of the wallet key in the keystore, instead of wallet_mnemonic",
+	)
+	rootCmd.PersistentFlags().String(
+		"passphrase-file",
+		"",
+		"File with the passphrase of the wallet key, instead of WALLET_PASSPHRASE or a prompt",
+	)
+	rootCmd.PersistentFlags().String(
+		"signer",
+		"",
+		"Endpoint of the signing daemon of the wallet, unix:///path/to/socket or http://host:port",
+	)
+	rootCmd.PersistentFlags().String(
+		"signer-token-file",
+		"",
+		"File with the token of the signing daemon",
+	)
+	viper.BindPFlag("wallet_signer", rootCmd.PersistentFlags().Lookup("signer"))
+	viper.BindPFlag("wallet_signer_token_file", rootCmd.PersistentFlags().Lookup("signer-token-file"))
+	viper.BindPFlag("keystore_dir", rootCmd.PersistentFlags().Lookup("keystore-dir"))
+	viper.BindPFlag("jobs_db", rootCmd.PersistentFlags().Lookup("jobs-db"))
+	viper.BindPFlag("wallet_name", rootCmd.PersistentFlags().Lookup("wallet"))
+	viper.BindPFlag("wallet_passphrase_file", rootCmd.PersistentFlags().Lookup("passphrase-file"))
+	viper.BindPFlag("cache_dir", rootCmd.PersistentFlags().Lookup("cache-dir"))
+	viper.BindPFlag("offline", rootCmd.PersistentFlags().Lookup("offline"))
+	viper.SetDefault("cache_max_size", 512<<20)
+
+	rootCmd.PersistentFlags().StringVarP(
+		&outputMode,
+		"output",
+		"o",
+		outputText,
+		"Output mode: text, or json to print a single JSON object with the result or the error",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&sourceNetwork,
+		"source-network",
+		"",
+		"Network blocks and transactions are taken from (default is the source of the target network profile)",
+	)
+}
+
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "trustless-bridge-cli")
 }
 
 func initConfig() {
@@ -69,16 +171,51 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
+}
 
-	if network != "testnet" && network != "fastnet" {
-		fmt.Printf("invalid network: %s", network)
-		fmt.Println("using testnet")
-		network = "testnet"
+func connect() error {
+	if tonClient != nil {
+		return nil
 	}
 
 	var err error
 	tonClient, err = tonclient.NewTonClientNetwork(network)
 	if err != nil {
-		log.Fatalf("failed to create TonClient: %v", err)
+		return fmt.Errorf("failed to create TonClient: %w", err)
+	}
+	return nil
+}
+
+// normalizeFlagName makes --target-network an alias of --network, the network the contracts are deployed to.
+func normalizeFlagName(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	if name == "target-network" {
+		name = "network"
+	}
+	return pflag.NormalizedName(name)
+}
+
+// connectSource creates a TonClient of the network blocks and transactions are taken from.
+// Unless --source-network is set, it is the source of the target network profile,
+// so testnet and fastnet are the source of each other.
+func connectSource() (*tonclient.TonClient, string, error) {
+	name := sourceNetwork
+	if name == "" {
+		profile, err := tonclient.LoadNetworkProfile(network)
+		if err != nil {
+			return nil, "", err
+		}
+		name = profile.Source
+	}
+	if name == "" {
+		return nil, "", &cliError{code: codeConfig, err: fmt.Errorf("source network of %s is not set, use --source-network", network)}
+	}
+	if name == network {
+		return nil, "", inputError("source and target networks are the same: %s", name)
+	}
+
+	client, err := tonclient.NewTonClientNetwork(name)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create TonClient of %s: %w", name, err)
 	}
+	return client, name, nil
 }
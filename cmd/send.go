@@ -1,19 +1,99 @@
 package cmd
 
 import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/msgtrace"
 	"github.com/spf13/cobra"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
 )
 
 var sendCmd = &cobra.Command{
 	Use:   "send",
 	Short: "Send a message to a contract",
-	Run: func(cmd *cobra.Command, args []string) {
-		cmd.Help()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
 	},
+	Annotations: map[string]string{offlineAnnotation: "true"},
 }
 
 func init() {
 	rootCmd.AddCommand(sendCmd)
 	sendCmd.PersistentFlags().StringP("address", "a", "", "Address of the contract")
 	sendCmd.MarkFlagRequired("address")
+	addSendOptionFlags(sendCmd)
+	addSignatureFlags(sendCmd.PersistentFlags())
+	sendCmd.PersistentFlags().Duration(
+		"answer-timeout",
+		2*time.Minute,
+		"How long to follow the message chain for the answer of the contract, 0 to skip",
+	)
+}
+
+// awaitVerdict follows the message chain of the sent transaction with the answer timeout,
+// prints the verdict and returns an error unless the contract accepted the message.
+// The verdict is nil if following the chain is disabled.
+func awaitVerdict(
+	cmd *cobra.Command,
+	follow func(ctx context.Context) (*msgtrace.Verdict, error),
+) (*msgtrace.Verdict, error) {
+	timeout, err := cmd.Flags().GetDuration("answer-timeout")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answer timeout: %w", err)
+	}
+	if timeout == 0 {
+		return nil, nil
+	}
+	if timeout < 0 {
+		return nil, inputError("answer timeout must not be negative: %s", timeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	verdict, err := follow(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the answer: %w", err)
+	}
+
+	hops := make([]map[string]any, 0, len(verdict.Hops))
+	for _, hop := range verdict.Hops {
+		exitCode, _ := msgtrace.ExitCode(hop.Tx)
+		printf("Message %s -> %s", hop.Msg.SrcAddr, hop.Msg.DstAddr)
+		if hop.Msg.Bounced {
+			printf(" (bounced)")
+		}
+		printf(": transaction lt: %v, hash: %x, exit code: %d\n", hop.Tx.LT, hop.Tx.Hash, exitCode)
+		hops = append(hops, map[string]any{
+			"src":       hop.Msg.SrcAddr.String(),
+			"dst":       hop.Msg.DstAddr.String(),
+			"bounced":   hop.Msg.Bounced,
+			"tx_lt":     hop.Tx.LT,
+			"tx_hash":   hex.EncodeToString(hop.Tx.Hash),
+			"exit_code": exitCode,
+		})
+	}
+	setResult("hops", hops)
+	setResult("accepted", verdict.Accepted)
+	if !verdict.Accepted {
+		setResult("reason", verdict.Reason)
+		printf("Verdict: rejected, %s\n", verdict.Reason)
+		return verdict, verdict.Err()
+	}
+	printf("Verdict: accepted\n")
+	return verdict, nil
+}
+
+// reportSent prints the transaction of the wallet that sent the message.
+func reportSent(name string, sendTx *tlb.Transaction, inBlock *ton.BlockIDExt) {
+	printf("%s successfully sent\n", name)
+	printf("With transaction lt: %v, hash: %x\n", sendTx.LT, sendTx.Hash)
+	printf("In block: %v\n", inBlock.SeqNo)
+	setResult("tx_lt", sendTx.LT)
+	setResult("tx_hash", hex.EncodeToString(sendTx.Hash))
+	setResult("in_block", inBlock.SeqNo)
 }
@@ -2,12 +2,13 @@ package cmd
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"log"
 
 	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
 	"github.com/rsquad/trustless-bridge-cli/internal/liteclient"
-	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/msgtrace"
 	"github.com/spf13/cobra"
 	"github.com/xssnick/tonutils-go/address"
 )
@@ -28,10 +29,6 @@ func init() {
 }
 
 func runSendCheckBlock(cmd *cobra.Command, args []string) error {
-	network, err := cmd.Flags().GetString("network")
-	if err != nil {
-		network = "testnet"
-	}
 	seqno, err := cmd.Flags().GetUint32("seqno")
 	if err != nil {
 		return fmt.Errorf("failed to get seqno: %w", err)
@@ -42,28 +39,24 @@ func runSendCheckBlock(cmd *cobra.Command, args []string) error {
 	}
 	addr, err := address.ParseAddr(addrStr)
 	if err != nil {
-		return fmt.Errorf("failed to parse address: %w", err)
+		return inputError("failed to parse address: %w", err)
 	}
 
-	oppositeNetwork := "testnet"
-	if network == "testnet" {
-		oppositeNetwork = "fastnet"
-	}
-	oppositeTonClient, err := tonclient.NewTonClientNetwork(oppositeNetwork)
+	sourceTonClient, sourceName, err := connectSource()
 	if err != nil {
-		return fmt.Errorf("failed to create TonClient: %w", err)
+		return err
 	}
 
-	log.Printf("Attention: You are sending a message to the %s network with block %d from %s network", network, seqno, oppositeNetwork)
+	log.Printf("Attention: You are sending a message to the %s network with block %d from %s network", network, seqno, sourceName)
 
-	blockIDExt, blockBOC, err := blockutils.FetchMasterchainBlockBOC(context.Background(), oppositeTonClient, seqno)
+	blockIDExt, blockBOC, err := blockutils.FetchMasterchainBlockBOC(context.Background(), sourceTonClient, seqno)
 	if err != nil {
 		return fmt.Errorf("failed to fetch masterchain block: %w", err)
 	}
 
 	liteClient := liteclient.New(addr, tonClient)
 
-	signaturesMap, err := GetBlockSignatures(seqno, oppositeTonClient)
+	signaturesMap, err := GetBlockSignatures(seqno, sourceTonClient)
 	if err != nil {
 		return fmt.Errorf("failed to get block signatures: %w", err)
 	}
@@ -80,13 +73,27 @@ func runSendCheckBlock(cmd *cobra.Command, args []string) error {
 		blockProof,
 		signaturesDict,
 	)
+	if dryRunDone(err) {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to send check block: %w", err)
 	}
 
-	fmt.Printf("CheckBlock successfully sent\n")
-	fmt.Printf("With transaction lt: %v, hash: %x\n", sendTx.LT, sendTx.Hash)
-	fmt.Printf("In block: %v\n", blockIDExt.SeqNo)
+	reportSent("CheckBlock", sendTx, blockIDExt)
+
+	verdict, err := awaitVerdict(cmd, func(ctx context.Context) (*msgtrace.Verdict, error) {
+		return liteClient.CheckBlockVerdict(ctx, sendTx)
+	})
+	if err != nil || verdict == nil {
+		return err
+	}
+	answer, err := liteclient.ParseBlockAnswer(verdict.Answer.Body)
+	if err != nil {
+		return fmt.Errorf("failed to parse check_block_answer: %w", err)
+	}
+	setResult("block_hash", hex.EncodeToString(answer.BlockHash))
+	printf("Block is correct: %x\n", answer.BlockHash)
 
 	return nil
 }
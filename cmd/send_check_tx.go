@@ -2,15 +2,20 @@ package cmd
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 
 	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/msgtrace"
 	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
 	"github.com/rsquad/trustless-bridge-cli/internal/txchecker"
 	"github.com/rsquad/trustless-bridge-cli/internal/txutils"
 	"github.com/spf13/cobra"
 	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
@@ -19,7 +24,19 @@ var sendCheckTxCmd = &cobra.Command{
 	Short: "Send a check_transaction message to a TxChecker",
 	Long: `This command sends a check_transaction message to a TxChecker.
 If the network is specified as testnet, the system will fetch a block and transaction from fastnet
-and send a check_transaction message to TxChecker in testnet.`,
+and send a check_transaction message to TxChecker in testnet.
+
+By default the transaction is looked up in the masterchain block with the given seqno.
+With --workchain 0 the transaction is looked up in the shardchain blocks registered in that
+masterchain block. In this case the proof field contains the transaction proof in the shard block,
+and the block proof in current_block also keeps the ShardHashes branch with the shard block.
+
+With --out-msg the proof keeps an outbound message of the transaction (see "tx proof --out-msg"),
+so that the TxChecker can check the message the transaction sent.
+
+Instead of --seqno, --workchain and --tx-hash the transaction can be selected by its account,
+then the masterchain block and the workchain are looked up on the network.
+` + txSelectorUsage,
 	RunE: runSendCheckTx,
 }
 
@@ -27,15 +44,14 @@ func init() {
 	sendCmd.AddCommand(sendCheckTxCmd)
 	sendCheckTxCmd.Flags().Uint32P("seqno", "s", 0, "Block seqno")
 	sendCheckTxCmd.Flags().BytesHexP("tx-hash", "t", nil, "Transaction hash in hexadecimal format")
-	sendCheckTxCmd.MarkFlagRequired("seqno")
-	sendCheckTxCmd.MarkFlagRequired("tx-hash")
+	sendCheckTxCmd.Flags().Int32P("workchain", "w", -1, "Workchain of the transaction")
+	sendCheckTxCmd.Flags().String("out-msg", "", "Outbound message to keep in the proof: index in OutMsgs or message hash in hexadecimal format")
+	addTxSelectorFlags(sendCheckTxCmd)
+	sendCheckTxCmd.MarkFlagsMutuallyExclusive("account", "seqno")
+	sendCheckTxCmd.MarkFlagsMutuallyExclusive("account", "workchain")
 }
 
 func runSendCheckTx(cmd *cobra.Command, args []string) error {
-	network, err := cmd.Flags().GetString("network")
-	if err != nil {
-		network = "testnet"
-	}
 	seqno, err := cmd.Flags().GetUint32("seqno")
 	if err != nil {
 		return fmt.Errorf("failed to get seqno: %w", err)
@@ -44,55 +60,147 @@ func runSendCheckTx(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get tx hash: %w", err)
 	}
+	workchain, err := cmd.Flags().GetInt32("workchain")
+	if err != nil {
+		return fmt.Errorf("failed to get workchain: %w", err)
+	}
 	addrStr, err := cmd.Flags().GetString("address")
 	if err != nil {
 		return fmt.Errorf("failed to get address: %w", err)
 	}
 	addr, err := address.ParseAddr(addrStr)
 	if err != nil {
-		return fmt.Errorf("failed to parse address: %w", err)
+		return inputError("failed to parse address: %w", err)
 	}
-
-	oppositeNetwork := "testnet"
-	if network == "testnet" {
-		oppositeNetwork = "fastnet"
+	outMsg, err := getOutMsgSelector(cmd)
+	if err != nil {
+		return err
 	}
-	oppositeTonClient, err := tonclient.NewTonClientNetwork(oppositeNetwork)
+	query, err := getTxQuery(cmd, txHash)
 	if err != nil {
-		return fmt.Errorf("failed to create TonClient: %w", err)
+		return err
+	}
+	if query == nil && (!cmd.Flags().Changed("seqno") || len(txHash) == 0) {
+		return inputError("either --seqno and --tx-hash or --account must be set")
 	}
 
-	log.Printf("Attention: You are sending a message to the %s network with transaction %x and block %d from %s network", network, txHash, seqno, oppositeNetwork)
+	sourceTonClient, sourceName, err := connectSource()
+	if err != nil {
+		return err
+	}
 
-	blockIDExt, blockBOC, err := blockutils.FetchMasterchainBlockBOC(context.Background(), oppositeTonClient, seqno)
+	if query != nil {
+		loc, err := resolveTx(context.Background(), sourceTonClient, query)
+		if err != nil {
+			return fmt.Errorf("failed to locate transaction: %w", err)
+		}
+		seqno, workchain, txHash = loc.Masterchain.SeqNo, loc.Block.Workchain, loc.Tx.Hash
+	}
+
+	log.Printf("Attention: You are sending a message to the %s network with transaction %x and block %d from %s network", network, txHash, seqno, sourceName)
+
+	proof, err := buildCheckTx(context.Background(), sourceTonClient, seqno, workchain, txHash, outMsg)
 	if err != nil {
-		return fmt.Errorf("failed to fetch masterchain block: %w", err)
+		return err
 	}
+	if err = reportOutMsg(proof.tx, outMsg); err != nil {
+		return err
+	}
+
+	txChecker := txchecker.New(addr, tonClient)
 
-	blockCell, err := cell.FromBOC(blockBOC)
+	sendTx, blockIDExt, err := txChecker.SendCheckTx(
+		context.Background(),
+		txchecker.TxToCell(proof.tx),
+		proof.txProof,
+		proof.currentBlock,
+	)
+	if dryRunDone(err) {
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to parse block BOC: %w", err)
+		return fmt.Errorf("failed to send check tx: %w", err)
 	}
 
-	txProofCell, tx, err := txutils.BuildTxProof(blockCell, txHash)
+	reportSent(fmt.Sprintf("CheckTx for tx %x", txHash), sendTx, blockIDExt)
+
+	verdict, err := awaitVerdict(cmd, func(ctx context.Context) (*msgtrace.Verdict, error) {
+		return txChecker.CheckTxVerdict(ctx, sendTx)
+	})
+	if err != nil || verdict == nil {
+		return err
+	}
+	checkedTx, err := txchecker.ParseTransactionChecked(verdict.Answer.Body)
+	if err != nil {
+		return fmt.Errorf("failed to parse transaction_checked: %w", err)
+	}
+	checkedHash, err := checkedTx.BeginParse().LoadSlice(256)
 	if err != nil {
-		return fmt.Errorf("failed to build tx proof: %w", err)
+		return fmt.Errorf("failed to parse checked transaction: %w", err)
 	}
+	setResult("checked_tx_hash", hex.EncodeToString(checkedHash))
+	printf("Transaction checked: %x\n", checkedHash)
 
-	txChecker := txchecker.New(addr, tonClient)
+	return nil
+}
 
-	tx.Hash = txHash
+// checkTxProof is the content of a check_transaction message.
+type checkTxProof struct {
+	tx           *tlb.Transaction
+	txProof      *cell.Cell
+	currentBlock *cell.Cell
+}
 
-	signaturesMap, err := GetBlockSignatures(seqno, oppositeTonClient)
+// buildCheckTx proves the transaction in the masterchain block with the given seqno,
+// or in a shard block registered in it, and collects the signatures of the block.
+func buildCheckTx(
+	ctx context.Context,
+	sourceTonClient *tonclient.TonClient,
+	seqno uint32,
+	workchain int32,
+	txHash []byte,
+	outMsg *txutils.OutMsgSelector,
+) (*checkTxProof, error) {
+	blockIDExt, blockBOC, err := blockutils.FetchMasterchainBlockBOC(ctx, sourceTonClient, seqno)
 	if err != nil {
-		return fmt.Errorf("failed to get block signatures: %w", err)
+		return nil, fmt.Errorf("failed to fetch masterchain block: %w", err)
+	}
+
+	var txProofCell, blockProof *cell.Cell
+	var tx *tlb.Transaction
+	if workchain == -1 {
+		blockCell, err := cell.FromBOC(blockBOC)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse block BOC: %w", err)
+		}
+
+		if outMsg != nil {
+			txProofCell, tx, err = txutils.BuildTxProofWithOutMsg(blockCell, txHash, *outMsg)
+		} else {
+			txProofCell, tx, err = txutils.BuildTxProof(blockCell, txHash)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to build tx proof: %w", err)
+		}
+
+		blockProof, err = blockutils.BuildBlockProof(blockBOC)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build block proof: %w", err)
+		}
+	} else {
+		txProofCell, blockProof, tx, err = buildShardTxProof(ctx, sourceTonClient, blockIDExt, blockBOC, workchain, txHash, outMsg)
+		if err != nil {
+			return nil, err
+		}
 	}
-	signaturesDict := SignaturesMapToDict(signaturesMap)
 
-	blockProof, err := blockutils.BuildBlockProof(blockBOC)
+	tx.Hash = txHash
+
+	signaturesMap, err := GetBlockSignatures(seqno, sourceTonClient)
 	if err != nil {
-		return fmt.Errorf("failed to build block proof: %w", err)
+		return nil, fmt.Errorf("failed to get block signatures: %w", err)
 	}
+	signaturesDict := SignaturesMapToDict(signaturesMap)
 
 	currentBlockCell := cell.BeginCell().
 		MustStoreRef(
@@ -103,19 +211,40 @@ func runSendCheckTx(cmd *cobra.Command, args []string) error {
 		).MustStoreRef(signaturesDict.AsCell()).
 		EndCell()
 
-	sendTx, blockIDExt, err := txChecker.SendCheckTx(
-		context.Background(),
-		txchecker.TxToCell(tx),
-		txProofCell,
-		currentBlockCell,
-	)
+	return &checkTxProof{tx: tx, txProof: txProofCell, currentBlock: currentBlockCell}, nil
+}
+
+// buildShardTxProof looks for the transaction in the shardchain blocks registered
+// in the masterchain block and proves it together with the shard block registration.
+func buildShardTxProof(
+	ctx context.Context,
+	tonClient *tonclient.TonClient,
+	mcBlockIDExt *ton.BlockIDExt,
+	mcBlockBOC []byte,
+	workchain int32,
+	txHash []byte,
+	outMsg *txutils.OutMsgSelector,
+) (*cell.Cell, *cell.Cell, *tlb.Transaction, error) {
+	shardIDs, shardBOCs, err := blockutils.FetchShardBlocksBOC(ctx, tonClient, mcBlockIDExt, workchain)
 	if err != nil {
-		return fmt.Errorf("failed to send check tx: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to fetch shard blocks: %w", err)
 	}
 
-	fmt.Printf("CheckTx for tx %x successfully sent\n", txHash)
-	fmt.Printf("With transaction lt: %v, hash: %x\n", sendTx.LT, sendTx.Hash)
-	fmt.Printf("In block: %v\n", blockIDExt.SeqNo)
+	for i, shardBOC := range shardBOCs {
+		shardCell, err := cell.FromBOC(shardBOC)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to parse shard block BOC: %w", err)
+		}
+
+		txProofCell, blockProof, tx, err := txutils.BuildShardTxProof(shardCell, mcBlockBOC, txHash, outMsg)
+		if errors.Is(err, txutils.ErrTxNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to build tx proof in shard block %d: %w", shardIDs[i].SeqNo, err)
+		}
+		return txProofCell, blockProof, tx, nil
+	}
 
-	return nil
+	return nil, nil, nil, fmt.Errorf("%w: %x in shard blocks of masterchain block %d", txutils.ErrTxNotFound, txHash, mcBlockIDExt.SeqNo)
 }
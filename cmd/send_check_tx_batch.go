@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/batch"
+	"github.com/rsquad/trustless-bridge-cli/internal/fees"
+	"github.com/rsquad/trustless-bridge-cli/internal/msgtrace"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/txchecker"
+	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+var sendCheckTxBatchCmd = &cobra.Command{
+	Use:   "check-tx-batch",
+	Short: "Send check_transaction messages for many transactions from a highload wallet",
+	Long: `This command sends check_transaction messages to a TxChecker for every transaction in a file.
+Each line of the file is "<seqno> <tx hash> [workchain]", like --seqno, --tx-hash and --workchain
+of check-tx. The workchain is -1 if omitted. Empty lines and lines starting with # are skipped.
+
+The proofs are built concurrently and packed into as few external messages of the wallet
+as the message size limits of the network allow. The wallet must be a highload v3 wallet,
+set wallet_version to highloadv3.
+
+After sending, the message chain of every transaction is followed like in check-tx,
+and the outcome of every transaction is printed. The command fails unless all transactions
+were accepted.`,
+	RunE: runSendCheckTxBatch,
+}
+
+func init() {
+	sendCmd.AddCommand(sendCheckTxBatchCmd)
+	sendCheckTxBatchCmd.Flags().StringP("file", "f", "", "File with lines of <seqno> <tx hash> [workchain]")
+	sendCheckTxBatchCmd.Flags().Int("concurrency", 8, "Number of proofs built and chains followed at the same time")
+	sendCheckTxBatchCmd.MarkFlagRequired("file")
+}
+
+// Outcomes of the transactions of a batch.
+const (
+	batchProofFailed = "proof_failed"
+	batchSendFailed  = "send_failed"
+	batchDryRun      = "dry_run"
+	batchSent        = "sent"
+	batchAccepted    = "accepted"
+	batchRejected    = "rejected"
+	batchUnknown     = "unknown"
+)
+
+// batchItem is a transaction of the batch with its message and outcome.
+type batchItem struct {
+	entry  *batch.Entry
+	msg    *wallet.Message
+	status string
+	err    error
+	sendTx *tlb.Transaction
+}
+
+func runSendCheckTxBatch(cmd *cobra.Command, args []string) error {
+	path, err := cmd.Flags().GetString("file")
+	if err != nil {
+		return fmt.Errorf("failed to get file: %w", err)
+	}
+	concurrency, err := cmd.Flags().GetInt("concurrency")
+	if err != nil {
+		return fmt.Errorf("failed to get concurrency: %w", err)
+	}
+	if concurrency < 1 {
+		return inputError("concurrency must be positive: %d", concurrency)
+	}
+	timeout, err := cmd.Flags().GetDuration("answer-timeout")
+	if err != nil {
+		return fmt.Errorf("failed to get answer timeout: %w", err)
+	}
+	if timeout < 0 {
+		return inputError("answer timeout must not be negative: %s", timeout)
+	}
+	addrStr, err := cmd.Flags().GetString("address")
+	if err != nil {
+		return fmt.Errorf("failed to get address: %w", err)
+	}
+	addr, err := address.ParseAddr(addrStr)
+	if err != nil {
+		return inputError("failed to parse address: %w", err)
+	}
+	if version := viper.GetString("wallet_version"); !strings.EqualFold(version, "highloadv3") {
+		return fmt.Errorf("%w: check-tx-batch requires wallet_version highloadv3, got %q", tonclient.ErrWalletConfig, version)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return inputError("failed to open file: %w", err)
+	}
+	entries, err := batch.ParseEntries(file)
+	file.Close()
+	if err != nil {
+		return inputError("failed to parse %s: %w", path, err)
+	}
+	if len(entries) == 0 {
+		return inputError("no transactions in %s", path)
+	}
+
+	sourceTonClient, sourceName, err := connectSource()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Attention: You are sending check_transaction messages for %d transactions to the %s network from %s network", len(entries), network, sourceName)
+
+	ctx := context.Background()
+	txChecker := txchecker.New(addr, tonClient)
+	items := buildBatchItems(ctx, sourceTonClient, txChecker, entries, concurrency)
+
+	var messages []*wallet.Message
+	itemOf := map[*wallet.Message]*batchItem{}
+	for _, item := range items {
+		if item.msg != nil {
+			messages = append(messages, item.msg)
+			itemOf[item.msg] = item
+		}
+	}
+
+	var sent [][]*wallet.Message
+	if len(messages) > 0 {
+		limits, err := fees.LoadMsgLimits(ctx, tonClient.API)
+		if err != nil {
+			return fmt.Errorf("failed to load message limits: %w", err)
+		}
+		batches, err := batch.Pack(messages, limits)
+		if err != nil {
+			return fmt.Errorf("failed to pack messages: %w", err)
+		}
+		log.Printf("Packed %d messages into %d external messages", len(messages), len(batches))
+
+		for i, msgs := range batches {
+			sendTx, inBlock, err := tonClient.SendManyWaitTransaction(ctx, msgs)
+			for _, msg := range msgs {
+				item := itemOf[msg]
+				switch {
+				case errors.Is(err, tonclient.ErrDryRun):
+					item.status = batchDryRun
+				case err != nil:
+					item.status, item.err = batchSendFailed, err
+				default:
+					item.status, item.sendTx = batchSent, sendTx
+				}
+			}
+			if err != nil {
+				continue
+			}
+			printf("External message %d of %d with %d messages sent, transaction lt: %v, hash: %x, in block: %v\n",
+				i+1, len(batches), len(msgs), sendTx.LT, sendTx.Hash, inBlock.SeqNo)
+			sent = append(sent, msgs)
+		}
+	}
+
+	if timeout > 0 && len(sent) > 0 {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		trackBatches(ctx, txChecker, sent, itemOf, concurrency)
+	}
+
+	return reportBatchItems(items)
+}
+
+// buildBatchItems builds the check_transaction messages of the entries, concurrency at a time.
+// Entries whose proofs failed are kept with the error.
+func buildBatchItems(
+	ctx context.Context,
+	sourceTonClient *tonclient.TonClient,
+	txChecker *txchecker.TxCheckerContract,
+	entries []*batch.Entry,
+	concurrency int,
+) []*batchItem {
+	items := make([]*batchItem, len(entries))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				entry := entries[i]
+				items[i] = &batchItem{entry: entry}
+
+				proof, err := buildCheckTx(ctx, sourceTonClient, entry.Seqno, entry.Workchain, entry.TxHash, nil)
+				if err != nil {
+					items[i].status, items[i].err = batchProofFailed, err
+					continue
+				}
+				items[i].msg = txChecker.CheckTxMessage(txchecker.TxToCell(proof.tx), proof.txProof, proof.currentBlock)
+			}
+		}()
+	}
+	for i := range entries {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return items
+}
+
+// trackBatches finds the messages the wallet sent for every batch
+// and follows their chains, concurrency at a time.
+func trackBatches(
+	ctx context.Context,
+	txChecker *txchecker.TxCheckerContract,
+	batches [][]*wallet.Message,
+	itemOf map[*wallet.Message]*batchItem,
+	concurrency int,
+) {
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, msgs := range batches {
+		sendTx := itemOf[msgs[0]].sendTx
+		sentMsgs, err := msgtrace.Sent(ctx, tonClient.API, sendTx)
+		matched := batch.Match(msgs, sentMsgs)
+
+		for i, msg := range msgs {
+			item := itemOf[msg]
+			if matched[i] == nil {
+				item.status = batchUnknown
+				item.err = errors.New("the message was not sent by the wallet")
+				if err != nil {
+					item.err = fmt.Errorf("failed to find the message sent by the wallet: %w", err)
+				}
+				continue
+			}
+
+			wg.Add(1)
+			slots <- struct{}{}
+			go func(item *batchItem, msg *tlb.InternalMessage) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+				verdict, err := txChecker.CheckTxMessageVerdict(ctx, msg)
+				switch {
+				case err != nil:
+					item.status, item.err = batchUnknown, fmt.Errorf("failed to get the answer: %w", err)
+				case verdict.Accepted:
+					item.status = batchAccepted
+				default:
+					item.status, item.err = batchRejected, verdict.Err()
+				}
+			}(item, matched[i])
+		}
+	}
+	wg.Wait()
+}
+
+// reportBatchItems prints the outcome of every transaction and fails
+// unless all of them were accepted, or sent if the chains are not followed.
+func reportBatchItems(items []*batchItem) error {
+	results := make([]map[string]any, 0, len(items))
+	counts := map[string]int{}
+	for _, item := range items {
+		counts[item.status]++
+		result := map[string]any{
+			"line":      item.entry.Line,
+			"seqno":     item.entry.Seqno,
+			"tx_hash":   hex.EncodeToString(item.entry.TxHash),
+			"workchain": item.entry.Workchain,
+			"status":    item.status,
+		}
+		if item.sendTx != nil {
+			result["send_tx_hash"] = hex.EncodeToString(item.sendTx.Hash)
+		}
+		if item.err != nil {
+			result["error"] = item.err.Error()
+			printf("%s: %s, %v\n", item.entry, item.status, item.err)
+		} else {
+			printf("%s: %s\n", item.entry, item.status)
+		}
+		results = append(results, result)
+	}
+	setResult("transactions", results)
+	setResult("counts", counts)
+
+	done := counts[batchAccepted] + counts[batchSent] + counts[batchDryRun]
+	if done < len(items) {
+		return fmt.Errorf("%w: %d of %d transactions", batch.ErrIncomplete, len(items)-done, len(items))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/spf13/cobra"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+// addSendOptionFlags adds the flags that change how the wallet sends messages
+// to all subcommands of a command group.
+func addSendOptionFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool("dry-run", false, "Build and print the external message of the wallet without sending it")
+	cmd.PersistentFlags().Bool("estimate-fees", false, "Estimate the fees of the message chain with the gas and forward prices of the network")
+	cmd.PersistentFlags().String("amount", "", "Value attached to the message in TON, overrides the default of the command")
+}
+
+// applySendOptions sets the send options of the target network client from the flags.
+func applySendOptions(cmd *cobra.Command) error {
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return fmt.Errorf("failed to get dry run: %w", err)
+	}
+	estimateFees, err := cmd.Flags().GetBool("estimate-fees")
+	if err != nil {
+		return fmt.Errorf("failed to get estimate fees: %w", err)
+	}
+	amountStr, err := cmd.Flags().GetString("amount")
+	if err != nil {
+		return fmt.Errorf("failed to get amount: %w", err)
+	}
+
+	opts := tonclient.SendOptions{DryRun: dryRun, EstimateFees: estimateFees}
+	if amountStr != "" {
+		amount, err := tlb.FromTON(amountStr)
+		if err != nil {
+			return inputError("failed to parse amount: %w", err)
+		}
+		if amount.Nano().Sign() <= 0 {
+			return inputError("amount must be positive: %s", amountStr)
+		}
+		opts.Amount = &amount
+	}
+	if dryRun || estimateFees {
+		opts.Report = reportPrepared
+	}
+	preparedMessages = nil
+	tonClient.SetSendOptions(opts)
+	return nil
+}
+
+var preparedMessages []map[string]any
+
+// reportPrepared prints an external message of the wallet before it is sent.
+func reportPrepared(prepared *tonclient.PreparedMessage) {
+	printf("Wallet: %s\n", prepared.Wallet)
+	messages := make([]map[string]any, 0, len(prepared.Messages))
+	for _, msg := range prepared.Messages {
+		result := map[string]any{
+			"destination": msg.DstAddr.String(),
+			"amount":      msg.Amount.String(),
+			"deploy":      msg.StateInit != nil,
+		}
+		if msg.StateInit != nil {
+			printf("Destination (computed address): %s\n", msg.DstAddr)
+		} else {
+			printf("Destination: %s\n", msg.DstAddr)
+		}
+		printf("Value: %s TON\n", msg.Amount)
+		if msg.Body != nil {
+			result["payload"] = hex.EncodeToString(msg.Body.ToBOC())
+			printf("Payload: %x\n", msg.Body.ToBOC())
+		}
+		messages = append(messages, result)
+	}
+	printf("External message: %x\n", prepared.External.ToBOC())
+
+	result := map[string]any{
+		"wallet":   prepared.Wallet.String(),
+		"external": hex.EncodeToString(prepared.External.ToBOC()),
+		"messages": messages,
+	}
+	if e := prepared.Estimate; e != nil {
+		total := tlb.FromNanoTON(e.Total())
+		result["fees"] = map[string]any{
+			"import":       tlb.FromNanoTON(e.ImportFee).String(),
+			"wallet_gas":   tlb.FromNanoTON(e.WalletGas).String(),
+			"forward":      tlb.FromNanoTON(e.ForwardFee).String(),
+			"contract_gas": tlb.FromNanoTON(e.ContractGas).String(),
+			"total":        total.String(),
+		}
+		printf("Estimated fees: import %s, wallet gas %s, forward %s, contract gas up to %s TON\n",
+			tlb.FromNanoTON(e.ImportFee), tlb.FromNanoTON(e.WalletGas),
+			tlb.FromNanoTON(e.ForwardFee), tlb.FromNanoTON(e.ContractGas))
+		printf("Estimated total: up to %s TON\n", total)
+	}
+
+	preparedMessages = append(preparedMessages, result)
+	setResult("externals", preparedMessages)
+}
+
+// dryRunDone reports whether err is the end of a dry run, which is not a failure.
+func dryRunDone(err error) bool {
+	if errors.Is(err, tonclient.ErrDryRun) {
+		log.Printf("Attention: dry run, the message is not sent")
+		return true
+	}
+	return false
+}
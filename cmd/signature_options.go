@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/verifier"
+	"github.com/spf13/pflag"
+)
+
+var (
+	signatureStrategy string
+	signatureMargin   float64
+)
+
+// addSignatureFlags adds the flags that choose which block signatures are sent to the LiteClient.
+func addSignatureFlags(flags *pflag.FlagSet) {
+	flags.StringVar(
+		&signatureStrategy,
+		"signature-strategy",
+		string(verifier.StrategyMinimalByWeight),
+		"Signature selection strategy: minimal-by-weight, minimal-by-count, all or threshold",
+	)
+	flags.Float64Var(
+		&signatureMargin,
+		"signature-margin",
+		10,
+		"Signed weight above 2/3 required by the threshold strategy, in percent of the total weight",
+	)
+}
+
+// selectSignatures selects the signatures of the block with the strategy of the flags
+// and reports the signed weight, the total weight and the number of signatures.
+func selectSignatures(signatures *blockutils.BlockSignatures) (map[[32]byte][]byte, error) {
+	strategy, err := verifier.ParseStrategy(signatureStrategy)
+	if err != nil {
+		return nil, err
+	}
+	selection, err := verifier.SelectSignatures(signatures.Validators, signatures.Signatures, strategy, signatureMargin)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf(
+		"Selected %d signatures of block %d with the %s strategy, signed weight %d of %d",
+		len(selection.Signatures), signatures.Block.SeqNo, strategy, selection.SignedWeight, selection.TotalWeight,
+	)
+	if strategy == verifier.StrategyThreshold && len(selection.Signatures) == selection.Signers &&
+		selection.Margin() <= signatureMargin {
+		log.Printf("Attention: signers reach a margin of %.2f%% only, all signatures are selected", selection.Margin())
+	}
+	setResult("signature_strategy", strategy)
+	setResult("signature_count", len(selection.Signatures))
+	setResult("signed_weight", selection.SignedWeight)
+	setResult("total_weight", selection.TotalWeight)
+	return selection.Signatures, nil
+}
@@ -0,0 +1,121 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/signer"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var signerCmd = &cobra.Command{
+	Use:   "signer",
+	Short: "Run a signing daemon that keeps the wallet key out of other commands",
+	Long: `This command loads the wallet key, the key named by wallet_name (--wallet) in the keystore
+or wallet_mnemonic, and signs the messages of other commands of this CLI started with
+--signer set to the endpoint of the daemon. These commands never hold the private key.
+
+The daemon listens on a unix socket (unix:///path/to/socket), created with mode 0600,
+or on a TCP address (http://127.0.0.1:7070). With --token-file, requests must carry
+the token from the file, pass the same file to --signer-token-file of the other commands.
+The daemon signs any message hash it is asked for, do not expose it to other hosts.`,
+	RunE:        runSigner,
+	Annotations: map[string]string{offlineAnnotation: "true"},
+}
+
+func init() {
+	rootCmd.AddCommand(signerCmd)
+	signerCmd.Flags().String("listen", defaultSignerEndpoint(), "Endpoint to listen on, unix:///path/to/socket or http://host:port")
+	signerCmd.Flags().String("token-file", "", "File with the token requests must carry")
+}
+
+func defaultSignerEndpoint() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return "unix://" + filepath.Join(dir, "trustless-bridge-cli", "signer.sock")
+}
+
+func runSigner(cmd *cobra.Command, args []string) error {
+	endpoint, err := cmd.Flags().GetString("listen")
+	if err != nil {
+		return fmt.Errorf("failed to get listen endpoint: %w", err)
+	}
+	tokenFile, err := cmd.Flags().GetString("token-file")
+	if err != nil {
+		return fmt.Errorf("failed to get token file: %w", err)
+	}
+	if endpoint == "" {
+		return inputError("listen endpoint is not set")
+	}
+	if viper.GetString("wallet_signer") != "" {
+		return fmt.Errorf("%w: the signing daemon cannot use wallet_signer itself", tonclient.ErrWalletConfig)
+	}
+
+	var token string
+	if tokenFile != "" {
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return inputError("failed to read token file: %w", err)
+		}
+		if token = strings.TrimSpace(string(data)); token == "" {
+			return inputError("token file %s is empty", tokenFile)
+		}
+	}
+
+	key, err := tonclient.WalletKey()
+	if err != nil {
+		return err
+	}
+	keySigner := wallet.NewKeySigner(key)
+
+	listener, err := signer.Listen(endpoint)
+	if err != nil {
+		return inputError("failed to start signer: %w", err)
+	}
+
+	server := &http.Server{Handler: signer.Handler(keySigner, token), ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	log.Printf("Signing with public key %x on %s", keySigner.PublicKey(), endpoint)
+	if token == "" {
+		log.Printf("Attention: The signer has no token, any local process that can connect to it can sign messages")
+	}
+	if err = server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("signer stopped: %w", err)
+	}
+	log.Printf("Signer stopped")
+	return nil
+}
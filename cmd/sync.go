@@ -0,0 +1,238 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/liteclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/msgtrace"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/verifier"
+	"github.com/spf13/cobra"
+	"github.com/xssnick/tonutils-go/address"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Bring a LiteClient to the latest epoch of the source network",
+	Long: `This command reads the epoch of the LiteClient and walks the key block chain of the source
+network back from the latest key block (or --to-seqno) until the key block of that epoch.
+Every later key block that rotates the validator set is then sent to the LiteClient
+as a new_key_block message, oldest first.
+
+Before sending, each key block is verified locally against the validator set the LiteClient
+will check it with. The next key block is sent only after the LiteClient accepted the
+previous one, and the command stops at the first rejection.
+If the network is specified as testnet, the system will take key blocks from fastnet
+and send them to LiteClient in testnet.`,
+	RunE: runSync,
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.Flags().StringP("address", "a", "", "Address of the LiteClient contract")
+	addSignatureFlags(syncCmd.Flags())
+	syncCmd.Flags().Uint32("to-seqno", 0, "Seqno of the key block to sync to (default is the latest key block)")
+	syncCmd.Flags().Int("max-key-blocks", 100, "How many key blocks to walk back before giving up")
+	syncCmd.Flags().Duration("answer-timeout", 2*time.Minute, "How long to wait for the LiteClient to accept a key block")
+	syncCmd.MarkFlagRequired("address")
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	addrStr, err := cmd.Flags().GetString("address")
+	if err != nil {
+		return fmt.Errorf("failed to get address: %w", err)
+	}
+	addr, err := address.ParseAddr(addrStr)
+	if err != nil {
+		return inputError("failed to parse address: %w", err)
+	}
+	toSeqno, err := cmd.Flags().GetUint32("to-seqno")
+	if err != nil {
+		return fmt.Errorf("failed to get to seqno: %w", err)
+	}
+	maxKeyBlocks, err := cmd.Flags().GetInt("max-key-blocks")
+	if err != nil {
+		return fmt.Errorf("failed to get max key blocks: %w", err)
+	}
+	timeout, err := cmd.Flags().GetDuration("answer-timeout")
+	if err != nil {
+		return fmt.Errorf("failed to get answer timeout: %w", err)
+	}
+	if timeout <= 0 {
+		return inputError("answer timeout must be positive, key blocks are sent one after another")
+	}
+
+	sourceTonClient, sourceName, err := connectSource()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	liteClient := liteclient.New(addr, tonClient)
+	storage, err := liteClient.GetStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get LiteClient storage: %w", err)
+	}
+	printf("LiteClient epoch: %x\n", storage.EpochHash)
+
+	if toSeqno == 0 {
+		toSeqno, err = latestKeyBlockSeqno(ctx, sourceTonClient)
+		if err != nil {
+			return err
+		}
+	}
+
+	steps, err := epochSteps(ctx, sourceTonClient, storage.EpochHash, toSeqno, maxKeyBlocks)
+	if err != nil {
+		return err
+	}
+	setResult("to_seqno", toSeqno)
+	if len(steps) == 0 {
+		setResult("sent", []uint32{})
+		setResult("epoch_hash", hex.EncodeToString(storage.EpochHash))
+		printf("LiteClient is in sync with key block %d\n", toSeqno)
+		return nil
+	}
+
+	log.Printf("Attention: You are sending %d key blocks from %s network to LiteClient %s in %s network", len(steps), sourceName, addr, network)
+	for _, step := range steps {
+		printf("Key block %d: epoch %x\n", step.ID.SeqNo, step.EpochHash)
+	}
+
+	set, err := verifier.ValidatorSetFromDict(storage.ValidatorDict, storage.ValidatorsTotalWeight)
+	if err != nil {
+		return fmt.Errorf("failed to load LiteClient validators: %w", err)
+	}
+
+	sent := make([]uint32, 0, len(steps))
+	setResult("sent", sent)
+	for _, step := range steps {
+		if err = sendEpochStep(cmd, sourceTonClient, liteClient, set, step); err != nil {
+			return fmt.Errorf("failed to sync key block %d: %w", step.ID.SeqNo, err)
+		}
+		sent = append(sent, step.ID.SeqNo)
+		setResult("sent", sent)
+		setResult("epoch_hash", hex.EncodeToString(step.EpochHash))
+		set = verifier.ValidatorSetFromValidators(step.Validators)
+	}
+
+	printf("LiteClient is in sync with key block %d\n", toSeqno)
+	return nil
+}
+
+func latestKeyBlockSeqno(ctx context.Context, tonClient *tonclient.TonClient) (uint32, error) {
+	master, err := tonClient.API.GetMasterchainInfo(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	block, err := tonClient.GetBlockData(ctx, master)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get masterchain block %d: %w", master.SeqNo, err)
+	}
+	if block.BlockInfo.KeyBlock {
+		return block.BlockInfo.SeqNo, nil
+	}
+	return block.BlockInfo.PrevKeyBlockSeqno, nil
+}
+
+// epochSteps walks the key block chain back from toSeqno until a key block of the given epoch
+// and returns the later key blocks that rotate the validator set, oldest first.
+func epochSteps(
+	ctx context.Context,
+	tonClient *tonclient.TonClient,
+	epochHash []byte,
+	toSeqno uint32,
+	maxKeyBlocks int,
+) ([]*blockutils.KeyBlock, error) {
+	chain, err := blockutils.WalkKeyBlocks(ctx, tonClient, toSeqno, maxKeyBlocks, func(keyBlock *blockutils.KeyBlock) bool {
+		return bytes.Equal(keyBlock.EpochHash, epochHash)
+	})
+	if errors.Is(err, blockutils.ErrNotKeyBlock) {
+		return nil, inputError("%w", err)
+	}
+	if errors.Is(err, blockutils.ErrChainEnd) {
+		return nil, fmt.Errorf("epoch %x is not found before block %d: %w", epochHash, toSeqno, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// key blocks that keep the validator set are skipped
+	var steps []*blockutils.KeyBlock
+	prevEpochHash := epochHash
+	for _, keyBlock := range chain {
+		if bytes.Equal(keyBlock.EpochHash, prevEpochHash) {
+			continue
+		}
+		steps = append(steps, keyBlock)
+		prevEpochHash = keyBlock.EpochHash
+	}
+	return steps, nil
+}
+
+// sendEpochStep verifies the key block against the current set of the LiteClient,
+// sends it and waits for the answer of the LiteClient.
+func sendEpochStep(
+	cmd *cobra.Command,
+	source *tonclient.TonClient,
+	liteClient *liteclient.LiteClientContract,
+	set *verifier.ValidatorSet,
+	step *blockutils.KeyBlock,
+) error {
+	ctx := context.Background()
+	seqno := step.ID.SeqNo
+
+	blockIDExt, blockBOC, err := blockutils.FetchMasterchainBlockBOC(ctx, source, seqno)
+	if err != nil {
+		return fmt.Errorf("failed to fetch masterchain block: %w", err)
+	}
+	signaturesMap, err := GetBlockSignatures(seqno, source)
+	if err != nil {
+		return fmt.Errorf("failed to get block signatures: %w", err)
+	}
+	signaturesDict := SignaturesMapToDict(signaturesMap)
+	blockProof, err := blockutils.BuildBlockProof(blockBOC)
+	if err != nil {
+		return fmt.Errorf("failed to build block proof: %w", err)
+	}
+
+	res, err := verifier.VerifyKeyBlock(blockProof, blockIDExt.FileHash, signaturesDict, set)
+	if err != nil {
+		return fmt.Errorf("local verification failed: %w", err)
+	}
+	if !bytes.Equal(res.EpochHash, step.EpochHash) {
+		return fmt.Errorf("%w: epoch hash %x of the proof differs from %x", verifier.ErrInvalidProof, res.EpochHash, step.EpochHash)
+	}
+
+	sendTx, inBlock, err := liteClient.SendNewKeyBlock(ctx, blockIDExt.FileHash, blockProof, signaturesDict)
+	if err != nil {
+		return fmt.Errorf("failed to send new key block: %w", err)
+	}
+	printf("NewKeyBlock %d sent with transaction lt: %v, hash: %x in block %v\n", seqno, sendTx.LT, sendTx.Hash, inBlock.SeqNo)
+
+	_, err = awaitVerdict(cmd, func(ctx context.Context) (*msgtrace.Verdict, error) {
+		return liteClient.NewKeyBlockVerdict(ctx, sendTx)
+	})
+	return err
+}
@@ -15,11 +15,17 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
+	"context"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"strconv"
 
+	"github.com/rsquad/trustless-bridge-cli/internal/txresolver"
 	"github.com/rsquad/trustless-bridge-cli/internal/txutils"
 	"github.com/spf13/cobra"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
@@ -31,58 +37,183 @@ The proof is generated based on the transaction hash and the block file provided
 By default, the proof is output in hexadecimal format.
 Usage example: 
     trustless-bridge-cli tx proof -t <transaction_hash> -b <path_to_block.boc>
-You can specify the output format using the -f flag, with options 'hex' for hexadecimal or 'bin' for binary.`,
-	Run: runTxProof,
+You can specify the output format using the -f flag, with options 'hex' for hexadecimal or 'bin' for binary.
+
+For a shardchain (e.g. basechain) transaction pass the shard block with -b and the masterchain block
+that registers it in ShardHashes with -m:
+    trustless-bridge-cli tx proof -t <transaction_hash> -b <path_to_shard_block.boc> -m <path_to_masterchain_block.boc>
+In this case the output is a cell with two refs: the transaction proof in the shard block
+and the masterchain block proof that keeps the ShardHashes branch with the shard block.
+
+With --out-msg the proof keeps an outbound message of the transaction, selected by its index
+in OutMsgs or by the hash of the message cell. Of the OutMsgs dict only the branch with this
+message is kept:
+    trustless-bridge-cli tx proof -t <transaction_hash> -b <path_to_block.boc> --out-msg 0
+
+` + txSelectorUsage,
+	RunE:        runTxProof,
+	Annotations: map[string]string{offlineAnnotation: "true"},
 }
 
 func init() {
 	txCmd.AddCommand(txProofCmd)
 	txProofCmd.Flags().BytesHexP("tx-hash", "t", nil, "Transaction hash in hexadecimal format")
 	txProofCmd.Flags().StringP("block-boc-path", "b", "", "Path to the BOC file containing the block")
-	txProofCmd.MarkFlagRequired("tx-hash")
-	txProofCmd.MarkFlagRequired("block-boc-path")
+	txProofCmd.Flags().StringP("masterchain-block-boc-path", "m", "", "Path to the BOC file containing the masterchain block, if the block is a shard block")
 	txProofCmd.Flags().StringP("output-format", "f", "hex", "Output format options: 'bin' for binary, 'hex' for hexadecimal")
+	txProofCmd.Flags().String("out-msg", "", "Outbound message to keep in the proof: index in OutMsgs or message hash in hexadecimal format")
+	addTxSelectorFlags(txProofCmd)
 }
 
-func runTxProof(cmd *cobra.Command, args []string) {
+func runTxProof(cmd *cobra.Command, args []string) error {
 	txHash, err := cmd.Flags().GetBytesHex("tx-hash")
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to get tx hash: %w", err)
 	}
 	blockBocPath, err := cmd.Flags().GetString("block-boc-path")
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to get block BOC path: %w", err)
 	}
-
-	blockBOC, err := os.ReadFile(blockBocPath)
+	mcBlockBocPath, err := cmd.Flags().GetString("masterchain-block-boc-path")
+	if err != nil {
+		return fmt.Errorf("failed to get masterchain block BOC path: %w", err)
+	}
+	outputFormat, err := cmd.Flags().GetString("output-format")
+	if err != nil {
+		return fmt.Errorf("failed to get output format: %w", err)
+	}
+	outMsg, err := getOutMsgSelector(cmd)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	query, err := getTxQuery(cmd, txHash)
+	if err != nil {
+		return err
+	}
+
+	var blockBOC, mcBlockBOC []byte
+	if query != nil {
+		if blockBocPath != "" || mcBlockBocPath != "" {
+			return inputError("block BOC files can not be used with --account")
+		}
+		if txHash, blockBOC, mcBlockBOC, err = fetchTxBlocks(context.Background(), query); err != nil {
+			return err
+		}
+	} else {
+		if len(txHash) == 0 || blockBocPath == "" {
+			return inputError("either --tx-hash and --block-boc-path or --account must be set")
+		}
+		blockBOC, err = os.ReadFile(blockBocPath)
+		if err != nil {
+			return inputError("failed to read block BOC: %w", err)
+		}
+		if mcBlockBocPath != "" {
+			mcBlockBOC, err = os.ReadFile(mcBlockBocPath)
+			if err != nil {
+				return inputError("failed to read masterchain block BOC: %w", err)
+			}
+		}
+	}
+
 	blockCell, err := cell.FromBOC(blockBOC)
 	if err != nil {
-		panic(err)
+		return inputError("failed to parse block BOC: %w", err)
+	}
+	setResult("tx_hash", hex.EncodeToString(txHash))
+
+	if mcBlockBOC == nil {
+		var txProofCell *cell.Cell
+		var tx *tlb.Transaction
+		if outMsg != nil {
+			txProofCell, tx, err = txutils.BuildTxProofWithOutMsg(blockCell, txHash, *outMsg)
+		} else {
+			txProofCell, tx, err = txutils.BuildTxProof(blockCell, txHash)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to build tx proof: %w", err)
+		}
+		if err = reportOutMsg(tx, outMsg); err != nil {
+			return err
+		}
+
+		writeBOC("boc", outputFormat, txProofCell.ToBOC())
+		return nil
+	}
+
+	txProofCell, mcBlockProof, tx, err := txutils.BuildShardTxProof(blockCell, mcBlockBOC, txHash, outMsg)
+	if err != nil {
+		return fmt.Errorf("failed to build shard tx proof: %w", err)
+	}
+	if err = reportOutMsg(tx, outMsg); err != nil {
+		return err
+	}
+
+	writeBOC("boc", outputFormat, cell.BeginCell().
+		MustStoreRef(txProofCell).
+		MustStoreRef(mcBlockProof).
+		EndCell().
+		ToBOC())
+	return nil
+}
+
+// getOutMsgSelector parses the --out-msg flag, a 64 character value is a message hash,
+// anything else is an index. It returns nil if the flag is not set.
+func getOutMsgSelector(cmd *cobra.Command) (*txutils.OutMsgSelector, error) {
+	value, err := cmd.Flags().GetString("out-msg")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get out msg: %w", err)
+	}
+	if value == "" {
+		return nil, nil
 	}
 
-	txProofCell, _, err := txutils.BuildTxProof(blockCell, txHash)
+	if len(value) == 64 {
+		hash, err := hex.DecodeString(value)
+		if err != nil {
+			return nil, inputError("failed to parse out msg hash: %w", err)
+		}
+		return &txutils.OutMsgSelector{Hash: hash}, nil
+	}
+	index, err := strconv.ParseUint(value, 10, 15)
 	if err != nil {
-		panic(err)
+		return nil, inputError("failed to parse out msg index: %w", err)
 	}
+	return &txutils.OutMsgSelector{Index: uint16(index)}, nil
+}
 
-	outputFormattedProof(cmd, txProofCell)
+func reportOutMsg(tx *tlb.Transaction, outMsg *txutils.OutMsgSelector) error {
+	if outMsg == nil {
+		return nil
+	}
+	index, msg, err := txutils.FindOutMsg(tx, *outMsg)
+	if err != nil {
+		return err
+	}
+	setResult("out_msg_index", index)
+	setResult("out_msg_hash", hex.EncodeToString(msg.Hash()))
+	return nil
 }
 
-func outputFormattedProof(cmd *cobra.Command, proofCell *cell.Cell) {
-	outputFormat, err := cmd.Flags().GetString("output-format")
+// fetchTxBlocks locates the transaction of the query and fetches its block,
+// and the masterchain block that registers it if the block is a shard block.
+func fetchTxBlocks(ctx context.Context, query *txresolver.Query) (txHash, blockBOC, mcBlockBOC []byte, err error) {
+	if err = connect(); err != nil {
+		return nil, nil, nil, err
+	}
+	loc, err := resolveTx(ctx, tonClient, query)
 	if err != nil {
-		panic(err)
+		return nil, nil, nil, fmt.Errorf("failed to locate transaction: %w", err)
 	}
 
-	switch outputFormat {
-	case "hex":
-		fmt.Printf("%x\n", proofCell.ToBOC())
-	case "bin":
-		fallthrough
-	default:
-		os.Stdout.Write(proofCell.ToBOC())
+	blockBOC, err = tonClient.GetBlockBOC(ctx, loc.Block)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get block BOC: %w", err)
+	}
+	if loc.Block.Workchain != address.MasterchainID {
+		mcBlockBOC, err = tonClient.GetBlockBOC(ctx, loc.Masterchain)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get masterchain block BOC: %w", err)
+		}
 	}
+	return loc.Tx.Hash, blockBOC, mcBlockBOC, nil
 }
@@ -0,0 +1,96 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"log"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/txresolver"
+	"github.com/spf13/cobra"
+	"github.com/xssnick/tonutils-go/address"
+)
+
+const txSelectorUsage = `The transaction can also be selected by the account and its lt, its hash (-t)
+or the hash of its inbound message, then the blocks are looked up on the network:
+    --account <address> --lt <lt>
+    --account <address> -t <transaction_hash>
+    --account <address> --in-msg-hash <message_or_body_hash>`
+
+// addTxSelectorFlags adds the flags that select a transaction by its account, see getTxQuery.
+// The transaction hash flag -t is defined by the command itself.
+func addTxSelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().String("account", "", "Address of the account of the transaction")
+	cmd.Flags().Uint64("lt", 0, "Logical time of the transaction, with --account")
+	cmd.Flags().BytesHex("in-msg-hash", nil, "Hash of the inbound message or its body in hexadecimal format, with --account")
+	cmd.MarkFlagsMutuallyExclusive("lt", "in-msg-hash")
+}
+
+// getTxQuery returns the transaction query of the --account flags, nil if --account is not set.
+func getTxQuery(cmd *cobra.Command, txHash []byte) (*txresolver.Query, error) {
+	accountStr, err := cmd.Flags().GetString("account")
+	if err != nil {
+		return nil, err
+	}
+	lt, err := cmd.Flags().GetUint64("lt")
+	if err != nil {
+		return nil, err
+	}
+	inMsgHash, err := cmd.Flags().GetBytesHex("in-msg-hash")
+	if err != nil {
+		return nil, err
+	}
+	// hex flags that are not set are empty, not nil
+	if len(txHash) == 0 {
+		txHash = nil
+	}
+	if len(inMsgHash) == 0 {
+		inMsgHash = nil
+	}
+
+	if accountStr == "" {
+		if lt != 0 || inMsgHash != nil {
+			return nil, inputError("--lt and --in-msg-hash require --account")
+		}
+		return nil, nil
+	}
+	account, err := address.ParseAddr(accountStr)
+	if err != nil {
+		return nil, inputError("failed to parse account address: %w", err)
+	}
+
+	q := &txresolver.Query{Account: account, LT: lt, TxHash: txHash, InMsgHash: inMsgHash}
+	if err = q.Validate(); err != nil {
+		return nil, inputError("invalid transaction selector: %w", err)
+	}
+	return q, nil
+}
+
+// resolveTx locates the transaction of the query and reports its location.
+func resolveTx(ctx context.Context, tonClient *tonclient.TonClient, q *txresolver.Query) (*txresolver.Location, error) {
+	loc, err := txresolver.Resolve(ctx, tonClient, q)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Transaction %x (lt %d) is in block %d:%x:%d, masterchain block %d",
+		loc.Tx.Hash, loc.Tx.LT, loc.Block.Workchain, uint64(loc.Block.Shard), loc.Block.SeqNo, loc.Masterchain.SeqNo)
+	setResult("tx_lt", loc.Tx.LT)
+	setResult("workchain", loc.Block.Workchain)
+	setResult("block_seqno", loc.Block.SeqNo)
+	setResult("masterchain_seqno", loc.Masterchain.SeqNo)
+	return loc, nil
+}
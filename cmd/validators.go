@@ -0,0 +1,57 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/spf13/cobra"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+var validatorsCmd = &cobra.Command{
+	Use:   "validators",
+	Short: "Inspect validator sets of key blocks and LiteClients",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+	Annotations: map[string]string{offlineAnnotation: "true"},
+}
+
+func init() {
+	rootCmd.AddCommand(validatorsCmd)
+}
+
+// fetchKeyBlock fetches the masterchain block, or its previous key block if it is not a key block.
+func fetchKeyBlock(ctx context.Context, client *tonclient.TonClient, seqno uint32) (*tlb.Block, error) {
+	block, err := blockutils.FetchMasterchainBlock(ctx, client, seqno)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch masterchain block: %w", err)
+	}
+	if block.BlockInfo.KeyBlock {
+		return block, nil
+	}
+
+	log.Printf("Attention: block %d is not a key block, using the previous key block %d", seqno, block.BlockInfo.PrevKeyBlockSeqno)
+	block, err = blockutils.FetchMasterchainBlock(ctx, client, block.BlockInfo.PrevKeyBlockSeqno)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch masterchain block: %w", err)
+	}
+	return block, nil
+}
@@ -0,0 +1,138 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"slices"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/liteclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/verifier"
+	"github.com/spf13/cobra"
+	"github.com/xssnick/tonutils-go/address"
+)
+
+var validatorsDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the validator set of a key block with the set of a LiteClient",
+	Long: `Compares the main validators of a key block of the source network with the validators of a LiteClient
+deployed on the target network: added, removed and reweighted validators, and whether the validators
+present in both sets hold more than 2/3 of the weight of the LiteClient set.`,
+	RunE: runValidatorsDiff,
+}
+
+func init() {
+	validatorsCmd.AddCommand(validatorsDiffCmd)
+	validatorsDiffCmd.Flags().Uint32P("seqno", "s", 0, "Key block seqno")
+	validatorsDiffCmd.Flags().StringP("address", "a", "", "Address of the LiteClient contract")
+	validatorsDiffCmd.Flags().Int("param", 34, "Config param with the validator set of the key block: 32, 34 or 36")
+	validatorsDiffCmd.MarkFlagRequired("seqno")
+	validatorsDiffCmd.MarkFlagRequired("address")
+}
+
+func runValidatorsDiff(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	seqno, err := cmd.Flags().GetUint32("seqno")
+	if err != nil {
+		return fmt.Errorf("failed to get seqno: %w", err)
+	}
+	addrStr, err := cmd.Flags().GetString("address")
+	if err != nil {
+		return fmt.Errorf("failed to get address: %w", err)
+	}
+	param, err := cmd.Flags().GetInt("param")
+	if err != nil {
+		return fmt.Errorf("failed to get param: %w", err)
+	}
+	if !slices.Contains(blockutils.ValidatorSetParams, param) {
+		return inputError("param must be one of %v, got %d", blockutils.ValidatorSetParams, param)
+	}
+	addr, err := address.ParseAddr(addrStr)
+	if err != nil {
+		return inputError("failed to parse address: %w", err)
+	}
+
+	sourceTonClient, _, err := connectSource()
+	if err != nil {
+		return err
+	}
+	block, err := fetchKeyBlock(ctx, sourceTonClient, seqno)
+	if err != nil {
+		return err
+	}
+	blockSet, err := blockutils.DecodeValidatorSet(block, param)
+	if err != nil {
+		return fmt.Errorf("failed to decode validator set: %w", err)
+	}
+	if blockSet == nil {
+		return inputError("key block %d has no config param %d", block.BlockInfo.SeqNo, param)
+	}
+
+	validatorDict, err := liteclient.New(addr, tonClient).GetValidators(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get validators: %w", err)
+	}
+	liteClientSet, err := verifier.ValidatorSetFromDict(validatorDict, 0)
+	if err != nil {
+		return err
+	}
+
+	other := &verifier.ValidatorSet{Weights: map[[32]byte]uint64{}, TotalWeight: blockSet.MainWeight}
+	for _, v := range blockSet.MainValidators() {
+		var key [32]byte
+		copy(key[:], v.PublicKey)
+		other.Weights[key] = v.Weight
+	}
+	diff := verifier.DiffValidatorSets(liteClientSet, other)
+
+	setResult("seqno", block.BlockInfo.SeqNo)
+	setResult("param", param)
+	printf("Key block %d, config param %d: %d main validators, weight %d\n",
+		block.BlockInfo.SeqNo, param, len(other.Weights), other.TotalWeight)
+	printf("LiteClient: %d validators, weight %d\n", len(liteClientSet.Weights), liteClientSet.TotalWeight)
+
+	added := make([]map[string]any, 0, len(diff.Added))
+	for _, key := range diff.Added {
+		printf("+\t%x\t%d\n", key, other.Weights[key])
+		added = append(added, map[string]any{"public_key": hex.EncodeToString(key[:]), "weight": other.Weights[key]})
+	}
+	removed := make([]map[string]any, 0, len(diff.Removed))
+	for _, key := range diff.Removed {
+		printf("-\t%x\t%d\n", key, liteClientSet.Weights[key])
+		removed = append(removed, map[string]any{"public_key": hex.EncodeToString(key[:]), "weight": liteClientSet.Weights[key]})
+	}
+	reweighted := make([]map[string]any, 0, len(diff.Reweighted))
+	for _, key := range diff.Reweighted {
+		printf("~\t%x\t%d -> %d\n", key, liteClientSet.Weights[key], other.Weights[key])
+		reweighted = append(reweighted, map[string]any{
+			"public_key":         hex.EncodeToString(key[:]),
+			"weight":             other.Weights[key],
+			"lite_client_weight": liteClientSet.Weights[key],
+		})
+	}
+	setResult("added", added)
+	setResult("removed", removed)
+	setResult("reweighted", reweighted)
+	setResult("overlap_weight", diff.OverlapWeight)
+	setResult("lite_client_total_weight", diff.TotalWeight)
+	setResult("overlap_holds", diff.OverlapHolds())
+
+	printf("Overlap weight: %d/%d, more than 2/3: %t\n", diff.OverlapWeight, diff.TotalWeight, diff.OverlapHolds())
+	return nil
+}
@@ -0,0 +1,102 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/spf13/cobra"
+)
+
+var validatorsShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Decode the validator sets of a key block",
+	Long: `Decodes config params 32, 34 and 36 of a key block: the previous, the current and the next validator set.
+If the block is not a key block, its previous key block is used.`,
+	RunE: runValidatorsShow,
+}
+
+func init() {
+	validatorsCmd.AddCommand(validatorsShowCmd)
+	validatorsShowCmd.Flags().Uint32P("seqno", "s", 0, "Key block seqno")
+	validatorsShowCmd.MarkFlagRequired("seqno")
+}
+
+var validatorSetNames = map[int]string{32: "previous", 34: "current", 36: "next"}
+
+func runValidatorsShow(cmd *cobra.Command, args []string) error {
+	seqno, err := cmd.Flags().GetUint32("seqno")
+	if err != nil {
+		return fmt.Errorf("failed to get seqno: %w", err)
+	}
+
+	block, err := fetchKeyBlock(context.Background(), tonClient, seqno)
+	if err != nil {
+		return err
+	}
+	setResult("seqno", block.BlockInfo.SeqNo)
+	printf("Key block: %d\n", block.BlockInfo.SeqNo)
+
+	var sets []map[string]any
+	for _, param := range blockutils.ValidatorSetParams {
+		set, err := blockutils.DecodeValidatorSet(block, param)
+		if err != nil {
+			return fmt.Errorf("failed to decode validator set: %w", err)
+		}
+		if set == nil {
+			continue
+		}
+
+		printf("\nConfig param %d, %s validators\n", set.Param, validatorSetNames[set.Param])
+		printf("Since: %s, until: %s\n", formatUtime(set.UTimeSince), formatUtime(set.UTimeUntil))
+		printf("Validators: %d, main: %d\n", set.Total, set.Main)
+		printf("Total weight: %d, main weight: %d\n", set.TotalWeight, set.MainWeight)
+		printf("Epoch hash: %x\n", set.EpochHash)
+
+		validators := make([]map[string]any, 0, len(set.Validators))
+		for i, v := range set.Validators {
+			main := i < int(set.Main)
+			printf("%d\t%x\t%x\t%d\t%t\n", v.Index, v.PublicKey, v.ADNLAddr, v.Weight, main)
+			validators = append(validators, map[string]any{
+				"index":      v.Index,
+				"public_key": hex.EncodeToString(v.PublicKey),
+				"adnl":       hex.EncodeToString(v.ADNLAddr),
+				"weight":     v.Weight,
+				"main":       main,
+			})
+		}
+		sets = append(sets, map[string]any{
+			"param":        set.Param,
+			"utime_since":  set.UTimeSince,
+			"utime_until":  set.UTimeUntil,
+			"total":        set.Total,
+			"main":         set.Main,
+			"total_weight": set.TotalWeight,
+			"main_weight":  set.MainWeight,
+			"epoch_hash":   hex.EncodeToString(set.EpochHash),
+			"validators":   validators,
+		})
+	}
+	setResult("validator_sets", sets)
+	return nil
+}
+
+func formatUtime(utime uint32) string {
+	return fmt.Sprintf("%s (%d)", time.Unix(int64(utime), 0).UTC().Format(time.RFC3339), utime)
+}
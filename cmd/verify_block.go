@@ -0,0 +1,215 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/liteclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/verifier"
+	"github.com/spf13/cobra"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var verifyBlockCmd = &cobra.Command{
+	Use:   "block",
+	Short: "Verify a block proof and its signatures against a validator set",
+	Long: `This command checks a block proof and a signatures dictionary offline, using the same rules
+as the LiteClient contract:
+-	the proof is a merkle proof and its hashes are consistent,
+-	every signature is a valid ed25519 signature of ton.blockId(root_hash, file_hash)
+	made by a validator from the set,
+-	the signed weight is more than 2/3 of the total weight of the set.
+With --key-block the proof must also contain config param 34, as required by new_key_block.
+
+The proof is the output of "block prune -e", the signatures are the output of "block signatures"
+in bin or hex format. The validator set is taken from one of:
+-	--validators-block: a key block BOC ("block fetch -f bin"), main validators of its config param 34,
+-	--validators-file: a JSON object mapping validator public keys to weights, in the format
+	of the validator dict printed by "get lite-client-validators",
+-	--lite-client: the storage of a deployed LiteClient (requires network access).`,
+	RunE:        runVerifyBlock,
+	Annotations: map[string]string{offlineAnnotation: "true"},
+}
+
+func init() {
+	verifyCmd.AddCommand(verifyBlockCmd)
+	verifyBlockCmd.Flags().StringP("proof", "p", "", "Path to the block proof BOC")
+	verifyBlockCmd.Flags().BytesHex("file-hash", nil, "File hash of the block in hexadecimal format")
+	verifyBlockCmd.Flags().StringP("signatures", "S", "", "Path to the signatures dictionary BOC")
+	verifyBlockCmd.Flags().Bool("key-block", false, "Verify as a new_key_block payload")
+	verifyBlockCmd.Flags().String("validators-block", "", "Path to the key block BOC with the validator set")
+	verifyBlockCmd.Flags().String("validators-file", "", "Path to the JSON file with the validator set")
+	verifyBlockCmd.Flags().String("lite-client", "", "Address of the LiteClient to take the validator set from")
+	verifyBlockCmd.MarkFlagRequired("proof")
+	verifyBlockCmd.MarkFlagRequired("file-hash")
+	verifyBlockCmd.MarkFlagRequired("signatures")
+	verifyBlockCmd.MarkFlagsOneRequired("validators-block", "validators-file", "lite-client")
+	verifyBlockCmd.MarkFlagsMutuallyExclusive("validators-block", "validators-file", "lite-client")
+}
+
+func runVerifyBlock(cmd *cobra.Command, args []string) error {
+	proofPath, err := cmd.Flags().GetString("proof")
+	if err != nil {
+		return fmt.Errorf("failed to get proof: %w", err)
+	}
+	fileHash, err := cmd.Flags().GetBytesHex("file-hash")
+	if err != nil {
+		return fmt.Errorf("failed to get file hash: %w", err)
+	}
+	signaturesPath, err := cmd.Flags().GetString("signatures")
+	if err != nil {
+		return fmt.Errorf("failed to get signatures: %w", err)
+	}
+	keyBlock, err := cmd.Flags().GetBool("key-block")
+	if err != nil {
+		return fmt.Errorf("failed to get key block: %w", err)
+	}
+
+	proofCell, err := readBOCFile(proofPath)
+	if err != nil {
+		return inputError("failed to read proof: %w", err)
+	}
+	signaturesCell, err := readBOCFile(signaturesPath)
+	if err != nil {
+		return inputError("failed to read signatures: %w", err)
+	}
+
+	set, err := loadValidatorSet(cmd)
+	if err != nil {
+		return err
+	}
+
+	verify := verifier.VerifyBlock
+	if keyBlock {
+		verify = verifier.VerifyKeyBlock
+	}
+	res, err := verify(proofCell, fileHash, signaturesCell.AsDict(256), set)
+	if err != nil {
+		return fmt.Errorf("verification failed: %w", err)
+	}
+
+	setResult("root_hash", hex.EncodeToString(res.RootHash))
+	setResult("file_hash", hex.EncodeToString(res.FileHash))
+	setResult("signatures", res.Signatures)
+	setResult("signed_weight", res.SignedWeight)
+	setResult("total_weight", res.TotalWeight)
+	printf("Block proof is valid\n")
+	printf("Root hash: %x\n", res.RootHash)
+	printf("File hash: %x\n", res.FileHash)
+	printf("Signatures: %d, signed weight: %d/%d\n", res.Signatures, res.SignedWeight, res.TotalWeight)
+	if res.EpochHash != nil {
+		setResult("epoch_hash", hex.EncodeToString(res.EpochHash))
+		printf("New epoch hash: %x\n", res.EpochHash)
+	}
+
+	return nil
+}
+
+func loadValidatorSet(cmd *cobra.Command) (*verifier.ValidatorSet, error) {
+	blockPath, err := cmd.Flags().GetString("validators-block")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get validators block: %w", err)
+	}
+	filePath, err := cmd.Flags().GetString("validators-file")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get validators file: %w", err)
+	}
+	liteClientAddr, err := cmd.Flags().GetString("lite-client")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lite client: %w", err)
+	}
+
+	switch {
+	case blockPath != "":
+		blockCell, err := readBOCFile(blockPath)
+		if err != nil {
+			return nil, inputError("failed to read validators block: %w", err)
+		}
+		var block tlb.Block
+		if err = tlb.LoadFromCell(&block, blockCell.BeginParse()); err != nil {
+			return nil, inputError("failed to parse validators block: %w", err)
+		}
+		if block.Extra == nil || block.Extra.Custom == nil || block.Extra.Custom.ConfigParams == nil {
+			return nil, inputError("validators block is not a key block")
+		}
+		validators, _, _, err := blockutils.ExtractMainValidators(&block, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract main validators: %w", err)
+		}
+		return verifier.ValidatorSetFromValidators(validators), nil
+
+	case filePath != "":
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, inputError("failed to read validators file: %w", err)
+		}
+		var weights map[string]string
+		if err = json.Unmarshal(data, &weights); err != nil {
+			return nil, inputError("failed to parse validators file: %w", err)
+		}
+		dict := cell.NewDict(256)
+		for keyHex, weightHex := range weights {
+			key, err := hex.DecodeString(keyHex)
+			if err != nil || len(key) != 32 {
+				return nil, inputError("invalid validator public key: %s", keyHex)
+			}
+			weight, err := hex.DecodeString(weightHex)
+			if err != nil || len(weight) != 8 {
+				return nil, inputError("invalid weight of validator %s: %s", keyHex, weightHex)
+			}
+			dict.Set(
+				cell.BeginCell().MustStoreSlice(key, 256).EndCell(),
+				cell.BeginCell().MustStoreSlice(weight, 64).EndCell(),
+			)
+		}
+		return verifier.ValidatorSetFromDict(dict, 0)
+
+	default:
+		addr, err := address.ParseAddr(liteClientAddr)
+		if err != nil {
+			return nil, inputError("failed to parse address: %w", err)
+		}
+		if err = connect(); err != nil {
+			return nil, err
+		}
+		storage, err := liteclient.New(addr, tonClient).GetStorage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get storage: %w", err)
+		}
+		return verifier.ValidatorSetFromDict(storage.ValidatorDict, storage.ValidatorsTotalWeight)
+	}
+}
+
+// readBOCFile reads a BOC saved either in binary form or as a hex string.
+func readBOCFile(path string) (*cell.Cell, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if decoded, err := hex.DecodeString(string(bytes.TrimSpace(data))); err == nil {
+		data = decoded
+	}
+	return cell.FromBOC(data)
+}
@@ -0,0 +1,364 @@
+/*
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/jobs"
+	"github.com/rsquad/trustless-bridge-cli/internal/liteclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/metrics"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/txchecker"
+	"github.com/rsquad/trustless-bridge-cli/internal/txresolver"
+	"github.com/spf13/cobra"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+var watchAccountCmd = &cobra.Command{
+	Use:   "watch-account",
+	Short: "Prove every new transaction of an account to a TxChecker",
+	Long: `This command runs until interrupted and follows the transactions of an account of the source network.
+Every transaction, or only those whose inbound internal message has the --op op code, is proven
+as soon as its block is committed to the masterchain and sent to the TxChecker as a check_transaction message.
+
+The LT of the last processed transaction is stored in the state file. Every proven transaction
+is a job in the jobs database: failed sends are retried with backoff, and a transaction sent before
+a restart is confirmed by the hash of its external message instead of being sent again.
+Transactions whose job is rejected are skipped and can be sent again with "jobs retry".
+
+Without a state file only the transactions after the current last transaction of the account
+are processed, or the transactions after --from-lt.
+
+With --metrics-addr the watcher serves the same metrics and health check as relay, where the epoch lag
+is the one of the LiteClient of the TxChecker.`,
+	RunE: runWatchAccount,
+}
+
+func init() {
+	rootCmd.AddCommand(watchAccountCmd)
+	watchAccountCmd.Flags().StringP("address", "a", "", "Address of the account in the source network")
+	watchAccountCmd.Flags().String("tx-checker", "", "Address of the TxChecker contract")
+	watchAccountCmd.Flags().Uint32("op", 0, "Op code of the inbound message of the transactions to prove, e.g. 0x7362d09c")
+	watchAccountCmd.Flags().Uint64("from-lt", 0, "Process the transactions after this LT if there is no state file")
+	watchAccountCmd.Flags().String("state-file", "watch-account-state.json", "Path to the file with the last processed transaction")
+	addSignatureFlags(watchAccountCmd.Flags())
+	addJobFlags(watchAccountCmd)
+	addMetricsFlags(watchAccountCmd)
+	watchAccountCmd.MarkFlagRequired("address")
+	watchAccountCmd.MarkFlagRequired("tx-checker")
+}
+
+type watchState struct {
+	Account string `json:"account"`
+	LastLT  uint64 `json:"last_lt"`
+}
+
+func loadWatchState(path string) (*watchState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var state watchState
+	if err = json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+	return &state, nil
+}
+
+func saveWatchState(path string, state *watchState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func runWatchAccount(cmd *cobra.Command, args []string) error {
+	addrStr, err := cmd.Flags().GetString("address")
+	if err != nil {
+		return fmt.Errorf("failed to get address: %w", err)
+	}
+	account, err := address.ParseAddr(addrStr)
+	if err != nil {
+		return inputError("failed to parse address: %w", err)
+	}
+	txCheckerStr, err := cmd.Flags().GetString("tx-checker")
+	if err != nil {
+		return fmt.Errorf("failed to get tx checker: %w", err)
+	}
+	txCheckerAddr, err := address.ParseAddr(txCheckerStr)
+	if err != nil {
+		return inputError("failed to parse tx checker address: %w", err)
+	}
+	op, err := cmd.Flags().GetUint32("op")
+	if err != nil {
+		return fmt.Errorf("failed to get op: %w", err)
+	}
+	fromLT, err := cmd.Flags().GetUint64("from-lt")
+	if err != nil {
+		return fmt.Errorf("failed to get from lt: %w", err)
+	}
+	statePath, err := cmd.Flags().GetString("state-file")
+	if err != nil {
+		return fmt.Errorf("failed to get state file: %w", err)
+	}
+
+	state, err := loadWatchState(statePath)
+	if err != nil {
+		return err
+	}
+	if state != nil {
+		stateAccount, err := address.ParseAddr(state.Account)
+		if err != nil || !stateAccount.Equals(account) {
+			return inputError("state file %s belongs to account %s", statePath, state.Account)
+		}
+	}
+	store, err := openJobs()
+	if err != nil {
+		return err
+	}
+
+	sourceTonClient, sourceName, err := connectSource()
+	if err != nil {
+		return err
+	}
+	// the wallet key is unlocked now, so that a wrong config fails before watching
+	if _, err = tonClient.GetWallet(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if state == nil {
+		state = &watchState{Account: account.String(), LastLT: fromLT}
+		if !cmd.Flags().Changed("from-lt") {
+			if state.LastLT, err = lastTxLT(ctx, sourceTonClient, account); err != nil {
+				return err
+			}
+		}
+	}
+	if err = saveWatchState(statePath, state); err != nil {
+		return err
+	}
+
+	stopMetrics, err := startMetricsServer(cmd)
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+
+	log.Printf("Attention: You are proving transactions of %s in %s network to TxChecker %s in %s network, after lt %d",
+		account, sourceName, txCheckerAddr, network, state.LastLT)
+
+	w := &accountWatcher{
+		source:    sourceTonClient,
+		txChecker: txchecker.New(txCheckerAddr, tonClient),
+		account:   account,
+		state:     state,
+		statePath: statePath,
+	}
+	if cmd.Flags().Changed("op") {
+		w.op = &op
+	}
+	if metricsEnabled {
+		liteClientAddr, err := w.txChecker.GetLiteClientAddr(ctx)
+		if err != nil {
+			return err
+		}
+		w.liteClient = liteclient.New(liteClientAddr, tonClient)
+	}
+	w.runner, err = newJobRunner(cmd, store, w.sendCheckTx, w.txChecker.CheckTxVerdict)
+	if err != nil {
+		return err
+	}
+
+	// jobs retried by the operator are sent between the transactions
+	ticker := time.NewTicker(w.runner.Backoff.Initial)
+	defer ticker.Stop()
+	ownJobs := matchJobs(jobs.KindCheckTx, txCheckerAddr)
+
+	txs := make(chan *tlb.Transaction)
+	go sourceTonClient.API.SubscribeOnTransactions(ctx, account, state.LastLT, txs)
+
+	var confirmed int
+	for done := false; !done; {
+		select {
+		case tx, ok := <-txs:
+			if !ok {
+				done = true
+				break
+			}
+			jobConfirmed, err := w.process(ctx, tx)
+			if ctx.Err() != nil {
+				done = true
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if jobConfirmed {
+				confirmed++
+			}
+		case <-ticker.C:
+			if err := w.runner.RunDue(ctx, ownJobs); err != nil && ctx.Err() == nil {
+				return err
+			}
+			if metricsEnabled {
+				w.updateMetrics(ctx)
+			}
+		}
+	}
+
+	log.Printf("Watching stopped")
+	setResult("last_lt", w.state.LastLT)
+	setResult("confirmed", confirmed)
+	return nil
+}
+
+// lastTxLT returns the LT of the last transaction of the account.
+func lastTxLT(ctx context.Context, client *tonclient.TonClient, account *address.Address) (uint64, error) {
+	master, err := client.API.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	acc, err := client.API.GetAccount(ctx, master, account)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get account: %w", err)
+	}
+	return acc.LastTxLT, nil
+}
+
+type accountWatcher struct {
+	source     *tonclient.TonClient
+	txChecker  *txchecker.TxCheckerContract
+	liteClient *liteclient.LiteClientContract
+	account    *address.Address
+	op         *uint32
+	state      *watchState
+	statePath  string
+	runner     *jobs.Runner
+}
+
+// updateMetrics measures the epoch lag of the LiteClient of the TxChecker and the wallet balance.
+// Failures are only logged, the lag keeps its last value.
+func (w *accountWatcher) updateMetrics(ctx context.Context) {
+	latestKeySeqno, err := latestKeyBlockSeqno(ctx, w.source)
+	if err == nil {
+		err = updateEpochLag(ctx, w.source, w.liteClient, latestKeySeqno)
+	}
+	if err != nil {
+		log.Printf("failed to measure epoch lag: %v", err)
+	}
+	if err = updateWalletBalance(ctx); err != nil {
+		log.Printf("failed to update wallet balance: %v", err)
+	}
+}
+
+// process runs the check_transaction job of the transaction if it matches the op code
+// and saves the transaction as processed. It reports whether the job was confirmed.
+func (w *accountWatcher) process(ctx context.Context, tx *tlb.Transaction) (bool, error) {
+	var confirmed bool
+	if w.matches(tx) {
+		job, _, err := w.runner.Store.Add(&jobs.Job{
+			Kind:    jobs.KindCheckTx,
+			Network: network,
+			Target:  w.txChecker.Addr.String(),
+			Key:     hex.EncodeToString(tx.Hash),
+			Account: w.account.String(),
+			LT:      tx.LT,
+		})
+		if err != nil {
+			return false, err
+		}
+		if job, err = w.runner.Run(ctx, job); err != nil {
+			return false, err
+		}
+
+		confirmed = job.State == jobs.StateConfirmed
+		if confirmed {
+			log.Printf("CheckTx for tx %x (lt %d) confirmed in wallet transaction %s", tx.Hash, tx.LT, job.WalletTx)
+		} else {
+			log.Printf("Attention: job %d of transaction %x (lt %d) is %s, skipping it: %s", job.ID, tx.Hash, tx.LT, job.State, job.LastError)
+		}
+	}
+
+	w.state.LastLT = tx.LT
+	return confirmed, saveWatchState(w.statePath, w.state)
+}
+
+// matches reports whether the inbound message of the transaction is an internal
+// message with the op code of the watcher.
+func (w *accountWatcher) matches(tx *tlb.Transaction) bool {
+	if w.op == nil {
+		return true
+	}
+	if tx.IO.In == nil || tx.IO.In.MsgType != tlb.MsgTypeInternal {
+		return false
+	}
+	body := tx.IO.In.AsInternal().Body
+	if body == nil {
+		return false
+	}
+	op, err := body.BeginParse().LoadUInt(32)
+	return err == nil && uint32(op) == *w.op
+}
+
+// sendCheckTx proves the transaction of the job and sends it to the TxChecker. Until the block
+// of the transaction is committed to the masterchain it fails with blockutils.ErrNotRegistered,
+// and the job is sent again with backoff.
+func (w *accountWatcher) sendCheckTx(ctx context.Context, job *jobs.Job, sending func(inMsgHash []byte) error) error {
+	// retried jobs may belong to another account proven to the same TxChecker
+	account, err := address.ParseAddr(job.Account)
+	if err != nil {
+		return fmt.Errorf("%w: failed to parse account: %w", jobs.ErrPermanent, err)
+	}
+	loc, err := txresolver.Resolve(ctx, w.source, &txresolver.Query{Account: account, LT: job.LT})
+	if err != nil {
+		return fmt.Errorf("failed to locate transaction: %w", err)
+	}
+	started := time.Now()
+	proof, err := buildCheckTx(ctx, w.source, loc.Masterchain.SeqNo, loc.Block.Workchain, loc.Tx.Hash, nil)
+	if err != nil {
+		return err
+	}
+	metrics.ProofBuildSeconds.Observe(time.Since(started).Seconds())
+
+	message := w.txChecker.CheckTxMessage(txchecker.TxToCell(proof.tx), proof.txProof, proof.currentBlock)
+	if err = sendJobMessage(ctx, message, sending); err != nil {
+		return fmt.Errorf("failed to send check tx: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package accountproof
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var ErrAccountNotFound = errors.New("account is not in the state")
+
+// Proof proves the state of an account at a masterchain block. ToCell serializes it as
+//
+//	account_state_proof#_ mc_block_proof:^Cell state_proof:^Cell
+//	    shard_block_proof:(Maybe ^Cell) account:(Maybe ^Cell) = AccountStateProof;
+//
+// mc_block_proof is the proof of the masterchain block, in the form checked by the LiteClient.
+// For a masterchain account it also keeps the state update of the block, and for a shardchain
+// account the ShardHashes branch with the shard block, which is then proven by shard_block_proof
+// together with its state update. state_proof is the proof of the state after the block
+// (mc_block_proof or shard_block_proof) down to the ShardAccount of the address, which holds
+// the balance and the last transaction lt and hash. account is the Account cell with pruned
+// refs, so the hashes of the code and the data of an active account can be read from it.
+type Proof struct {
+	MasterchainBlockProof *cell.Cell
+	ShardBlockProof       *cell.Cell
+	StateProof            *cell.Cell
+	Account               *cell.Cell
+
+	ShardAccount *tlb.ShardAccount
+	Balance      tlb.Coins
+	// State is the parsed account, set together with Account.
+	State *tlb.AccountState
+}
+
+// Build builds the proof of the account state at the masterchain block.
+// The account cell is kept in the proof only if withAccount is set.
+func Build(
+	ctx context.Context,
+	tonClient *tonclient.TonClient,
+	mcBlock *ton.BlockIDExt,
+	mcBlockBOC []byte,
+	addr *address.Address,
+	withAccount bool,
+) (*Proof, error) {
+	state, err := getAccountState(ctx, tonClient, mcBlock, addr)
+	if err != nil {
+		return nil, err
+	}
+	if len(state.Proof) != 2 {
+		return nil, ton.ErrNoProof
+	}
+
+	p := &Proof{StateProof: state.Proof[1]}
+
+	stateBlock, stateBlockBOC := mcBlock, mcBlockBOC
+	if addr.Workchain() != address.MasterchainID {
+		if state.Shard == nil {
+			return nil, fmt.Errorf("shard block of the account is not returned")
+		}
+		stateBlock = state.Shard
+		stateBlockBOC, err = tonClient.GetBlockBOC(ctx, stateBlock)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get shard block BOC: %w", err)
+		}
+
+		p.MasterchainBlockProof, err = blockutils.BuildBlockProofWithShard(mcBlockBOC, addr.Workchain(), stateBlock.RootHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build masterchain block proof: %w", err)
+		}
+	}
+
+	stateBlockProof, err := blockutils.BuildBlockProofWithState(stateBlockBOC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build block proof: %w", err)
+	}
+	if p.MasterchainBlockProof == nil {
+		p.MasterchainBlockProof = stateBlockProof
+	} else {
+		p.ShardBlockProof = stateBlockProof
+	}
+
+	shardState, err := ton.CheckBlockShardStateProof([]*cell.Cell{stateBlockProof, p.StateProof}, stateBlock.RootHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check state proof: %w", err)
+	}
+	if err = p.loadShardAccount(shardState, addr); err != nil {
+		return nil, err
+	}
+
+	if withAccount {
+		if err = p.attachAccount(state.State); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *Proof) ToCell() *cell.Cell {
+	b := cell.BeginCell().
+		MustStoreRef(p.MasterchainBlockProof).
+		MustStoreRef(p.StateProof).
+		MustStoreMaybeRef(p.ShardBlockProof).
+		MustStoreMaybeRef(p.Account)
+	return b.EndCell()
+}
+
+func getAccountState(
+	ctx context.Context,
+	tonClient *tonclient.TonClient,
+	mcBlock *ton.BlockIDExt,
+	addr *address.Address,
+) (*ton.AccountState, error) {
+	var resp tl.Serializable
+	err := tonClient.API.Client().QueryLiteserver(ctx, ton.GetAccountState{
+		ID: mcBlock,
+		Account: ton.AccountID{
+			Workchain: addr.Workchain(),
+			ID:        addr.Data(),
+		},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case ton.AccountState:
+		if !t.ID.Equals(mcBlock) {
+			return nil, fmt.Errorf("account state of block %d instead of %d", t.ID.SeqNo, mcBlock.SeqNo)
+		}
+		return &t, nil
+	case ton.LSError:
+		return nil, t
+	}
+	return nil, fmt.Errorf("unknown response type")
+}
+
+func (p *Proof) loadShardAccount(shardState *tlb.ShardStateUnsplit, addr *address.Address) error {
+	if shardState.Accounts.ShardAccounts == nil {
+		return fmt.Errorf("no shard accounts in the state proof")
+	}
+	value, err := shardState.Accounts.ShardAccounts.LoadValue(
+		cell.BeginCell().MustStoreSlice(addr.Data(), 256).EndCell(),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrAccountNotFound, addr)
+	}
+
+	var balance tlb.DepthBalanceInfo
+	if err = tlb.LoadFromCell(&balance, value); err != nil {
+		return fmt.Errorf("failed to parse balance: %w", err)
+	}
+	var shardAccount tlb.ShardAccount
+	if err = tlb.LoadFromCell(&shardAccount, value); err != nil {
+		return fmt.Errorf("failed to parse shard account: %w", err)
+	}
+
+	p.ShardAccount = &shardAccount
+	p.Balance = balance.Currencies.Coins
+	return nil
+}
+
+// attachAccount keeps the root cell of the account, whose hash is referenced by the ShardAccount.
+func (p *Proof) attachAccount(account *cell.Cell) error {
+	if account == nil {
+		return fmt.Errorf("account state is not returned")
+	}
+	if string(account.Hash()) != string(p.ShardAccount.Account.Hash(0)) {
+		return fmt.Errorf("account state does not match the state proof")
+	}
+
+	var state tlb.AccountState
+	if err := state.LoadFromCell(account.BeginParse()); err != nil {
+		return fmt.Errorf("failed to parse account state: %w", err)
+	}
+
+	accountProof, err := account.CreateProof(cell.CreateProofSkeleton())
+	if err != nil {
+		return fmt.Errorf("failed to build account proof: %w", err)
+	}
+	p.Account = accountProof
+	p.State = &state
+	return nil
+}
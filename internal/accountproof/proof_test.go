@@ -0,0 +1,145 @@
+package accountproof_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/accountproof"
+	"github.com/rsquad/trustless-bridge-cli/internal/blocktest"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// prepareAccountState returns the state with the active account and its state proof,
+// in which only the ShardAccounts dict is kept, as liteservers return it.
+func prepareAccountState(addr *address.Address, workchain int32, seqno uint32) (state, stateProof, account *cell.Cell) {
+	account = blocktest.Account(addr, 5000, cell.BeginCell().MustStoreUInt(1, 8).EndCell(), cell.BeginCell().MustStoreUInt(2, 8).EndCell())
+	accounts := cell.NewDict(256)
+	key := cell.BeginCell().MustStoreSlice(addr.Data(), 256).EndCell()
+	if err := accounts.Set(key, blocktest.ShardAccount(account, 5000, 777, bytes.Repeat([]byte{0x77}, 32))); err != nil {
+		panic(err)
+	}
+	state = blocktest.ShardState(workchain, seqno, accounts)
+
+	sk := cell.CreateProofSkeleton()
+	sk.ProofRef(1).SetRecursive()
+	stateProof, err := state.CreateProof(sk)
+	if err != nil {
+		panic(err)
+	}
+	return state, stateProof, account
+}
+
+// prepareGetAccountState adds the response to the account state request at the masterchain block.
+func prepareGetAccountState(fixtures *tonclient.Fixtures, mcBlock, shard *ton.BlockIDExt, addr *address.Address, stateProof, account *cell.Cell) {
+	err := fixtures.Add(
+		ton.GetAccountState{ID: mcBlock, Account: ton.AccountID{Workchain: addr.Workchain(), ID: addr.Data()}},
+		ton.AccountState{
+			ID:         mcBlock,
+			Shard:      shard,
+			ShardProof: []*cell.Cell{},
+			Proof:      []*cell.Cell{stateProof, stateProof},
+			State:      account,
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func checkAccount(t *testing.T, p *accountproof.Proof, account *cell.Cell) {
+	t.Helper()
+	if p.Balance.Nano().Uint64() != 5000 || p.ShardAccount.LastTransLT != 777 ||
+		!bytes.Equal(p.ShardAccount.LastTransHash, bytes.Repeat([]byte{0x77}, 32)) {
+		t.Fatalf("unexpected shard account: balance %s, %+v", p.Balance, p.ShardAccount)
+	}
+	if p.State == nil || p.State.Status != tlb.AccountStatusActive {
+		t.Fatalf("unexpected account state: %+v", p.State)
+	}
+	if _, err := cell.UnwrapProof(p.Account, account.Hash()); err != nil {
+		t.Fatalf("invalid account proof: %v", err)
+	}
+}
+
+func TestBuildMasterchainAccount(t *testing.T) {
+	addr := address.NewAddress(0, 0xff, bytes.Repeat([]byte{0x11}, 32))
+	state, stateProof, account := prepareAccountState(addr, -1, 100)
+	mcBlock := &blocktest.Block{Workchain: -1, Seqno: 100, NewState: state}
+	fixtures := &tonclient.Fixtures{}
+	prepareGetAccountState(fixtures, mcBlock.ID(), mcBlock.ID(), addr, stateProof, account)
+
+	p, err := accountproof.Build(context.Background(), tonclient.NewTonClientFixtures(fixtures), mcBlock.ID(), mcBlock.BOC(), addr, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.ShardBlockProof != nil {
+		t.Fatal("unexpected shard block proof for a masterchain account")
+	}
+	if _, err = ton.CheckBlockShardStateProof([]*cell.Cell{p.MasterchainBlockProof, p.StateProof}, mcBlock.Cell().Hash()); err != nil {
+		t.Fatalf("invalid state proof: %v", err)
+	}
+	checkAccount(t, p, account)
+}
+
+func TestBuildShardAccount(t *testing.T) {
+	addr := address.NewAddress(0, 0, bytes.Repeat([]byte{0x22}, 32))
+	state, stateProof, account := prepareAccountState(addr, 0, 10)
+	shardBlock := &blocktest.Block{Workchain: 0, Seqno: 10, NewState: state}
+	mcBlock := &blocktest.Block{
+		Workchain: -1,
+		Seqno:     100,
+		ShardHashes: map[int32]*cell.Cell{
+			0: blocktest.ShardFork(
+				blocktest.ShardDescr(10, shardBlock.Cell().Hash()),
+				blocktest.ShardDescr(11, bytes.Repeat([]byte{0x0B}, 32)),
+			),
+		},
+	}
+	fixtures := &tonclient.Fixtures{}
+	prepareGetAccountState(fixtures, mcBlock.ID(), shardBlock.ID(), addr, stateProof, account)
+	if err := fixtures.Add(ton.GetBlockData{ID: shardBlock.ID()}, ton.BlockData{ID: shardBlock.ID(), Payload: shardBlock.BOC()}); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := accountproof.Build(context.Background(), tonclient.NewTonClientFixtures(fixtures), mcBlock.ID(), mcBlock.BOC(), addr, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	block, err := ton.CheckBlockProof(p.MasterchainBlockProof, mcBlock.Cell().Hash())
+	if err != nil {
+		t.Fatalf("invalid masterchain block proof: %v", err)
+	}
+	shards, err := ton.LoadShardsFromHashes(block.Extra.Custom.ShardHashes, true)
+	if err != nil {
+		t.Fatalf("failed to load shards from the proof: %v", err)
+	}
+	if len(shards) != 1 || !bytes.Equal(shards[0].RootHash, shardBlock.Cell().Hash()) {
+		t.Fatalf("unexpected shards in the proof: %+v", shards)
+	}
+	if p.ShardBlockProof == nil {
+		t.Fatal("shard block proof is not built")
+	}
+	if _, err = ton.CheckBlockShardStateProof([]*cell.Cell{p.ShardBlockProof, p.StateProof}, shardBlock.Cell().Hash()); err != nil {
+		t.Fatalf("invalid state proof: %v", err)
+	}
+	checkAccount(t, p, account)
+}
+
+func TestBuildAccountNotFound(t *testing.T) {
+	addr := address.NewAddress(0, 0xff, bytes.Repeat([]byte{0x11}, 32))
+	other := address.NewAddress(0, 0xff, bytes.Repeat([]byte{0x33}, 32))
+	state, stateProof, _ := prepareAccountState(addr, -1, 100)
+	mcBlock := &blocktest.Block{Workchain: -1, Seqno: 100, NewState: state}
+	fixtures := &tonclient.Fixtures{}
+	prepareGetAccountState(fixtures, mcBlock.ID(), mcBlock.ID(), other, stateProof, nil)
+
+	_, err := accountproof.Build(context.Background(), tonclient.NewTonClientFixtures(fixtures), mcBlock.ID(), mcBlock.BOC(), other, false)
+	if !errors.Is(err, accountproof.ErrAccountNotFound) {
+		t.Fatalf("expected ErrAccountNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package batch
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Entry is a transaction to check: the masterchain block that proves it,
+// the transaction hash and the workchain of the transaction.
+type Entry struct {
+	Line      int
+	Seqno     uint32
+	TxHash    []byte
+	Workchain int32
+}
+
+func (e *Entry) String() string {
+	return fmt.Sprintf("%d %x", e.Seqno, e.TxHash)
+}
+
+// ParseEntries reads lines of "<seqno> <tx hash> [workchain]". The workchain is -1
+// if omitted. Empty lines and lines starting with # are skipped.
+func ParseEntries(r io.Reader) ([]*Entry, error) {
+	var entries []*Entry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 && len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected <seqno> <tx hash> [workchain]", line)
+		}
+		seqno, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid seqno: %w", line, err)
+		}
+		txHash, err := hex.DecodeString(fields[1])
+		if err != nil || len(txHash) != 32 {
+			return nil, fmt.Errorf("line %d: tx hash must be 32 bytes in hexadecimal format", line)
+		}
+		workchain := int64(-1)
+		if len(fields) == 3 {
+			if workchain, err = strconv.ParseInt(fields[2], 10, 32); err != nil {
+				return nil, fmt.Errorf("line %d: invalid workchain: %w", line, err)
+			}
+		}
+
+		entries = append(entries, &Entry{
+			Line:      line,
+			Seqno:     uint32(seqno),
+			TxHash:    txHash,
+			Workchain: int32(workchain),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,46 @@
+package batch_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/batch"
+)
+
+const txHash = "0908bfb9eb41b3186e63ab043142a3c4d493bfbaa3013094f17a15d3575a3138"
+
+func TestParseEntries(t *testing.T) {
+	input := "# deposits\n706883 " + txHash + "\n\n  706884 " + txHash + " 0  \n"
+	entries, err := batch.ParseEntries(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if e := entries[0]; e.Line != 2 || e.Seqno != 706883 || e.Workchain != -1 {
+		t.Fatalf("unexpected first entry: %+v", e)
+	}
+	if e := entries[1]; e.Line != 4 || e.Seqno != 706884 || e.Workchain != 0 {
+		t.Fatalf("unexpected second entry: %+v", e)
+	}
+	if got := entries[0].String(); got != "706883 "+txHash {
+		t.Fatalf("unexpected string %q", got)
+	}
+}
+
+func TestParseEntriesErrors(t *testing.T) {
+	for _, input := range []string{
+		"706883",
+		"seqno " + txHash,
+		"706883 0908",
+		"706883 " + txHash + " basechain",
+		"706883 " + txHash + " 0 extra",
+	} {
+		if _, err := batch.ParseEntries(strings.NewReader(input)); err == nil {
+			t.Fatalf("expected an error for %q", input)
+		} else if !strings.HasPrefix(err.Error(), "line 1:") {
+			t.Fatalf("expected the line number in %q", err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package batch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/fees"
+	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var (
+	ErrTooLarge = errors.New("message does not fit into an external message")
+	// ErrIncomplete is returned when not every message of a batch was accepted.
+	ErrIncomplete = errors.New("batch is incomplete")
+)
+
+const (
+	// MaxMessages is the number of actions a highload v3 wallet sends in one transaction.
+	// Larger batches are split into chained internal messages, so they are not packed.
+	MaxMessages = 253
+
+	// The external message of a highload v3 wallet wraps the packed messages into
+	// the signed payload, the internal message to itself and its body.
+	// Every packed message adds an action cell to the list.
+	extOverheadBytes = 512
+	extOverheadCells = 8
+	extOverheadDepth = 5
+	actionBytes      = 48
+)
+
+// group is a batch of messages with the distinct cells they bring into the external message.
+type group struct {
+	messages []*wallet.Message
+	seen     map[string]bool
+	bytes    uint64
+	bits     uint64
+	cells    uint64
+	depth    uint64
+}
+
+// Pack splits the messages into as few batches as possible, each fitting into one
+// external message of a highload v3 wallet within the limits. Cells shared by the messages,
+// such as proofs of the same block, are counted once per batch.
+// Messages keep their order within a batch.
+func Pack(messages []*wallet.Message, limits *fees.MsgLimits) ([][]*wallet.Message, error) {
+	var groups []*group
+	for i, msg := range messages {
+		msgCell, err := tlb.ToCell(msg.InternalMessage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize message %d: %w", i, err)
+		}
+
+		added := false
+		for _, g := range groups {
+			if g.tryAdd(msg, msgCell, limits) {
+				added = true
+				break
+			}
+		}
+		if added {
+			continue
+		}
+
+		g := &group{seen: map[string]bool{}}
+		if !g.tryAdd(msg, msgCell, limits) {
+			return nil, fmt.Errorf("%w: message %d", ErrTooLarge, i)
+		}
+		groups = append(groups, g)
+	}
+
+	batches := make([][]*wallet.Message, 0, len(groups))
+	for _, g := range groups {
+		batches = append(batches, g.messages)
+	}
+	return batches, nil
+}
+
+// tryAdd adds the message to the group if the group stays within the limits.
+func (g *group) tryAdd(msg *wallet.Message, msgCell *cell.Cell, limits *fees.MsgLimits) bool {
+	if len(g.messages) >= MaxMessages {
+		return false
+	}
+
+	fresh := map[string]*cell.Cell{}
+	collect(msgCell, g.seen, fresh)
+
+	bytes, bits, cells := g.bytes+actionBytes, g.bits, g.cells+1
+	for _, c := range fresh {
+		bytes += cellBytes(c)
+		bits += uint64(c.BitsSize())
+		cells++
+	}
+	depth := max(g.depth, uint64(msgCell.Depth()))
+	// the action list is a chain of cells, every message is one cell deeper
+	listDepth := uint64(len(g.messages)+1) + depth
+
+	if bytes+extOverheadBytes > limits.MaxExtMsgSize ||
+		bits > limits.MaxMsgBits ||
+		cells+extOverheadCells > limits.MaxMsgCells ||
+		listDepth+extOverheadDepth > limits.MaxExtMsgDepth {
+		return false
+	}
+
+	for key := range fresh {
+		g.seen[key] = true
+	}
+	g.messages = append(g.messages, msg)
+	g.bytes, g.bits, g.cells, g.depth = bytes, bits, cells, depth
+	return true
+}
+
+// collect adds the cells of the tree that are not in seen to fresh.
+func collect(c *cell.Cell, seen map[string]bool, fresh map[string]*cell.Cell) {
+	key := string(c.Hash())
+	if seen[key] || fresh[key] != nil {
+		return
+	}
+	fresh[key] = c
+	for i := 0; i < int(c.RefsNum()); i++ {
+		collect(c.MustPeekRef(i), seen, fresh)
+	}
+}
+
+// cellBytes is the size of the cell in a BOC: descriptors, data and ref indexes of up to 4 bytes.
+func cellBytes(c *cell.Cell) uint64 {
+	return 2 + uint64(c.BitsSize()+7)/8 + 4*uint64(c.RefsNum())
+}
+
+// Match returns the sent internal message of every message of the batch, found by
+// the destination and the body, or nil for messages that were not sent.
+func Match(messages []*wallet.Message, sent []*tlb.InternalMessage) []*tlb.InternalMessage {
+	matched := make([]*tlb.InternalMessage, len(messages))
+	used := make([]bool, len(sent))
+	for i, msg := range messages {
+		for j, s := range sent {
+			if used[j] || !s.DstAddr.Equals(msg.InternalMessage.DstAddr) {
+				continue
+			}
+			if bytes.Equal(bodyHash(s.Body), bodyHash(msg.InternalMessage.Body)) {
+				matched[i], used[j] = s, true
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// bodyHash returns the hash of the message body, a message without a body has an empty one.
+func bodyHash(body *cell.Cell) []byte {
+	if body == nil {
+		body = cell.BeginCell().EndCell()
+	}
+	return body.Hash()
+}
@@ -0,0 +1,95 @@
+package batch_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/batch"
+	"github.com/rsquad/trustless-bridge-cli/internal/fees"
+	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var txCheckerAddr = address.MustParseRawAddr("0:1111111111111111111111111111111111111111111111111111111111111111")
+
+// prepareMessage returns a message with a body of a unique cell and a shared proof of about 1KB.
+func prepareMessage(id uint64, proof *cell.Cell) *wallet.Message {
+	body := cell.BeginCell().MustStoreUInt(id, 64).MustStoreRef(proof).EndCell()
+	return wallet.SimpleMessage(txCheckerAddr, tlb.MustFromTON("1"), body)
+}
+
+func prepareProof(id uint64) *cell.Cell {
+	proof := cell.BeginCell().MustStoreUInt(id, 64).EndCell()
+	for i := 0; i < 8; i++ {
+		proof = cell.BeginCell().MustStoreUInt(id, 32).MustStoreSlice(make([]byte, 120), 960).MustStoreRef(proof).EndCell()
+	}
+	return proof
+}
+
+func TestPack(t *testing.T) {
+	limits := fees.DefaultMsgLimits
+	limits.MaxExtMsgSize = 3072
+
+	var messages []*wallet.Message
+	for i := uint64(0); i < 6; i++ {
+		messages = append(messages, prepareMessage(i, prepareProof(i)))
+	}
+	batches, err := batch.Pack(messages, &limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 3 {
+		t.Fatalf("expected 3 batches of distinct proofs, got %d", len(batches))
+	}
+	if batches[0][0] != messages[0] || batches[0][1] != messages[1] {
+		t.Fatal("messages are not packed in order")
+	}
+
+	// proofs of the same block are stored once
+	shared := prepareProof(100)
+	messages = messages[:0]
+	for i := uint64(0); i < 6; i++ {
+		messages = append(messages, prepareMessage(i, shared))
+	}
+	if batches, err = batch.Pack(messages, &limits); err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 1 || len(batches[0]) != 6 {
+		t.Fatalf("expected 1 batch with shared proofs, got %d", len(batches))
+	}
+
+	limits.MaxExtMsgSize = 1024
+	if _, err = batch.Pack(messages, &limits); !errors.Is(err, batch.ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	proof := prepareProof(0)
+	messages := []*wallet.Message{prepareMessage(1, proof), prepareMessage(2, proof), prepareMessage(3, proof)}
+	sent := []*tlb.InternalMessage{messages[2].InternalMessage, messages[0].InternalMessage}
+
+	matched := batch.Match(messages, sent)
+	if matched[0] != sent[1] || matched[1] != nil || matched[2] != sent[0] {
+		t.Fatalf("unexpected match %v", matched)
+	}
+}
+
+func TestMatchEmptyBody(t *testing.T) {
+	messages := []*wallet.Message{
+		wallet.SimpleMessage(txCheckerAddr, tlb.MustFromTON("1"), nil),
+		prepareMessage(1, prepareProof(0)),
+	}
+	// a message sent without a body is parsed with an empty one
+	sent := []*tlb.InternalMessage{
+		messages[1].InternalMessage,
+		{DstAddr: txCheckerAddr, Body: cell.BeginCell().EndCell()},
+	}
+
+	matched := batch.Match(messages, sent)
+	if matched[0] != sent[1] || matched[1] != sent[0] {
+		t.Fatalf("unexpected match %v", matched)
+	}
+}
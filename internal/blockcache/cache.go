@@ -0,0 +1,299 @@
+package blockcache
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// ErrMiss is returned when an entry is not in the cache.
+var ErrMiss = errors.New("not in cache")
+
+const (
+	kindIDs        = "ids"
+	kindBlocks     = "blocks"
+	kindProofs     = "proofs"
+	kindValidators = "validators"
+)
+
+// Cache keeps block BOCs and validator sets addressed by block root hashes,
+// block proofs addressed by the root hashes of both ends, and block ids
+// looked up by seqno. A cache holds the entries of one network.
+// When the total size exceeds the limit, the least recently used entries are evicted.
+type Cache struct {
+	dir     string
+	maxSize int64
+
+	mu   sync.Mutex
+	size int64
+}
+
+// ValidatorSet is the main validator set of config param 34 of a key block.
+type ValidatorSet struct {
+	Validators  []*tlb.ValidatorAddr `json:"validators"`
+	TotalWeight uint64               `json:"total_weight"`
+	EpochHash   []byte               `json:"epoch_hash"`
+}
+
+// Open opens the cache in dir, creating it if needed. maxSize is the limit
+// of the total size of the entries in bytes, 0 means no limit.
+func Open(dir string, maxSize int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir: %w", err)
+	}
+	c := &Cache{dir: dir, maxSize: maxSize}
+
+	entries, err := c.entries()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		c.size += e.size
+	}
+	return c, nil
+}
+
+// BlockID returns the id of the block found by the workchain, shard and seqno before.
+func (c *Cache) BlockID(workchain int32, shard int64, seqno uint32) (*ton.BlockIDExt, error) {
+	data, err := c.get(kindIDs, lookupKey(workchain, shard, seqno))
+	if err != nil {
+		return nil, err
+	}
+	var id ton.BlockIDExt
+	if err = json.Unmarshal(data, &id); err != nil {
+		return nil, fmt.Errorf("failed to parse cached block id: %w", err)
+	}
+	return &id, nil
+}
+
+func (c *Cache) PutBlockID(workchain int32, shard int64, seqno uint32, id *ton.BlockIDExt) error {
+	data, err := json.Marshal(id)
+	if err != nil {
+		return fmt.Errorf("failed to marshal block id: %w", err)
+	}
+	return c.put(kindIDs, lookupKey(workchain, shard, seqno), data)
+}
+
+// BlockBOC returns the BOC of the block. The root hash of the BOC is checked,
+// so a corrupted entry is a miss.
+func (c *Cache) BlockBOC(id *ton.BlockIDExt) ([]byte, error) {
+	key := hex.EncodeToString(id.RootHash)
+	boc, err := c.get(kindBlocks, key)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkRootHash(boc, id.RootHash); err != nil {
+		c.remove(kindBlocks, key)
+		return nil, ErrMiss
+	}
+	return boc, nil
+}
+
+// PutBlockBOC stores the BOC of the block if its root hash matches the id.
+func (c *Cache) PutBlockBOC(id *ton.BlockIDExt, boc []byte) error {
+	if err := checkRootHash(boc, id.RootHash); err != nil {
+		return err
+	}
+	return c.put(kindBlocks, hex.EncodeToString(id.RootHash), boc)
+}
+
+// BlockProof returns the proof from the known block to the target block.
+func (c *Cache) BlockProof(known, target *ton.BlockIDExt) (*ton.PartialBlockProof, error) {
+	data, err := c.get(kindProofs, proofKey(known, target))
+	if err != nil {
+		return nil, err
+	}
+	var resp tl.Serializable
+	if _, err = tl.Parse(&resp, data, true); err != nil {
+		return nil, fmt.Errorf("failed to parse cached block proof: %w", err)
+	}
+	proof, ok := resp.(ton.PartialBlockProof)
+	if !ok {
+		return nil, fmt.Errorf("unexpected cached block proof type %T", resp)
+	}
+	return &proof, nil
+}
+
+func (c *Cache) PutBlockProof(known, target *ton.BlockIDExt, proof *ton.PartialBlockProof) error {
+	data, err := tl.Serialize(*proof, true)
+	if err != nil {
+		return fmt.Errorf("failed to serialize block proof: %w", err)
+	}
+	return c.put(kindProofs, proofKey(known, target), data)
+}
+
+// Validators returns the main validator set of the key block.
+func (c *Cache) Validators(keyBlock *ton.BlockIDExt) (*ValidatorSet, error) {
+	data, err := c.get(kindValidators, hex.EncodeToString(keyBlock.RootHash))
+	if err != nil {
+		return nil, err
+	}
+	var set ValidatorSet
+	if err = json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse cached validator set: %w", err)
+	}
+	return &set, nil
+}
+
+func (c *Cache) PutValidators(keyBlock *ton.BlockIDExt, set *ValidatorSet) error {
+	data, err := json.Marshal(set)
+	if err != nil {
+		return fmt.Errorf("failed to marshal validator set: %w", err)
+	}
+	return c.put(kindValidators, hex.EncodeToString(keyBlock.RootHash), data)
+}
+
+func lookupKey(workchain int32, shard int64, seqno uint32) string {
+	return fmt.Sprintf("%d_%016x_%d", workchain, uint64(shard), seqno)
+}
+
+func proofKey(known, target *ton.BlockIDExt) string {
+	return hex.EncodeToString(known.RootHash) + "_" + hex.EncodeToString(target.RootHash)
+}
+
+func checkRootHash(boc, rootHash []byte) error {
+	root, err := cell.FromBOC(boc)
+	if err != nil {
+		return fmt.Errorf("failed to parse block BOC: %w", err)
+	}
+	if !bytes.Equal(root.Hash(), rootHash) {
+		return fmt.Errorf("block BOC hash %x does not match root hash %x", root.Hash(), rootHash)
+	}
+	return nil
+}
+
+func (c *Cache) path(kind, key string) string {
+	return filepath.Join(c.dir, kind, key)
+}
+
+// get reads the entry and marks it as recently used.
+func (c *Cache) get(kind, key string) ([]byte, error) {
+	path := c.path(kind, key)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return data, nil
+}
+
+func (c *Cache) put(kind, key string, data []byte) error {
+	path := c.path(kind, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// an overwritten entry no longer takes space
+	var oldSize int64
+	if info, err := os.Stat(path); err == nil {
+		oldSize = info.Size()
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	c.size += int64(len(data)) - oldSize
+	if c.maxSize > 0 && c.size > c.maxSize {
+		return c.evict()
+	}
+	return nil
+}
+
+func (c *Cache) remove(kind, key string) {
+	path := c.path(kind, key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	if err = os.Remove(path); err == nil {
+		c.size -= info.Size()
+	}
+}
+
+type entry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (c *Cache) entries() ([]entry, error) {
+	var entries []entry
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cache entries: %w", err)
+	}
+	return entries, nil
+}
+
+// evict removes the least recently used entries until the cache takes
+// no more than 3/4 of the limit, so that eviction does not run on every put.
+// The size is recounted, as other processes may share the cache.
+func (c *Cache) evict() error {
+	entries, err := c.entries()
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+
+	c.size = 0
+	for _, e := range entries {
+		c.size += e.size
+	}
+	for _, e := range entries {
+		if c.size <= c.maxSize*3/4 {
+			break
+		}
+		if err = os.Remove(e.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to evict cache entry: %w", err)
+		}
+		c.size -= e.size
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package blockcache_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blockcache"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func prepareBlock(tag uint64) (*ton.BlockIDExt, []byte) {
+	root := cell.BeginCell().MustStoreUInt(tag, 64).EndCell()
+	return &ton.BlockIDExt{
+		Workchain: -1,
+		Shard:     -9223372036854775808,
+		SeqNo:     uint32(tag),
+		RootHash:  root.Hash(),
+		FileHash:  make([]byte, 32),
+	}, root.ToBOC()
+}
+
+func TestBlockBOC(t *testing.T) {
+	cache, err := blockcache.Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, boc := prepareBlock(1)
+
+	if _, err = cache.BlockBOC(id); !errors.Is(err, blockcache.ErrMiss) {
+		t.Fatalf("expected miss, got %v", err)
+	}
+	if err = cache.PutBlockBOC(id, boc); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cache.BlockBOC(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, boc) {
+		t.Fatalf("unexpected boc: %x", got)
+	}
+
+	_, otherBOC := prepareBlock(2)
+	if err = cache.PutBlockBOC(id, otherBOC); err == nil {
+		t.Fatalf("expected root hash mismatch")
+	}
+}
+
+func TestBlockIDAndProof(t *testing.T) {
+	cache, err := blockcache.Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	known, _ := prepareBlock(1)
+	target, _ := prepareBlock(2)
+
+	if err = cache.PutBlockID(-1, 0, 2, target); err != nil {
+		t.Fatal(err)
+	}
+	id, err := cache.BlockID(-1, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !id.Equals(target) {
+		t.Fatalf("unexpected block id: %v", id)
+	}
+
+	proof := &ton.PartialBlockProof{Complete: true, From: known, To: target, Steps: []any{}}
+	if err = cache.PutBlockProof(known, target, proof); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cache.BlockProof(known, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Complete || !got.From.Equals(known) || !got.To.Equals(target) {
+		t.Fatalf("unexpected proof: %+v", got)
+	}
+	if _, err = cache.BlockProof(target, known); !errors.Is(err, blockcache.ErrMiss) {
+		t.Fatalf("expected miss, got %v", err)
+	}
+}
+
+func TestValidators(t *testing.T) {
+	cache, err := blockcache.Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyBlock, _ := prepareBlock(1)
+	set := &blockcache.ValidatorSet{
+		Validators: []*tlb.ValidatorAddr{{
+			PublicKey: tlb.SigPubKeyED25519{Key: bytes.Repeat([]byte{0x11}, 32)},
+			Weight:    100,
+			ADNLAddr:  make([]byte, 32),
+		}},
+		TotalWeight: 100,
+		EpochHash:   bytes.Repeat([]byte{0xAB}, 32),
+	}
+	if err = cache.PutValidators(keyBlock, set); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cache.Validators(keyBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TotalWeight != 100 || len(got.Validators) != 1 ||
+		!bytes.Equal(got.Validators[0].PublicKey.Key, set.Validators[0].PublicKey.Key) ||
+		!bytes.Equal(got.EpochHash, set.EpochHash) {
+		t.Fatalf("unexpected validator set: %+v", got)
+	}
+}
+
+func TestEviction(t *testing.T) {
+	dir := t.TempDir()
+	_, boc := prepareBlock(0)
+	// room for three blocks, the fourth one triggers eviction
+	cache, err := blockcache.Open(dir, int64(len(boc))*3+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []*ton.BlockIDExt
+	for i := uint64(1); i <= 3; i++ {
+		id, boc := prepareBlock(i)
+		if err = cache.PutBlockBOC(id, boc); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	// age the first two blocks, reading the first one then leaves the second one least recently used
+	past := time.Now().Add(-time.Hour)
+	for i, id := range ids[:2] {
+		at := past.Add(time.Duration(i) * time.Minute)
+		path := filepath.Join(dir, "blocks", hex.EncodeToString(id.RootHash))
+		if err = os.Chtimes(path, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = cache.BlockBOC(ids[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	id, boc := prepareBlock(4)
+	if err = cache.PutBlockBOC(id, boc); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = cache.BlockBOC(ids[1]); !errors.Is(err, blockcache.ErrMiss) {
+		t.Fatalf("expected the least recently used block to be evicted, got %v", err)
+	}
+	for _, id := range []*ton.BlockIDExt{ids[0], id} {
+		if _, err = cache.BlockBOC(id); err != nil {
+			t.Fatalf("block %d evicted: %v", id.SeqNo, err)
+		}
+	}
+}
+
+func TestOverwriteSize(t *testing.T) {
+	_, boc := prepareBlock(0)
+	// room for three blocks, overwriting an entry does not take more room
+	cache, err := blockcache.Open(t.TempDir(), int64(len(boc))*3+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, firstBOC := prepareBlock(1)
+	for i := 0; i < 5; i++ {
+		if err = cache.PutBlockBOC(first, firstBOC); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var ids []*ton.BlockIDExt
+	for i := uint64(2); i <= 3; i++ {
+		id, boc := prepareBlock(i)
+		if err = cache.PutBlockBOC(id, boc); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	for _, id := range append(ids, first) {
+		if _, err = cache.BlockBOC(id); err != nil {
+			t.Fatalf("block %d evicted: %v", id.SeqNo, err)
+		}
+	}
+}
+
+func TestCorruptedEntrySize(t *testing.T) {
+	_, boc := prepareBlock(0)
+	// room for three blocks, a removed corrupted entry does not take room
+	dir := t.TempDir()
+	cache, err := blockcache.Open(dir, int64(len(boc))*3+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	corrupted, corruptedBOC := prepareBlock(1)
+	if err = cache.PutBlockBOC(corrupted, corruptedBOC); err != nil {
+		t.Fatal(err)
+	}
+	_, otherBOC := prepareBlock(2)
+	path := filepath.Join(dir, "blocks", hex.EncodeToString(corrupted.RootHash))
+	if err = os.WriteFile(path, otherBOC, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cache.BlockBOC(corrupted); !errors.Is(err, blockcache.ErrMiss) {
+		t.Fatalf("expected miss, got %v", err)
+	}
+
+	var ids []*ton.BlockIDExt
+	for i := uint64(3); i <= 5; i++ {
+		id, boc := prepareBlock(i)
+		if err = cache.PutBlockBOC(id, boc); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	for _, id := range ids {
+		if _, err = cache.BlockBOC(id); err != nil {
+			t.Fatalf("block %d evicted: %v", id.SeqNo, err)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+// Package blocktest builds synthetic blocks and validator sets for tests. The blocks are
+// serialized in the same layout as real ones, so they can be parsed, proven and checked
+// with tonutils-go, but only the fields used by the proofs carry data.
+package blocktest
+
+import (
+	"crypto/ed25519"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Validator is a validator of a synthetic set together with its private key.
+type Validator struct {
+	PublicKey  ed25519.PublicKey
+	PrivateKey ed25519.PrivateKey
+	Weight     uint64
+}
+
+// NewValidators generates a validator with a new key for every weight.
+func NewValidators(weights ...uint64) []Validator {
+	validators := make([]Validator, len(weights))
+	for i, w := range weights {
+		pub, priv, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			panic(err)
+		}
+		validators[i] = Validator{PublicKey: pub, PrivateKey: priv, Weight: w}
+	}
+	return validators
+}
+
+// ValidatorSet returns the validators_ext value of a validator set config param,
+// in which all validators are main ones.
+func ValidatorSet(utimeSince uint32, validators []Validator) *cell.Cell {
+	list := cell.NewDict(16)
+	var totalWeight uint64
+	for i, v := range validators {
+		addr := cell.BeginCell().
+			MustStoreUInt(0x73, 8).
+			MustStoreUInt(0x8e81278a, 32).
+			MustStoreSlice(v.PublicKey, 256).
+			MustStoreUInt(v.Weight, 64).
+			MustStoreSlice(make([]byte, 32), 256).
+			EndCell()
+		if err := list.SetIntKey(big.NewInt(int64(i)), addr); err != nil {
+			panic(err)
+		}
+		totalWeight += v.Weight
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(0x12, 8).
+		MustStoreUInt(uint64(utimeSince), 32).
+		MustStoreUInt(uint64(utimeSince)+65536, 32).
+		MustStoreUInt(uint64(len(validators)), 16).
+		MustStoreUInt(uint64(len(validators)), 16).
+		MustStoreUInt(totalWeight, 64).
+		MustStoreDict(list).
+		EndCell()
+}
+
+// ShardDescr returns a leaf of a ShardHashes bin tree with the description of a shard block.
+func ShardDescr(seqno uint32, rootHash []byte) *cell.Cell {
+	return cell.BeginCell().
+		MustStoreBoolBit(false).
+		MustStoreUInt(0xa, 4).
+		MustStoreUInt(uint64(seqno), 32).
+		MustStoreUInt(0, 32).
+		MustStoreUInt(0, 64).
+		MustStoreUInt(0, 64).
+		MustStoreSlice(rootHash, 256).
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreUInt(0, 5+3).
+		MustStoreUInt(0, 32).
+		// next_validator_shard, which liteservers return as the shard, is the unsplit one as in Block.ID
+		MustStoreUInt(1<<63, 64).
+		MustStoreUInt(0, 32+32).
+		MustStoreUInt(0, 1).
+		MustStoreRef(cell.BeginCell().
+			MustStoreCoins(0).MustStoreDict(nil).
+			MustStoreCoins(0).MustStoreDict(nil).
+			EndCell()).
+		EndCell()
+}
+
+// ShardFork returns a fork of a ShardHashes bin tree.
+func ShardFork(left, right *cell.Cell) *cell.Cell {
+	return cell.BeginCell().MustStoreBoolBit(true).MustStoreRef(left).MustStoreRef(right).EndCell()
+}
+
+// Block describes a synthetic block.
+type Block struct {
+	Workchain int32
+	Seqno     uint32
+	// CatchainSeqno and ValidatorSetHash are the gen_catchain_seqno and
+	// the gen_validator_list_hash_short of the block info.
+	CatchainSeqno    uint32
+	ValidatorSetHash uint32
+	PrevKeyBlock     uint32
+	// Config makes a masterchain block a key block with the given config param values.
+	Config map[uint32]*cell.Cell
+	// ShardHashes are the bin trees of the shard descriptions by workchain.
+	ShardHashes map[int32]*cell.Cell
+	// Accounts is the ShardAccountBlocks dict, see AccountBlocks.
+	Accounts *cell.Dictionary
+	// NewState is the new state of the state update.
+	NewState *cell.Cell
+	// MasterRef is the seqno of the masterchain block referred by a shardchain block.
+	MasterRef uint32
+}
+
+// Cell serializes the block.
+func (b *Block) Cell() *cell.Cell {
+	master := b.Workchain == -1
+	keyBlock := master && b.Config != nil
+
+	info := cell.BeginCell().
+		MustStoreUInt(0x9bc7a987, 32).
+		MustStoreUInt(0, 32).
+		MustStoreBoolBit(!master).
+		MustStoreUInt(0, 5).
+		MustStoreBoolBit(keyBlock).
+		MustStoreBoolBit(false).
+		MustStoreUInt(0, 8).
+		MustStoreUInt(uint64(b.Seqno), 32).
+		MustStoreUInt(0, 32).
+		MustStoreUInt(0, 2).
+		MustStoreUInt(0, 6).
+		MustStoreInt(int64(b.Workchain), 32).
+		MustStoreUInt(1<<63, 64).
+		MustStoreUInt(1700000000, 32).
+		MustStoreUInt(uint64(b.Seqno)*1000000, 64).
+		MustStoreUInt(uint64(b.Seqno)*1000000+1000, 64).
+		MustStoreUInt(uint64(b.ValidatorSetHash), 32).
+		MustStoreUInt(uint64(b.CatchainSeqno), 32).
+		MustStoreUInt(0, 32).
+		MustStoreUInt(uint64(b.PrevKeyBlock), 32)
+	if !master {
+		info.MustStoreRef(extBlkRef(b.MasterRef))
+	}
+	info.MustStoreRef(extBlkRef(b.Seqno - 1))
+
+	newState := b.NewState
+	if newState == nil {
+		newState = cell.BeginCell().EndCell()
+	}
+	stateUpdate := cell.BeginCell().
+		MustStoreUInt(0x72, 8).
+		MustStoreRef(cell.BeginCell().EndCell()).
+		MustStoreRef(newState).
+		EndCell()
+
+	accounts := b.Accounts
+	if accounts == nil {
+		accounts = cell.NewDict(256)
+	}
+	extra := cell.BeginCell().
+		MustStoreUInt(0x4a33f6fd, 32).
+		MustStoreRef(cell.BeginCell().EndCell()).
+		MustStoreRef(cell.BeginCell().EndCell()).
+		MustStoreRef(cell.BeginCell().MustStoreDict(accounts).EndCell()).
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreSlice(make([]byte, 32), 256)
+	if master {
+		extra.MustStoreMaybeRef(b.mcExtra(keyBlock))
+	} else {
+		extra.MustStoreMaybeRef(nil)
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(0x11ef55aa, 32).
+		MustStoreInt(-239, 32).
+		MustStoreRef(info.EndCell()).
+		MustStoreRef(cell.BeginCell().MustStoreUInt(uint64(b.Seqno), 32).EndCell()).
+		MustStoreRef(stateUpdate).
+		MustStoreRef(extra.EndCell()).
+		EndCell()
+}
+
+// ShardHashes returns the ShardHashes dict with the bin trees of the shard descriptions by workchain.
+func ShardHashes(binTrees map[int32]*cell.Cell) *cell.Dictionary {
+	shardHashes := cell.NewDict(32)
+	for workchain, binTree := range binTrees {
+		key := cell.BeginCell().MustStoreInt(int64(workchain), 32).EndCell()
+		if err := shardHashes.Set(key, cell.BeginCell().MustStoreRef(binTree).EndCell()); err != nil {
+			panic(err)
+		}
+	}
+	return shardHashes
+}
+
+func (b *Block) mcExtra(keyBlock bool) *cell.Cell {
+	extra := cell.BeginCell().
+		MustStoreUInt(0xcca5, 16).
+		MustStoreBoolBit(keyBlock).
+		MustStoreDict(ShardHashes(b.ShardHashes)).
+		MustStoreDict(nil).
+		MustStoreRef(cell.BeginCell().
+			MustStoreDict(nil).
+			MustStoreMaybeRef(nil).
+			MustStoreMaybeRef(nil).
+			EndCell())
+	if keyBlock {
+		params := cell.NewDict(32)
+		for param, value := range b.Config {
+			if err := params.SetIntKey(big.NewInt(int64(param)), cell.BeginCell().MustStoreRef(value).EndCell()); err != nil {
+				panic(err)
+			}
+		}
+		extra.
+			MustStoreSlice(make([]byte, 32), 256).
+			MustStoreRef(params.AsCell())
+	}
+	return extra.EndCell()
+}
+
+func extBlkRef(seqno uint32) *cell.Cell {
+	return cell.BeginCell().
+		MustStoreUInt(uint64(seqno)*1000000+1000, 64).
+		MustStoreUInt(uint64(seqno), 32).
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreSlice(make([]byte, 32), 256).
+		EndCell()
+}
+
+// BOC serializes the block to a bag of cells.
+func (b *Block) BOC() []byte {
+	return b.Cell().ToBOC()
+}
+
+// ID returns the id of the block, with the zero file hash.
+func (b *Block) ID() *ton.BlockIDExt {
+	shard := int64(-9223372036854775808)
+	return &ton.BlockIDExt{
+		Workchain: b.Workchain,
+		Shard:     shard,
+		SeqNo:     b.Seqno,
+		RootHash:  b.Cell().Hash(),
+		FileHash:  make([]byte, 32),
+	}
+}
+
+// Parse parses the block as tonutils-go does.
+func (b *Block) Parse() *tlb.Block {
+	var block tlb.Block
+	if err := tlb.LoadFromCell(&block, b.Cell().BeginParse()); err != nil {
+		panic(err)
+	}
+	return &block
+}
+
+// AccountBlocks returns a ShardAccountBlocks dict with the transactions of the accounts,
+// which are grouped by their account address.
+func AccountBlocks(txs ...*cell.Cell) *cell.Dictionary {
+	byAccount := map[string]*cell.Dictionary{}
+	var order []string
+	for _, txCell := range txs {
+		var tx tlb.Transaction
+		if err := tlb.LoadFromCell(&tx, txCell.BeginParse()); err != nil {
+			panic(err)
+		}
+		addr := string(tx.AccountAddr)
+		if byAccount[addr] == nil {
+			byAccount[addr] = cell.NewDict(64)
+			order = append(order, addr)
+		}
+		value := cell.BeginCell().MustStoreCoins(0).MustStoreDict(nil).MustStoreRef(txCell).EndCell()
+		if err := byAccount[addr].Set(cell.BeginCell().MustStoreUInt(tx.LT, 64).EndCell(), value); err != nil {
+			panic(err)
+		}
+	}
+
+	accounts := cell.NewDict(256)
+	for _, addr := range order {
+		accBlock := cell.BeginCell().
+			MustStoreCoins(0).MustStoreDict(nil).
+			MustStoreUInt(0x5, 4).
+			MustStoreSlice([]byte(addr), 256).
+			MustStoreBuilder(dictInline(byAccount[addr])).
+			MustStoreRef(cell.BeginCell().MustStoreUInt(0x72, 8).
+				MustStoreSlice(make([]byte, 32), 256).
+				MustStoreSlice(make([]byte, 32), 256).
+				EndCell()).
+			EndCell()
+		if err := accounts.Set(cell.BeginCell().MustStoreSlice([]byte(addr), 256).EndCell(), accBlock); err != nil {
+			panic(err)
+		}
+	}
+	return accounts
+}
+
+// dictInline stores a non-empty dict without the maybe bit and the ref, as HashmapAug is stored.
+func dictInline(dict *cell.Dictionary) *cell.Builder {
+	return dict.AsCell().ToBuilder()
+}
+
+// Transaction returns a transaction of the account with the given lt, inbound message and outbound messages.
+func Transaction(account []byte, lt uint64, inMsg *cell.Cell, outMsgs ...*cell.Cell) *cell.Cell {
+	return transaction(account, lt, lt-1, make([]byte, 32), inMsg, outMsgs)
+}
+
+// NextTransaction returns a transaction of the account like Transaction,
+// which refers to the previous transaction of the account.
+func NextTransaction(prev *cell.Cell, lt uint64, inMsg *cell.Cell, outMsgs ...*cell.Cell) *cell.Cell {
+	var tx tlb.Transaction
+	if err := tlb.LoadFromCell(&tx, prev.BeginParse()); err != nil {
+		panic(err)
+	}
+	return transaction(tx.AccountAddr, lt, tx.LT, prev.Hash(), inMsg, outMsgs)
+}
+
+func transaction(account []byte, lt, prevLT uint64, prevHash []byte, inMsg *cell.Cell, outMsgs []*cell.Cell) *cell.Cell {
+	out := cell.NewDict(15)
+	for i, msg := range outMsgs {
+		key := cell.BeginCell().MustStoreUInt(uint64(i), 15).EndCell()
+		if err := out.Set(key, cell.BeginCell().MustStoreRef(msg).EndCell()); err != nil {
+			panic(err)
+		}
+	}
+
+	description := successDescription()
+	return cell.BeginCell().
+		MustStoreUInt(0b0111, 4).
+		MustStoreSlice(account, 256).
+		MustStoreUInt(lt, 64).
+		MustStoreSlice(prevHash, 256).
+		MustStoreUInt(prevLT, 64).
+		MustStoreUInt(1700000000, 32).
+		MustStoreUInt(uint64(len(outMsgs)), 15).
+		MustStoreUInt(0b10, 2).
+		MustStoreUInt(0b10, 2).
+		MustStoreRef(cell.BeginCell().
+			MustStoreMaybeRef(inMsg).
+			MustStoreDict(out).
+			EndCell()).
+		MustStoreCoins(0).
+		MustStoreDict(nil).
+		MustStoreRef(cell.BeginCell().
+			MustStoreUInt(0x72, 8).
+			MustStoreSlice(make([]byte, 32), 256).
+			MustStoreSlice(make([]byte, 32), 256).
+			EndCell()).
+		MustStoreRef(description).
+		EndCell()
+}
+
+// successDescription is an ordinary transaction description with a successful compute phase.
+func successDescription() *cell.Cell {
+	vm := tlb.ComputePhaseVM{Success: true, GasFees: tlb.ZeroCoins}
+	vm.Details.GasUsed = big.NewInt(0)
+	vm.Details.GasLimit = big.NewInt(0)
+	vm.Details.VMInitStateHash = make([]byte, 32)
+	vm.Details.VMFinalStateHash = make([]byte, 32)
+	description, err := tlb.ToCell(tlb.TransactionDescriptionOrdinary{ComputePhase: tlb.ComputePhase{Phase: vm}})
+	if err != nil {
+		panic(err)
+	}
+	return description
+}
@@ -0,0 +1,91 @@
+package blocktest
+
+import (
+	"crypto/ed25519"
+	"hash/crc32"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// CatchainConfig returns a catchain_config value of config param 28,
+// with which masterchain validators are not shuffled.
+func CatchainConfig() *cell.Cell {
+	return cell.BeginCell().
+		MustStoreUInt(0xc1, 8).
+		MustStoreUInt(250, 32).
+		MustStoreUInt(250, 32).
+		MustStoreUInt(1000, 32).
+		MustStoreUInt(7, 32).
+		EndCell()
+}
+
+// ValidatorSetHash returns the short hash of the validators of the catchain,
+// which blocks keep as gen_validator_list_hash_short.
+func ValidatorSetHash(catchainSeqno uint32, validators []Validator) uint32 {
+	set := ton.ValidatorSetHashable{CCSeqno: catchainSeqno}
+	for _, v := range validators {
+		set.Validators = append(set.Validators, ton.ValidatorItemHashable{
+			Key:    v.PublicKey,
+			Weight: v.Weight,
+			Addr:   make([]byte, 32),
+		})
+	}
+	data, err := tl.Serialize(set, true)
+	if err != nil {
+		panic(err)
+	}
+	return crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))
+}
+
+// Sign returns the signatures of the block by the validators, as liteservers return them.
+func Sign(block *ton.BlockIDExt, validators []Validator) []ton.Signature {
+	payload, err := tl.Serialize(ton.BlockID{RootHash: block.RootHash, FileHash: block.FileHash}, true)
+	if err != nil {
+		panic(err)
+	}
+	signatures := make([]ton.Signature, len(validators))
+	for i, v := range validators {
+		nodeID, err := tl.Hash(adnl.PublicKeyED25519{Key: v.PublicKey})
+		if err != nil {
+			panic(err)
+		}
+		signatures[i] = ton.Signature{NodeIDShort: nodeID, Signature: ed25519.Sign(v.PrivateKey, payload)}
+	}
+	return signatures
+}
+
+// ForwardLink returns the forward link of a block proof from the key block to the block,
+// signed by the validators. The key block must keep config params 28 and 34 with the validators,
+// and the block must be generated by their catchain, see ValidatorSetHash.
+func ForwardLink(from, to *Block, validators []Validator) ton.BlockLinkForward {
+	toSk := cell.CreateProofSkeleton()
+	toSk.ProofRef(0)
+	destProof, err := to.Cell().CreateProof(toSk)
+	if err != nil {
+		panic(err)
+	}
+
+	fromSk := cell.CreateProofSkeleton()
+	fromSk.ProofRef(3).ProofRef(3).SetRecursive()
+	configProof, err := from.Cell().CreateProof(fromSk)
+	if err != nil {
+		panic(err)
+	}
+
+	toID := to.ID()
+	return ton.BlockLinkForward{
+		ToKeyBlock:  to.Config != nil,
+		From:        from.ID(),
+		To:          toID,
+		DestProof:   destProof.ToBOC(),
+		ConfigProof: configProof.ToBOC(),
+		SignatureSet: &ton.SignatureSet{
+			ValidatorSetHash: int32(to.ValidatorSetHash),
+			CatchainSeqno:    int32(to.CatchainSeqno),
+			Signatures:       Sign(toID, validators),
+		},
+	}
+}
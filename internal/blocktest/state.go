@@ -0,0 +1,89 @@
+package blocktest
+
+import (
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Account returns an active account with the code and the data.
+func Account(addr *address.Address, balance uint64, code, data *cell.Cell) *cell.Cell {
+	return cell.BeginCell().
+		MustStoreBoolBit(true).
+		MustStoreAddr(addr).
+		MustStoreVarUInt(0, 7).
+		MustStoreVarUInt(0, 7).
+		MustStoreVarUInt(0, 7).
+		MustStoreUInt(1700000000, 32).
+		MustStoreBoolBit(false).
+		MustStoreUInt(0, 64).
+		MustStoreCoins(balance).
+		MustStoreDict(nil).
+		MustStoreBoolBit(true).
+		MustStoreBoolBit(false).
+		MustStoreBoolBit(false).
+		MustStoreMaybeRef(code).
+		MustStoreMaybeRef(data).
+		MustStoreDict(nil).
+		EndCell()
+}
+
+// ShardAccount returns a value of the ShardAccounts dict of a shard state.
+func ShardAccount(account *cell.Cell, balance uint64, lastLT uint64, lastHash []byte) *cell.Cell {
+	return cell.BeginCell().
+		MustStoreUInt(0, 5).
+		MustStoreCoins(balance).
+		MustStoreDict(nil).
+		MustStoreRef(account).
+		MustStoreSlice(lastHash, 256).
+		MustStoreUInt(lastLT, 64).
+		EndCell()
+}
+
+// ShardState returns a ShardStateUnsplit with the ShardAccounts dict.
+func ShardState(workchain int32, seqno uint32, accounts *cell.Dictionary) *cell.Cell {
+	return shardState(workchain, seqno, accounts, nil)
+}
+
+// MasterState returns a masterchain ShardStateUnsplit with the ShardAccounts dict
+// and the config param values in its McStateExtra.
+func MasterState(seqno uint32, accounts *cell.Dictionary, config map[uint32]*cell.Cell) *cell.Cell {
+	params := cell.NewDict(32)
+	for param, value := range config {
+		if err := params.SetIntKey(big.NewInt(int64(param)), cell.BeginCell().MustStoreRef(value).EndCell()); err != nil {
+			panic(err)
+		}
+	}
+	extra := cell.BeginCell().
+		MustStoreUInt(0xcc26, 16).
+		MustStoreDict(nil).
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreRef(params.AsCell()).
+		MustStoreRef(cell.BeginCell().EndCell()).
+		MustStoreCoins(0).
+		MustStoreDict(nil).
+		EndCell()
+	return shardState(-1, seqno, accounts, extra)
+}
+
+func shardState(workchain int32, seqno uint32, accounts *cell.Dictionary, mcExtra *cell.Cell) *cell.Cell {
+	return cell.BeginCell().
+		MustStoreUInt(0x9023afe2, 32).
+		MustStoreInt(-239, 32).
+		MustStoreUInt(0, 2).
+		MustStoreUInt(0, 6).
+		MustStoreInt(int64(workchain), 32).
+		MustStoreUInt(1<<63, 64).
+		MustStoreUInt(uint64(seqno), 32).
+		MustStoreUInt(0, 32).
+		MustStoreUInt(1700000000, 32).
+		MustStoreUInt(uint64(seqno)*1000000, 64).
+		MustStoreUInt(0, 32).
+		MustStoreRef(cell.BeginCell().EndCell()).
+		MustStoreBoolBit(false).
+		MustStoreRef(cell.BeginCell().MustStoreDict(accounts).EndCell()).
+		MustStoreRef(cell.BeginCell().EndCell()).
+		MustStoreMaybeRef(mcExtra).
+		EndCell()
+}
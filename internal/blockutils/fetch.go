@@ -10,12 +10,12 @@ import (
 )
 
 func FetchMasterchainBlock(ctx context.Context, tonClient *tonclient.TonClient, seqno uint32) (*tlb.Block, error) {
-	blockIDExt, err := tonClient.API.LookupBlock(ctx, -1, 0, seqno)
+	blockIDExt, err := tonClient.LookupBlock(ctx, -1, 0, seqno)
 	if err != nil {
 		return nil, err
 	}
 
-	return tonClient.API.GetBlockData(ctx, blockIDExt)
+	return tonClient.GetBlockData(ctx, blockIDExt)
 }
 
 func FetchMasterchainBlockBOC(
@@ -23,7 +23,7 @@ func FetchMasterchainBlockBOC(
 	tonClient *tonclient.TonClient,
 	seqno uint32,
 ) (*ton.BlockIDExt, []byte, error) {
-	blockIDExt, err := tonClient.API.LookupBlock(ctx, -1, 0, seqno)
+	blockIDExt, err := tonClient.LookupBlock(ctx, -1, 0, seqno)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to lookup block: %w", err)
 	}
@@ -33,3 +33,35 @@ func FetchMasterchainBlockBOC(
 	}
 	return blockIDExt, blockBOC, nil
 }
+
+// FetchShardBlocksBOC returns the shardchain blocks of the workchain
+// that are registered in the given masterchain block.
+func FetchShardBlocksBOC(
+	ctx context.Context,
+	tonClient *tonclient.TonClient,
+	master *ton.BlockIDExt,
+	workchain int32,
+) ([]*ton.BlockIDExt, [][]byte, error) {
+	shards, err := tonClient.API.GetBlockShardsInfo(ctx, master)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get shards info: %w", err)
+	}
+
+	var ids []*ton.BlockIDExt
+	var bocs [][]byte
+	for _, shard := range shards {
+		if shard.Workchain != workchain {
+			continue
+		}
+		boc, err := tonClient.GetBlockBOC(ctx, shard)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get shard block BOC: %w", err)
+		}
+		ids = append(ids, shard)
+		bocs = append(bocs, boc)
+	}
+	if len(ids) == 0 {
+		return nil, nil, fmt.Errorf("no shard blocks of workchain %d in masterchain block %d", workchain, master.SeqNo)
+	}
+	return ids, bocs, nil
+}
@@ -0,0 +1,75 @@
+package blockutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+var (
+	ErrNotKeyBlock = errors.New("block is not a key block")
+	ErrChainEnd    = errors.New("key block chain ends")
+)
+
+// KeyBlock is a masterchain key block with the main validators of its config.
+type KeyBlock struct {
+	ID          *ton.BlockIDExt
+	Validators  []*tlb.ValidatorAddr
+	TotalWeight uint64
+	EpochHash   []byte
+}
+
+// WalkKeyBlocks follows the key block chain back from the key block with the seqno
+// until stop returns true for a key block, and returns the key blocks before it, oldest first.
+// At most maxKeyBlocks key blocks are returned, 0 means no limit.
+// The chain must reach the stop block, ErrChainEnd is returned otherwise.
+func WalkKeyBlocks(
+	ctx context.Context,
+	tonClient *tonclient.TonClient,
+	seqno uint32,
+	maxKeyBlocks int,
+	stop func(*KeyBlock) bool,
+) ([]*KeyBlock, error) {
+	var chain []*KeyBlock
+	for {
+		id, err := tonClient.LookupBlock(ctx, -1, 0, seqno)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lookup key block %d: %w", seqno, err)
+		}
+		block, err := tonClient.GetBlockData(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get key block %d: %w", seqno, err)
+		}
+		if !block.BlockInfo.KeyBlock {
+			return nil, fmt.Errorf("%w: %d", ErrNotKeyBlock, seqno)
+		}
+		validators, totalWeight, epochHash, err := ExtractMainValidators(block, tonClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract main validators of key block %d: %w", seqno, err)
+		}
+
+		keyBlock := &KeyBlock{ID: id, Validators: validators, TotalWeight: totalWeight, EpochHash: epochHash}
+		if stop(keyBlock) {
+			break
+		}
+		if maxKeyBlocks > 0 && len(chain) == maxKeyBlocks {
+			return nil, fmt.Errorf("%w: no stop in %d key blocks", ErrChainEnd, maxKeyBlocks)
+		}
+		chain = append(chain, keyBlock)
+
+		// the zerostate is not a block, so the chain ends at the first key block
+		if block.BlockInfo.PrevKeyBlockSeqno == 0 || block.BlockInfo.PrevKeyBlockSeqno >= seqno {
+			return nil, fmt.Errorf("%w: at block %d", ErrChainEnd, seqno)
+		}
+		seqno = block.BlockInfo.PrevKeyBlockSeqno
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
+}
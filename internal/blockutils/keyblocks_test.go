@@ -0,0 +1,92 @@
+package blockutils_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blocktest"
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// prepareKeyBlockChain adds a key block every 100 blocks with the validator sets,
+// each key block referring to the previous one.
+func prepareKeyBlockChain(fixtures *tonclient.Fixtures, sets ...*cell.Cell) []*blocktest.Block {
+	var blocks []*blocktest.Block
+	for i, set := range sets {
+		block := prepareKeyBlock(uint32(i+1)*100, set)
+		block.PrevKeyBlock = uint32(i) * 100
+		id := block.ID()
+		err := fixtures.Add(
+			ton.LookupBlock{Mode: 1, ID: &ton.BlockInfoShort{Workchain: -1, Shard: 0, Seqno: int32(block.Seqno)}},
+			ton.BlockHeader{ID: id, HeaderProof: []byte{}},
+		)
+		if err != nil {
+			panic(err)
+		}
+		if err = fixtures.Add(ton.GetBlockData{ID: id}, ton.BlockData{ID: id, Payload: block.BOC()}); err != nil {
+			panic(err)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+func TestWalkKeyBlocks(t *testing.T) {
+	first := blocktest.ValidatorSet(1000, blocktest.NewValidators(40, 30, 20, 10))
+	second := blocktest.ValidatorSet(2000, blocktest.NewValidators(50, 50))
+	third := blocktest.ValidatorSet(3000, blocktest.NewValidators(100))
+	fixtures := &tonclient.Fixtures{}
+	prepareKeyBlockChain(fixtures, first, first, second, third)
+	client := tonclient.NewTonClientFixtures(fixtures)
+
+	// the walk stops at the first key block of the epoch, not at the first one of the chain
+	chain, err := blockutils.WalkKeyBlocks(context.Background(), client, 400, 10, func(keyBlock *blockutils.KeyBlock) bool {
+		return bytes.Equal(keyBlock.EpochHash, blockutils.EpochHash(first))
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(chain) != 2 || chain[0].ID.SeqNo != 300 || chain[1].ID.SeqNo != 400 {
+		t.Fatalf("unexpected chain: %+v", chain)
+	}
+	if !bytes.Equal(chain[0].EpochHash, second.Hash()) || !bytes.Equal(chain[1].EpochHash, third.Hash()) {
+		t.Fatalf("unexpected epoch hashes: %x, %x", chain[0].EpochHash, chain[1].EpochHash)
+	}
+	if len(chain[0].Validators) != 2 || chain[0].TotalWeight != 100 {
+		t.Fatalf("unexpected validators of key block 300: %d, total weight %d", len(chain[0].Validators), chain[0].TotalWeight)
+	}
+
+	chain, err = blockutils.WalkKeyBlocks(context.Background(), client, 400, 0, func(keyBlock *blockutils.KeyBlock) bool {
+		return keyBlock.ID.SeqNo <= 200
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(chain) != 2 || chain[0].ID.SeqNo != 300 || chain[1].ID.SeqNo != 400 {
+		t.Fatalf("unexpected chain: %+v", chain)
+	}
+}
+
+func TestWalkKeyBlocksChainEnd(t *testing.T) {
+	first := blocktest.ValidatorSet(1000, blocktest.NewValidators(40, 30, 20, 10))
+	second := blocktest.ValidatorSet(2000, blocktest.NewValidators(50, 50))
+	fixtures := &tonclient.Fixtures{}
+	prepareKeyBlockChain(fixtures, first, second, second)
+	client := tonclient.NewTonClientFixtures(fixtures)
+	unknown := func(keyBlock *blockutils.KeyBlock) bool {
+		return bytes.Equal(keyBlock.EpochHash, make([]byte, 32))
+	}
+
+	if _, err := blockutils.WalkKeyBlocks(context.Background(), client, 300, 2, unknown); !errors.Is(err, blockutils.ErrChainEnd) {
+		t.Fatalf("expected the limit of key blocks, got %v", err)
+	}
+	// key block 100 refers to the zerostate, which is not walked
+	if _, err := blockutils.WalkKeyBlocks(context.Background(), client, 300, 0, unknown); !errors.Is(err, blockutils.ErrChainEnd) {
+		t.Fatalf("expected the end of the chain, got %v", err)
+	}
+}
@@ -1,53 +1,220 @@
 package blockutils
 
 import (
+	"bytes"
+	"fmt"
+
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
 func BuildBlockProof(blockBOC []byte) (*cell.Cell, error) {
-	blockCell, err := cell.FromBOC(blockBOC)
+	blockCell, block, err := parseBlockBOC(blockBOC)
+	if err != nil {
+		return nil, err
+	}
+
+	rootSk, _, err := blockProofSk(block)
 	if err != nil {
 		return nil, err
 	}
 
+	return blockCell.CreateProof(rootSk)
+}
+
+// BuildBlockProofWithShard builds the same proof of a masterchain block as BuildBlockProof,
+// but additionally keeps the branch of ShardHashes with the description of the shard block
+// with the given root hash. Such a proof links a shardchain block to a signed masterchain block.
+func BuildBlockProofWithShard(blockBOC []byte, workchain int32, shardRootHash []byte) (*cell.Cell, error) {
+	blockCell, block, err := parseBlockBOC(blockBOC)
+	if err != nil {
+		return nil, err
+	}
+	if block.Extra == nil || block.Extra.Custom == nil {
+		return nil, fmt.Errorf("not a masterchain block")
+	}
+	if block.Extra.Custom.ShardHashes.IsEmpty() {
+		return nil, fmt.Errorf("masterchain block has no shard hashes")
+	}
+
+	rootSk, customSk, err := blockProofSk(block)
+	if err != nil {
+		return nil, err
+	}
+	if customSk == nil {
+		customSk = rootSk.ProofRef(3).ProofRef(3)
+	}
+
+	binTreeSlice, shardHashesSk, err := block.Extra.Custom.ShardHashes.LoadValueWithProof(
+		cell.BeginCell().MustStoreInt(int64(workchain), 32).EndCell(),
+		customSk.ProofRef(0),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("workchain %d is not in shard hashes: %w", workchain, err)
+	}
+	binTree, err := binTreeSlice.LoadRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load shards bin tree: %w", err)
+	}
+
+	found, err := proveShardDescr(binTree.MustToCell(), shardHashesSk.ProofRef(0), shardRootHash)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("shard block %x is not registered in the masterchain block", shardRootHash)
+	}
+
+	return blockCell.CreateProof(rootSk)
+}
+
+// BuildBlockProofWithState builds the same proof as BuildBlockProof, but additionally keeps
+// the state update of the block with the hash of the state after the block.
+func BuildBlockProofWithState(blockBOC []byte) (*cell.Cell, error) {
+	blockCell, block, err := parseBlockBOC(blockBOC)
+	if err != nil {
+		return nil, err
+	}
+
+	rootSk, _, err := blockProofSk(block)
+	if err != nil {
+		return nil, err
+	}
+	rootSk.ProofRef(2)
+
+	return blockCell.CreateProof(rootSk)
+}
+
+func parseBlockBOC(blockBOC []byte) (*cell.Cell, *tlb.Block, error) {
+	blockCell, err := cell.FromBOC(blockBOC)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var block tlb.Block
 	err = tlb.LoadFromCell(&block, blockCell.BeginParse())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	return blockCell, &block, nil
+}
+
+// blockProofSk returns the proof skeleton used by the LiteClient: the block info for
+// ordinary blocks, or config param 34 for key blocks. For key blocks the skeleton of
+// McBlockExtra is returned too, so more branches can be attached to it.
+func blockProofSk(block *tlb.Block) (rootSk *cell.ProofSkeleton, customSk *cell.ProofSkeleton, err error) {
+	if block.Extra == nil || block.Extra.Custom == nil || block.Extra.Custom.ConfigParams == nil {
+		return createBlockProofSk(), nil, nil
+	}
+
+	rootSk, customSk, configSk := createKeyBlockProofSk(configRefIndex(block.Extra.Custom))
+	if err = proveConfigParams(block.Extra.Custom.ConfigParams, configSk, 34); err != nil {
+		return nil, nil, err
+	}
+
+	return rootSk, customSk, nil
+}
 
+// BuildConfigProof builds a proof of a key block that keeps the given config params,
+// in the same form as the proof of config param 34 built by BuildBlockProof.
+func BuildConfigProof(blockBOC []byte, params []uint32) (*cell.Cell, error) {
+	blockCell, block, err := parseBlockBOC(blockBOC)
+	if err != nil {
+		return nil, err
+	}
 	if block.Extra == nil || block.Extra.Custom == nil || block.Extra.Custom.ConfigParams == nil {
-		rootSk := createBlockProofSk()
-		return blockCell.CreateProof(rootSk)
+		return nil, fmt.Errorf("block has no config params, it is not a key block")
+	}
+	if len(params) == 0 {
+		return nil, fmt.Errorf("no config params to prove")
+	}
+
+	rootSk, _, configSk := createKeyBlockProofSk(configRefIndex(block.Extra.Custom))
+	if err = proveConfigParams(block.Extra.Custom.ConfigParams, configSk, params...); err != nil {
+		return nil, err
 	}
 
+	return blockCell.CreateProof(rootSk)
+}
+
+// configRefIndex returns the index of the config params ref in McBlockExtra,
+// which depends on whether the shard hashes and shard fees dicts are empty.
+func configRefIndex(extra *tlb.McBlockExtra) int {
 	configRefIndex := 3
-	if block.Extra.Custom.ShardHashes.IsEmpty() {
+	if extra.ShardHashes.IsEmpty() {
 		configRefIndex -= 1
 	}
-	if block.Extra.Custom.ShardFees.IsEmpty() {
+	if extra.ShardFees.IsEmpty() {
 		configRefIndex -= 1
 	}
-	rootSk, configSk := createKeyBlockProofSk(configRefIndex)
-	_, config34Sk, err := block.Extra.Custom.ConfigParams.Config.Params.LoadValueWithProof(
-		cell.BeginCell().MustStoreUInt(34, 32).EndCell(),
-		configSk,
-	)
+	return configRefIndex
+}
+
+// proveConfigParams keeps the values of the config params in the skeleton of the config dict.
+func proveConfigParams(config *tlb.ConfigParams, configSk *cell.ProofSkeleton, params ...uint32) error {
+	for _, param := range params {
+		_, paramSk, err := config.Config.Params.LoadValueWithProof(
+			cell.BeginCell().MustStoreUInt(uint64(param), 32).EndCell(),
+			configSk,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to prove config param %d: %w", param, err)
+		}
+		paramSk.SetRecursive()
+	}
+	return nil
+}
+
+// proveShardDescr walks a BinTree of ShardDescr and keeps the path to the leaf
+// with the given root hash in the skeleton.
+func proveShardDescr(node *cell.Cell, sk *cell.ProofSkeleton, rootHash []byte) (bool, error) {
+	s := node.BeginParse()
+	isFork, err := s.LoadBoolBit()
 	if err != nil {
-		return nil, err
+		return false, fmt.Errorf("failed to load bin tree tag: %w", err)
 	}
-	config34Sk.SetRecursive()
 
-	return blockCell.CreateProof(rootSk)
+	if !isFork {
+		// shard_descr#a or shard_descr#b: seq_no:uint32 reg_mc_seqno:uint32
+		// start_lt:uint64 end_lt:uint64 root_hash:bits256 ...
+		if _, err = s.LoadSlice(4 + 32 + 32 + 64 + 64); err != nil {
+			return false, fmt.Errorf("failed to load shard descr: %w", err)
+		}
+		hash, err := s.LoadSlice(256)
+		if err != nil {
+			return false, fmt.Errorf("failed to load shard root hash: %w", err)
+		}
+		if !bytes.Equal(hash, rootHash) {
+			return false, nil
+		}
+		sk.SetRecursive()
+		return true, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		child, err := node.PeekRef(i)
+		if err != nil {
+			return false, fmt.Errorf("failed to load bin tree fork: %w", err)
+		}
+		childSk := cell.CreateProofSkeleton()
+		found, err := proveShardDescr(child, childSk, rootHash)
+		if err != nil {
+			return false, err
+		}
+		if found {
+			sk.AttachAt(i, childSk)
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
-func createKeyBlockProofSk(configIdx int) (rootSk *cell.ProofSkeleton, configSk *cell.ProofSkeleton) {
+func createKeyBlockProofSk(configIdx int) (rootSk, customSk, configSk *cell.ProofSkeleton) {
 	rootSk = cell.CreateProofSkeleton()
 	extraSk := rootSk.ProofRef(3)
-	customSk := extraSk.ProofRef(3)
+	customSk = extraSk.ProofRef(3)
 	configSk = customSk.ProofRef(configIdx)
-	return rootSk, configSk
+	return rootSk, customSk, configSk
 }
 
 func createBlockProofSk() (rootSk *cell.ProofSkeleton) {
@@ -0,0 +1,140 @@
+package blockutils_test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blocktest"
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestBuildBlockProofWithShard(t *testing.T) {
+	left := bytes.Repeat([]byte{0x0A}, 32)
+	right := bytes.Repeat([]byte{0x0B}, 32)
+	mcBlock := &blocktest.Block{
+		Workchain: -1,
+		Seqno:     100,
+		ShardHashes: map[int32]*cell.Cell{
+			0: blocktest.ShardFork(blocktest.ShardDescr(10, left), blocktest.ShardDescr(11, right)),
+		},
+	}
+	rootHash := mcBlock.Cell().Hash()
+
+	proof, err := blockutils.BuildBlockProofWithShard(mcBlock.BOC(), 0, right)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	block, err := ton.CheckBlockProof(proof, rootHash)
+	if err != nil {
+		t.Fatalf("invalid proof: %v", err)
+	}
+	if block.BlockInfo.SeqNo != 100 {
+		t.Fatalf("unexpected block info: %+v", block.BlockInfo)
+	}
+
+	// the description of the other shard is pruned
+	shards, err := ton.LoadShardsFromHashes(block.Extra.Custom.ShardHashes, true)
+	if err != nil {
+		t.Fatalf("failed to load shards from the proof: %v", err)
+	}
+	if len(shards) != 1 || shards[0].Workchain != 0 || shards[0].SeqNo != 11 || !bytes.Equal(shards[0].RootHash, right) {
+		t.Fatalf("unexpected shards in the proof: %+v", shards)
+	}
+}
+
+func TestBuildBlockProofWithShardNotRegistered(t *testing.T) {
+	mcBlock := &blocktest.Block{
+		Workchain:   -1,
+		Seqno:       100,
+		ShardHashes: map[int32]*cell.Cell{0: blocktest.ShardDescr(10, bytes.Repeat([]byte{0x0A}, 32))},
+	}
+
+	if _, err := blockutils.BuildBlockProofWithShard(mcBlock.BOC(), 0, bytes.Repeat([]byte{0x0B}, 32)); err == nil {
+		t.Fatal("expected an error for a shard block that is not registered")
+	}
+	if _, err := blockutils.BuildBlockProofWithShard(mcBlock.BOC(), 1, bytes.Repeat([]byte{0x0A}, 32)); err == nil {
+		t.Fatal("expected an error for a workchain that is not in shard hashes")
+	}
+}
+
+func TestBuildConfigProof(t *testing.T) {
+	set := blocktest.ValidatorSet(1000, blocktest.NewValidators(40, 30, 20, 10))
+	catchainConfig := blocktest.CatchainConfig()
+	for _, shardHashes := range []map[int32]*cell.Cell{
+		nil,
+		{0: blocktest.ShardDescr(10, bytes.Repeat([]byte{0x0A}, 32))},
+	} {
+		keyBlock := prepareKeyBlock(100, set)
+		keyBlock.Config[28] = catchainConfig
+		keyBlock.ShardHashes = shardHashes
+
+		proof, err := blockutils.BuildConfigProof(keyBlock.BOC(), []uint32{28})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		block, err := ton.CheckBlockProof(proof, keyBlock.Cell().Hash())
+		if err != nil {
+			t.Fatalf("invalid proof: %v", err)
+		}
+
+		params := block.Extra.Custom.ConfigParams.Config.Params
+		value, err := params.LoadValueByIntKey(big.NewInt(28))
+		if err != nil {
+			t.Fatalf("config param 28 is not in the proof: %v", err)
+		}
+		if ref, err := value.LoadRefCell(); err != nil || !bytes.Equal(ref.Hash(), catchainConfig.Hash()) {
+			t.Fatalf("unexpected config param 28: %v", err)
+		}
+		// the other params are pruned
+		if value, err = params.LoadValueByIntKey(big.NewInt(34)); err == nil {
+			if _, err = value.LoadRefCell(); err == nil {
+				t.Fatal("config param 34 is not pruned")
+			}
+		}
+	}
+}
+
+func TestBuildConfigProofNotKeyBlock(t *testing.T) {
+	block := &blocktest.Block{Workchain: -1, Seqno: 100}
+	if _, err := blockutils.BuildConfigProof(block.BOC(), []uint32{34}); err == nil {
+		t.Fatal("expected an error for a block that is not a key block")
+	}
+	keyBlock := prepareKeyBlock(100, blocktest.ValidatorSet(1000, blocktest.NewValidators(100)))
+	if _, err := blockutils.BuildConfigProof(keyBlock.BOC(), []uint32{28}); err == nil {
+		t.Fatal("expected an error for a param that is not in the config")
+	}
+}
+
+func TestBuildBlockProofWithState(t *testing.T) {
+	accounts := cell.NewDict(256)
+	state := blocktest.ShardState(-1, 100, accounts)
+	block := &blocktest.Block{Workchain: -1, Seqno: 100, NewState: state}
+	rootHash := block.Cell().Hash()
+
+	proof, err := blockutils.BuildBlockProofWithState(block.BOC())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = ton.CheckBlockProof(proof, rootHash); err != nil {
+		t.Fatalf("invalid proof: %v", err)
+	}
+
+	// the state update of the proof binds a proof of the state after the block
+	stateSk := cell.CreateProofSkeleton()
+	stateSk.ProofRef(1).SetRecursive()
+	stateProof, err := state.CreateProof(stateSk)
+	if err != nil {
+		t.Fatalf("failed to build state proof: %v", err)
+	}
+	shardState, err := ton.CheckBlockShardStateProof([]*cell.Cell{proof, stateProof}, rootHash)
+	if err != nil {
+		t.Fatalf("invalid state proof: %v", err)
+	}
+	if shardState.Seqno != 100 {
+		t.Fatalf("unexpected shard state: %+v", shardState)
+	}
+
+}
@@ -0,0 +1,272 @@
+package blockutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// maxProofParts limits the number of liteserver responses followed in a proof chain.
+const maxProofParts = 64
+
+// maxCommitLag is the number of masterchain blocks after the master ref of a shard block
+// that are searched for the block that commits it.
+const maxCommitLag = 16
+
+var (
+	ErrZerostate         = errors.New("zerostate is not signed by validators")
+	ErrNotCommitted      = errors.New("shard block is not committed to the masterchain")
+	ErrNotRegistered     = errors.New("shard block is not registered in a masterchain block")
+	ErrInvalidSignatures = errors.New("invalid block signatures")
+)
+
+// BlockSignatures are the signatures of a masterchain block together with the validator subset
+// of its catchain, which is needed to verify them.
+type BlockSignatures struct {
+	Block            *ton.BlockIDExt
+	CatchainSeqno    uint32
+	ValidatorSetHash uint32
+	Validators       []*tlb.ValidatorAddr
+	TotalWeight      uint64
+	Signatures       []ton.Signature
+}
+
+// FetchBlockSignatures follows the proof chain from the known masterchain block to the target one
+// and returns the verified signatures of the forward link that ends at the target.
+// If known is nil, the previous key block of the target is used.
+func FetchBlockSignatures(
+	ctx context.Context,
+	tonClient *tonclient.TonClient,
+	known, target *ton.BlockIDExt,
+) (*BlockSignatures, error) {
+	if target.Workchain != -1 {
+		return nil, fmt.Errorf("block %d of workchain %d is not a masterchain block", target.SeqNo, target.Workchain)
+	}
+	if target.SeqNo == 0 {
+		return nil, ErrZerostate
+	}
+	if known == nil {
+		block, err := tonClient.GetBlockData(ctx, target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block %d: %w", target.SeqNo, err)
+		}
+		known, err = tonClient.LookupBlock(ctx, -1, 0, block.BlockInfo.PrevKeyBlockSeqno)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lookup key block %d: %w", block.BlockInfo.PrevKeyBlockSeqno, err)
+		}
+	}
+	if known.SeqNo >= target.SeqNo {
+		return nil, fmt.Errorf("known block %d must precede the target block %d", known.SeqNo, target.SeqNo)
+	}
+
+	from := known
+	for i := 0; i < maxProofParts; i++ {
+		proof, err := tonClient.GetBlockProofExt(ctx, from, target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block proof from %d to %d: %w", from.SeqNo, target.SeqNo, err)
+		}
+		for _, step := range proof.Steps {
+			if link, ok := step.(ton.BlockLinkForward); ok && link.To.Equals(target) {
+				return linkSignatures(ctx, tonClient, &link)
+			}
+		}
+		if proof.To.Equals(target) || proof.To.Equals(from) {
+			break
+		}
+		from = proof.To
+	}
+	return nil, fmt.Errorf("proof chain from %d to %d has no forward link signing the target", known.SeqNo, target.SeqNo)
+}
+
+// linkSignatures verifies the signatures of the forward link against the validator subset
+// of the catchain that generated the target block.
+func linkSignatures(
+	ctx context.Context,
+	tonClient *tonclient.TonClient,
+	link *ton.BlockLinkForward,
+) (*BlockSignatures, error) {
+	destProof, err := cell.FromBOC(link.DestProof)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse target block proof: %w", err)
+	}
+	toBlock, err := ton.CheckBlockProof(destProof, link.To.RootHash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignatures, err)
+	}
+	set := link.SignatureSet
+	if toBlock.BlockInfo.GenCatchainSeqno != uint32(set.CatchainSeqno) ||
+		toBlock.BlockInfo.GenValidatorListHashShort != uint32(set.ValidatorSetHash) {
+		return nil, fmt.Errorf("%w: signature set does not match the catchain of block %d", ErrInvalidSignatures, link.To.SeqNo)
+	}
+
+	catchainConfig, validatorSet, err := linkConfig(ctx, tonClient, link)
+	if err != nil {
+		return nil, err
+	}
+	validators, err := ton.GetMainValidators(link.To, catchainConfig, validatorSet, uint32(set.CatchainSeqno))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get validators of catchain %d: %w", set.CatchainSeqno, err)
+	}
+	signatures := append([]ton.Signature(nil), set.Signatures...)
+	if err = ton.CheckBlockSignatures(link.To, set, validators); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignatures, err)
+	}
+
+	var totalWeight uint64
+	for _, v := range validators {
+		totalWeight += v.Weight
+	}
+	return &BlockSignatures{
+		Block:            link.To,
+		CatchainSeqno:    uint32(set.CatchainSeqno),
+		ValidatorSetHash: uint32(set.ValidatorSetHash),
+		Validators:       validators,
+		TotalWeight:      totalWeight,
+		Signatures:       signatures,
+	}, nil
+}
+
+// linkConfig returns the catchain config and the validator set of the source block of the link.
+// They are taken from the config proof of the link, except for the zerostate,
+// whose config is in the state and not in a block.
+func linkConfig(
+	ctx context.Context,
+	tonClient *tonclient.TonClient,
+	link *ton.BlockLinkForward,
+) (tlb.CatchainConfig, tlb.ValidatorSetAny, error) {
+	var catchainConfig tlb.CatchainConfig
+	var validatorSet tlb.ValidatorSetAny
+	var catchainCell, validatorsCell *cell.Cell
+
+	if link.From.SeqNo == 0 {
+		config, err := tonClient.API.GetBlockchainConfig(ctx, link.From, 28, 34)
+		if err != nil {
+			return catchainConfig, validatorSet, fmt.Errorf("failed to get zerostate config: %w", err)
+		}
+		catchainCell, validatorsCell = config.Get(28), config.Get(34)
+	} else {
+		configProof, err := cell.FromBOC(link.ConfigProof)
+		if err != nil {
+			return catchainConfig, validatorSet, fmt.Errorf("failed to parse config proof: %w", err)
+		}
+		fromBlock, err := ton.CheckBlockProof(configProof, link.From.RootHash)
+		if err != nil {
+			return catchainConfig, validatorSet, fmt.Errorf("%w: %v", ErrInvalidSignatures, err)
+		}
+		if fromBlock.Extra == nil || fromBlock.Extra.Custom == nil || fromBlock.Extra.Custom.ConfigParams.Config.Params == nil {
+			return catchainConfig, validatorSet, fmt.Errorf("config proof of block %d has no config", link.From.SeqNo)
+		}
+		params := fromBlock.Extra.Custom.ConfigParams.Config.Params
+		catchainSlice, err := params.LoadValueByIntKey(big.NewInt(28))
+		if err == nil {
+			catchainCell, err = catchainSlice.LoadRefCell()
+		}
+		if err != nil {
+			return catchainConfig, validatorSet, fmt.Errorf("config proof of block %d has no param 28: %w", link.From.SeqNo, err)
+		}
+		validatorsSlice, err := params.LoadValueByIntKey(big.NewInt(34))
+		if err == nil {
+			validatorsCell, err = validatorsSlice.LoadRefCell()
+		}
+		if err != nil {
+			return catchainConfig, validatorSet, fmt.Errorf("config proof of block %d has no param 34: %w", link.From.SeqNo, err)
+		}
+	}
+	if catchainCell == nil || validatorsCell == nil {
+		return catchainConfig, validatorSet, fmt.Errorf("config of block %d lacks params 28 and 34", link.From.SeqNo)
+	}
+
+	if err := tlb.LoadFromCell(&catchainConfig, catchainCell.BeginParse()); err != nil {
+		return catchainConfig, validatorSet, fmt.Errorf("failed to parse catchain config: %w", err)
+	}
+	if err := tlb.LoadFromCell(&validatorSet, validatorsCell.BeginParse()); err != nil {
+		return catchainConfig, validatorSet, fmt.Errorf("failed to parse validator set: %w", err)
+	}
+	return catchainConfig, validatorSet, nil
+}
+
+// FindCommittingMasterBlock returns the first masterchain block whose shard hashes include the shard block
+// or one of its descendants. Validators of shardchains do not publish their signatures through liteservers,
+// so a shard block is proven by the signatures of this masterchain block.
+func FindCommittingMasterBlock(
+	ctx context.Context,
+	tonClient *tonclient.TonClient,
+	shardBlock *ton.BlockIDExt,
+) (*ton.BlockIDExt, error) {
+	master, _, err := findCommittingMasterBlock(ctx, tonClient, shardBlock)
+	return master, err
+}
+
+// FindRegisteringMasterBlock returns the committing masterchain block like FindCommittingMasterBlock,
+// but only if its shard hashes include the shard block itself, as proofs of the shard block
+// through ShardHashes need. ErrNotRegistered is returned if a descendant is registered instead.
+func FindRegisteringMasterBlock(
+	ctx context.Context,
+	tonClient *tonclient.TonClient,
+	shardBlock *ton.BlockIDExt,
+) (*ton.BlockIDExt, error) {
+	master, registered, err := findCommittingMasterBlock(ctx, tonClient, shardBlock)
+	if err != nil {
+		return nil, err
+	}
+	if !registered.Equals(shardBlock) {
+		return nil, fmt.Errorf("%w: masterchain block %d registers block %d of shard %x instead of %d",
+			ErrNotRegistered, master.SeqNo, registered.SeqNo, uint64(registered.Shard), shardBlock.SeqNo)
+	}
+	return master, nil
+}
+
+// findCommittingMasterBlock returns the committing masterchain block together with
+// the block of its shard hashes that is the shard block or its descendant.
+func findCommittingMasterBlock(
+	ctx context.Context,
+	tonClient *tonclient.TonClient,
+	shardBlock *ton.BlockIDExt,
+) (master, registered *ton.BlockIDExt, err error) {
+	if shardBlock.Workchain == -1 {
+		return shardBlock, shardBlock, nil
+	}
+	block, err := tonClient.GetBlockData(ctx, shardBlock)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get shard block %d: %w", shardBlock.SeqNo, err)
+	}
+	if block.BlockInfo.MasterRef == nil {
+		return nil, nil, fmt.Errorf("shard block %d has no master ref", shardBlock.SeqNo)
+	}
+
+	for seqno := block.BlockInfo.MasterRef.SeqNo + 1; seqno <= block.BlockInfo.MasterRef.SeqNo+maxCommitLag; seqno++ {
+		master, err = tonClient.LookupBlock(ctx, -1, 0, seqno)
+		if err != nil {
+			if errors.Is(err, ton.ErrBlockNotFound) {
+				break
+			}
+			return nil, nil, fmt.Errorf("failed to lookup masterchain block %d: %w", seqno, err)
+		}
+		shards, err := tonClient.API.GetBlockShardsInfo(ctx, master)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get shards info of %d: %w", seqno, err)
+		}
+		for _, shard := range shards {
+			if shard.Workchain == shardBlock.Workchain &&
+				ShardsIntersect(shard.Shard, shardBlock.Shard) &&
+				shard.SeqNo >= shardBlock.SeqNo {
+				return master, shard, nil
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: block %d of shard %x", ErrNotCommitted, shardBlock.SeqNo, uint64(shardBlock.Shard))
+}
+
+// ShardsIntersect reports whether one shard is the same as or an ancestor of the other.
+func ShardsIntersect(a, b int64) bool {
+	x, y := uint64(a), uint64(b)
+	bit := max(x&-x, y&-y)
+	mask := ^(bit<<1 - 1)
+	return (x^y)&mask == 0
+}
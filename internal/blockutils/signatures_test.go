@@ -0,0 +1,265 @@
+package blockutils_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blocktest"
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// prepareMasterBlock adds a masterchain block with the shard hashes, which only
+// LookupBlock and GetAllShardsInfo return.
+func prepareMasterBlock(fixtures *tonclient.Fixtures, seqno uint32, shardHashes map[int32]*cell.Cell) *ton.BlockIDExt {
+	id := (&blocktest.Block{Workchain: -1, Seqno: seqno, ShardHashes: shardHashes}).ID()
+	err := fixtures.Add(
+		ton.LookupBlock{Mode: 1, ID: &ton.BlockInfoShort{Workchain: -1, Shard: 0, Seqno: int32(seqno)}},
+		ton.BlockHeader{ID: id, HeaderProof: []byte{}},
+	)
+	if err != nil {
+		panic(err)
+	}
+	err = fixtures.Add(ton.GetAllShardsInfo{ID: id}, ton.AllShardsInfo{
+		ID:    id,
+		Proof: []*cell.Cell{cell.BeginCell().EndCell()},
+		Data:  cell.BeginCell().MustStoreDict(blocktest.ShardHashes(shardHashes)).EndCell(),
+	})
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// prepareMasterBlockNotFound makes the masterchain block with the seqno not generated yet.
+func prepareMasterBlockNotFound(fixtures *tonclient.Fixtures, seqno uint32) {
+	err := fixtures.Add(
+		ton.LookupBlock{Mode: 1, ID: &ton.BlockInfoShort{Workchain: -1, Shard: 0, Seqno: int32(seqno)}},
+		ton.LSError{Code: 651, Text: "block not found"},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// prepareShardBlock adds shard block 10 that refers to masterchain block 100.
+func prepareShardBlock(fixtures *tonclient.Fixtures) *blocktest.Block {
+	block := &blocktest.Block{Workchain: 0, Seqno: 10, MasterRef: 100}
+	if err := fixtures.Add(ton.GetBlockData{ID: block.ID()}, ton.BlockData{ID: block.ID(), Payload: block.BOC()}); err != nil {
+		panic(err)
+	}
+	return block
+}
+
+func TestFindCommittingMasterBlock(t *testing.T) {
+	fixtures := &tonclient.Fixtures{}
+	shardBlock := prepareShardBlock(fixtures)
+	prepareMasterBlock(fixtures, 101, map[int32]*cell.Cell{0: blocktest.ShardDescr(9, bytes.Repeat([]byte{0x09}, 32))})
+	master := prepareMasterBlock(fixtures, 102, map[int32]*cell.Cell{0: blocktest.ShardDescr(10, shardBlock.Cell().Hash())})
+	client := tonclient.NewTonClientFixtures(fixtures)
+
+	for name, find := range map[string]func(context.Context, *tonclient.TonClient, *ton.BlockIDExt) (*ton.BlockIDExt, error){
+		"committing":  blockutils.FindCommittingMasterBlock,
+		"registering": blockutils.FindRegisteringMasterBlock,
+	} {
+		found, err := find(context.Background(), client, shardBlock.ID())
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if !found.Equals(master) {
+			t.Fatalf("%s: unexpected masterchain block %d", name, found.SeqNo)
+		}
+
+		mcBlock := &blocktest.Block{Workchain: -1, Seqno: 200}
+		if found, err = find(context.Background(), client, mcBlock.ID()); err != nil || !found.Equals(mcBlock.ID()) {
+			t.Fatalf("%s: a masterchain block must commit itself, got %v, %v", name, found, err)
+		}
+	}
+}
+
+func TestFindCommittingMasterBlockDescendant(t *testing.T) {
+	fixtures := &tonclient.Fixtures{}
+	shardBlock := prepareShardBlock(fixtures)
+	master := prepareMasterBlock(fixtures, 101, map[int32]*cell.Cell{0: blocktest.ShardDescr(11, bytes.Repeat([]byte{0x0B}, 32))})
+	client := tonclient.NewTonClientFixtures(fixtures)
+
+	// the signatures of the masterchain block prove the shard block through prev refs of block 11
+	found, err := blockutils.FindCommittingMasterBlock(context.Background(), client, shardBlock.ID())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !found.Equals(master) {
+		t.Fatalf("unexpected masterchain block %d", found.SeqNo)
+	}
+	// but its shard hashes do not prove the shard block
+	if _, err = blockutils.FindRegisteringMasterBlock(context.Background(), client, shardBlock.ID()); !errors.Is(err, blockutils.ErrNotRegistered) {
+		t.Fatalf("expected ErrNotRegistered, got %v", err)
+	}
+}
+
+func TestFindCommittingMasterBlockNotCommitted(t *testing.T) {
+	fixtures := &tonclient.Fixtures{}
+	shardBlock := prepareShardBlock(fixtures)
+	prepareMasterBlock(fixtures, 101, map[int32]*cell.Cell{0: blocktest.ShardDescr(9, bytes.Repeat([]byte{0x09}, 32))})
+	prepareMasterBlockNotFound(fixtures, 102)
+	client := tonclient.NewTonClientFixtures(fixtures)
+
+	if _, err := blockutils.FindCommittingMasterBlock(context.Background(), client, shardBlock.ID()); !errors.Is(err, blockutils.ErrNotCommitted) {
+		t.Fatalf("expected ErrNotCommitted, got %v", err)
+	}
+	if _, err := blockutils.FindRegisteringMasterBlock(context.Background(), client, shardBlock.ID()); !errors.Is(err, blockutils.ErrNotCommitted) {
+		t.Fatalf("expected ErrNotCommitted, got %v", err)
+	}
+}
+
+// prepareSigningKeyBlock returns a key block with the validators in its config,
+// generated by the same catchain as the blocks after it.
+func prepareSigningKeyBlock(seqno uint32, validators []blocktest.Validator) *blocktest.Block {
+	return &blocktest.Block{
+		Workchain:        -1,
+		Seqno:            seqno,
+		CatchainSeqno:    7,
+		ValidatorSetHash: blocktest.ValidatorSetHash(7, validators),
+		Config: map[uint32]*cell.Cell{
+			28: blocktest.CatchainConfig(),
+			34: blocktest.ValidatorSet(1000, validators),
+		},
+	}
+}
+
+// prepareBlockProof adds the proof from the known block to the target one
+// that the liteserver returns, which ends at the to block.
+func prepareBlockProof(fixtures *tonclient.Fixtures, known, target, to *blocktest.Block, steps ...any) {
+	err := fixtures.Add(
+		ton.GetBlockProof{Mode: 0x1001, KnownBlock: known.ID(), TargetBlock: target.ID()},
+		ton.PartialBlockProof{Complete: to == target, From: known.ID(), To: to.ID(), Steps: steps},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestFetchBlockSignatures(t *testing.T) {
+	validators := blocktest.NewValidators(40, 30, 20, 10)
+	keyBlock := prepareSigningKeyBlock(100, validators)
+	block := &blocktest.Block{
+		Workchain:        -1,
+		Seqno:            110,
+		CatchainSeqno:    7,
+		ValidatorSetHash: blocktest.ValidatorSetHash(7, validators),
+		PrevKeyBlock:     100,
+	}
+	fixtures := &tonclient.Fixtures{}
+	prepareBlockProof(fixtures, keyBlock, block, block, blocktest.ForwardLink(keyBlock, block, validators))
+	// without a known block, the previous key block of the target is looked up
+	if err := fixtures.Add(ton.GetBlockData{ID: block.ID()}, ton.BlockData{ID: block.ID(), Payload: block.BOC()}); err != nil {
+		t.Fatal(err)
+	}
+	err := fixtures.Add(
+		ton.LookupBlock{Mode: 1, ID: &ton.BlockInfoShort{Workchain: -1, Shard: 0, Seqno: 100}},
+		ton.BlockHeader{ID: keyBlock.ID(), HeaderProof: []byte{}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := tonclient.NewTonClientFixtures(fixtures)
+
+	for _, known := range []*ton.BlockIDExt{keyBlock.ID(), nil} {
+		s, err := blockutils.FetchBlockSignatures(context.Background(), client, known, block.ID())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !s.Block.Equals(block.ID()) || s.CatchainSeqno != 7 || s.ValidatorSetHash != block.ValidatorSetHash {
+			t.Fatalf("unexpected signatures of block %d, catchain %d", s.Block.SeqNo, s.CatchainSeqno)
+		}
+		if len(s.Signatures) != 4 || len(s.Validators) != 4 || s.TotalWeight != 100 {
+			t.Fatalf("unexpected %d signatures of %d validators with weight %d", len(s.Signatures), len(s.Validators), s.TotalWeight)
+		}
+	}
+}
+
+func TestFetchBlockSignaturesProofChain(t *testing.T) {
+	validators := blocktest.NewValidators(40, 30, 20, 10)
+	keyBlock := prepareSigningKeyBlock(100, validators)
+	nextKeyBlock := prepareSigningKeyBlock(105, validators)
+	nextKeyBlock.PrevKeyBlock = 100
+	block := &blocktest.Block{
+		Workchain:        -1,
+		Seqno:            110,
+		CatchainSeqno:    7,
+		ValidatorSetHash: blocktest.ValidatorSetHash(7, validators),
+		PrevKeyBlock:     105,
+	}
+	fixtures := &tonclient.Fixtures{}
+	// the first response ends at the next key block, the link from it signs the target
+	prepareBlockProof(fixtures, keyBlock, block, nextKeyBlock, blocktest.ForwardLink(keyBlock, nextKeyBlock, validators))
+	prepareBlockProof(fixtures, nextKeyBlock, block, block, blocktest.ForwardLink(nextKeyBlock, block, validators))
+
+	s, err := blockutils.FetchBlockSignatures(context.Background(), tonclient.NewTonClientFixtures(fixtures), keyBlock.ID(), block.ID())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !s.Block.Equals(block.ID()) || len(s.Signatures) != 4 {
+		t.Fatalf("unexpected %d signatures of block %d", len(s.Signatures), s.Block.SeqNo)
+	}
+}
+
+func TestFetchBlockSignaturesInvalid(t *testing.T) {
+	validators := blocktest.NewValidators(40, 30, 20, 10)
+	keyBlock := prepareSigningKeyBlock(100, validators)
+	block := &blocktest.Block{
+		Workchain:        -1,
+		Seqno:            110,
+		CatchainSeqno:    7,
+		ValidatorSetHash: blocktest.ValidatorSetHash(7, validators),
+		PrevKeyBlock:     100,
+	}
+	link := blocktest.ForwardLink(keyBlock, block, validators)
+	link.SignatureSet.Signatures = blocktest.Sign(block.ID(), blocktest.NewValidators(40, 30, 20, 10))
+	fixtures := &tonclient.Fixtures{}
+	prepareBlockProof(fixtures, keyBlock, block, block, link)
+	client := tonclient.NewTonClientFixtures(fixtures)
+
+	_, err := blockutils.FetchBlockSignatures(context.Background(), client, keyBlock.ID(), block.ID())
+	if !errors.Is(err, blockutils.ErrInvalidSignatures) {
+		t.Fatalf("expected ErrInvalidSignatures, got %v", err)
+	}
+	zerostate := (&blocktest.Block{Workchain: -1, Seqno: 0}).ID()
+	if _, err = blockutils.FetchBlockSignatures(context.Background(), client, nil, zerostate); !errors.Is(err, blockutils.ErrZerostate) {
+		t.Fatalf("expected ErrZerostate, got %v", err)
+	}
+}
+
+func TestShardsIntersect(t *testing.T) {
+	const (
+		whole = int64(-1 << 63)            // 8000...
+		left  = int64(0x4000000000000000)  // 4000...
+		right = int64(-0x4000000000000000) // c000...
+		leftR = int64(0x6000000000000000)  // 6000..., the right child of left
+		deep  = int64(0x7800000000000000)  // 7800..., a descendant of leftR
+	)
+	for _, c := range []struct {
+		a, b int64
+		want bool
+	}{
+		{whole, whole, true},
+		{whole, left, true},
+		{right, whole, true},
+		{left, left, true},
+		{left, right, false},
+		{left, leftR, true},
+		{leftR, right, false},
+		{deep, leftR, true},
+		{deep, left, true},
+		{deep, right, false},
+		{deep, int64(0x2000000000000000), false},
+	} {
+		if got := blockutils.ShardsIntersect(c.a, c.b); got != c.want {
+			t.Fatalf("ShardsIntersect(%x, %x) = %v, want %v", uint64(c.a), uint64(c.b), got, c.want)
+		}
+	}
+}
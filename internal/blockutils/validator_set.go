@@ -0,0 +1,121 @@
+package blockutils
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// ValidatorSetParams are the config params with the previous, the current and the next validator set.
+var ValidatorSetParams = []int{32, 34, 36}
+
+// ValidatorEntry is a validator of a set in the order of the set.
+type ValidatorEntry struct {
+	Index     uint16
+	PublicKey []byte
+	// ADNLAddr is nil for entries without an ADNL address.
+	ADNLAddr []byte
+	Weight   uint64
+}
+
+// ValidatorSetInfo is a decoded validator set config param.
+type ValidatorSetInfo struct {
+	Param       int
+	UTimeSince  uint32
+	UTimeUntil  uint32
+	Total       uint16
+	Main        uint16
+	TotalWeight uint64
+	MainWeight  uint64
+	Validators  []ValidatorEntry
+	// EpochHash is the hash of the set, see EpochHash.
+	EpochHash []byte
+}
+
+// MainValidators returns the validators that sign masterchain blocks.
+func (s *ValidatorSetInfo) MainValidators() []ValidatorEntry {
+	return s.Validators[:min(int(s.Main), len(s.Validators))]
+}
+
+// DecodeValidatorSet decodes the validator set in the config param of the key block.
+// It returns nil if the block has no such param.
+func DecodeValidatorSet(block *tlb.Block, param int) (*ValidatorSetInfo, error) {
+	if block.Extra == nil || block.Extra.Custom == nil || block.Extra.Custom.ConfigParams == nil {
+		return nil, fmt.Errorf("block %d has no config", block.BlockInfo.SeqNo)
+	}
+	value := block.Extra.Custom.ConfigParams.Config.Params.GetByIntKey(big.NewInt(int64(param)))
+	if value == nil {
+		return nil, nil
+	}
+	setCell, err := value.PeekRef(0)
+	if err != nil {
+		return nil, fmt.Errorf("config param %d has no value: %w", param, err)
+	}
+
+	var set tlb.ValidatorSetAny
+	if err = tlb.LoadFromCell(&set, setCell.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse config param %d: %w", param, err)
+	}
+
+	info := &ValidatorSetInfo{Param: param, EpochHash: EpochHash(setCell)}
+	var list *cell.Dictionary
+	var definedWeight uint64
+	switch t := set.Validators.(type) {
+	case tlb.ValidatorSet:
+		info.UTimeSince, info.UTimeUntil, info.Total, info.Main, list = t.UTimeSince, t.UTimeUntil, t.Total, t.Main, t.List
+	case tlb.ValidatorSetExt:
+		info.UTimeSince, info.UTimeUntil, info.Total, info.Main, list = t.UTimeSince, t.UTimeUntil, t.Total, t.Main, t.List
+		definedWeight = t.TotalWeight
+	default:
+		return nil, fmt.Errorf("unknown validator set type in config param %d", param)
+	}
+
+	kvs, err := list.LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load validators list dict: %w", err)
+	}
+	for i, kv := range kvs {
+		index, err := kv.Key.LoadUInt(16)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse validator key: %w", err)
+		}
+		entry, err := decodeValidator(kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		entry.Index = uint16(index)
+		info.Validators = append(info.Validators, entry)
+
+		info.TotalWeight += entry.Weight
+		if i < int(info.Main) {
+			info.MainWeight += entry.Weight
+		}
+	}
+	if definedWeight != 0 {
+		info.TotalWeight = definedWeight
+	}
+	return info, nil
+}
+
+// decodeValidator parses a validator descriptor with or without an ADNL address.
+func decodeValidator(value *cell.Slice) (ValidatorEntry, error) {
+	tag, err := value.Copy().LoadUInt(8)
+	if err != nil {
+		return ValidatorEntry{}, fmt.Errorf("failed to parse validator descr: %w", err)
+	}
+	if tag == 0x53 {
+		var v tlb.Validator
+		if err = tlb.LoadFromCell(&v, value); err != nil {
+			return ValidatorEntry{}, fmt.Errorf("failed to parse validator descr: %w", err)
+		}
+		return ValidatorEntry{PublicKey: v.PublicKey.Key, Weight: v.Weight}, nil
+	}
+
+	var v tlb.ValidatorAddr
+	if err = tlb.LoadFromCell(&v, value); err != nil {
+		return ValidatorEntry{}, fmt.Errorf("failed to parse validator addr: %w", err)
+	}
+	return ValidatorEntry{PublicKey: v.PublicKey.Key, ADNLAddr: v.ADNLAddr, Weight: v.Weight}, nil
+}
@@ -0,0 +1,64 @@
+package blockutils_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blocktest"
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestDecodeValidatorSet(t *testing.T) {
+	validators := blocktest.NewValidators(40, 30, 20, 10)
+	setCell := blocktest.ValidatorSet(1000, validators)
+	block := prepareKeyBlock(100, setCell).Parse()
+
+	set, err := blockutils.DecodeValidatorSet(block, 34)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if set.Param != 34 || set.UTimeSince != 1000 || set.UTimeUntil != 1000+65536 {
+		t.Fatalf("unexpected validator set: %+v", set)
+	}
+	if set.Total != 4 || set.Main != 4 || set.TotalWeight != 100 || set.MainWeight != 100 {
+		t.Fatalf("unexpected counts: %d/%d, weights %d/%d", set.Main, set.Total, set.MainWeight, set.TotalWeight)
+	}
+	if len(set.MainValidators()) != 4 {
+		t.Fatalf("unexpected main validators: %d", len(set.MainValidators()))
+	}
+	for i, v := range set.Validators {
+		if v.Index != uint16(i) || !bytes.Equal(v.PublicKey, validators[i].PublicKey) || v.Weight != validators[i].Weight {
+			t.Fatalf("unexpected validator %d: %+v", i, v)
+		}
+		if !bytes.Equal(v.ADNLAddr, make([]byte, 32)) {
+			t.Fatalf("unexpected ADNL address of validator %d: %x", i, v.ADNLAddr)
+		}
+	}
+
+	_, _, epochHash, err := blockutils.ExtractMainValidators(block, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(set.EpochHash, epochHash) || !bytes.Equal(set.EpochHash, blockutils.EpochHash(setCell)) {
+		t.Fatalf("epoch hash %x differs from the one of ExtractMainValidators %x", set.EpochHash, epochHash)
+	}
+}
+
+func TestDecodeValidatorSetMissing(t *testing.T) {
+	block := prepareKeyBlock(100, blocktest.ValidatorSet(1000, blocktest.NewValidators(50, 50))).Parse()
+	set, err := blockutils.DecodeValidatorSet(block, 36)
+	if err != nil || set != nil {
+		t.Fatalf("expected no validator set, got %+v: %v", set, err)
+	}
+
+	notKeyBlock := (&blocktest.Block{Workchain: -1, Seqno: 101, PrevKeyBlock: 100}).Parse()
+	if _, err = blockutils.DecodeValidatorSet(notKeyBlock, 34); err == nil {
+		t.Fatal("expected an error for a block without config")
+	}
+
+	invalid := &blocktest.Block{Workchain: -1, Seqno: 102, Config: map[uint32]*cell.Cell{34: cell.BeginCell().MustStoreUInt(0x13, 8).EndCell()}}
+	if _, err = blockutils.DecodeValidatorSet(invalid.Parse(), 34); err == nil {
+		t.Fatal("expected an error for an invalid validator set")
+	}
+}
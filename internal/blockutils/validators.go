@@ -1,22 +1,65 @@
 package blockutils
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"math/big"
 
+	"github.com/rsquad/trustless-bridge-cli/internal/blockcache"
 	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
 	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
+// FetchMainValidators returns the main validators of the key block as ExtractMainValidators does.
+// The validator set is kept in the cache, so the key block is fetched once per epoch.
+func FetchMainValidators(
+	ctx context.Context,
+	tonClient *tonclient.TonClient,
+	keyBlock *ton.BlockIDExt,
+) ([]*tlb.ValidatorAddr, uint64, []byte, error) {
+	cache := tonClient.Cache()
+	if cache != nil {
+		if set, err := cache.Validators(keyBlock); err == nil {
+			return set.Validators, set.TotalWeight, set.EpochHash, nil
+		}
+	}
+
+	block, err := tonClient.GetBlockData(ctx, keyBlock)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to get key block %d: %w", keyBlock.SeqNo, err)
+	}
+	validators, totalWeight, epochHash, err := ExtractMainValidators(block, tonClient)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	if cache != nil {
+		err = cache.PutValidators(keyBlock, &blockcache.ValidatorSet{
+			Validators:  validators,
+			TotalWeight: totalWeight,
+			EpochHash:   epochHash,
+		})
+		if err != nil {
+			log.Printf("failed to cache validator set: %v", err)
+		}
+	}
+	return validators, totalWeight, epochHash, nil
+}
+
 func ExtractMainValidators(block *tlb.Block, tonClient *tonclient.TonClient) ([]*tlb.ValidatorAddr, uint64, []byte, error) {
 	c, err := block.Extra.Custom.ConfigParams.Config.Params.LoadValueByIntKey(big.NewInt(34))
 	if err != nil {
 		return nil, 0, nil, err
 	}
-	valDict := c.MustLoadRef()
+	setCell, err := c.LoadRefCell()
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("config param 34 has no value: %w", err)
+	}
 	var set tlb.ValidatorSetAny
-	if err = tlb.LoadFromCell(&set, valDict); err != nil {
+	if err = tlb.LoadFromCell(&set, setCell.BeginParse()); err != nil {
 		return nil, 0, nil, err
 	}
 
@@ -78,5 +121,11 @@ func ExtractMainValidators(block *tlb.Block, tonClient *tonclient.TonClient) ([]
 		totalWeight += validatorsKeys[i].weight
 	}
 
-	return validators, totalWeight, valDict.MustToCell().Hash(3), nil
+	return validators, totalWeight, EpochHash(setCell), nil
+}
+
+// EpochHash returns the hash of the validator set cell of a config param,
+// which LiteClient contracts store to identify the epoch.
+func EpochHash(setCell *cell.Cell) []byte {
+	return setCell.Hash(3)
 }
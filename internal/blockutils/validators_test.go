@@ -0,0 +1,42 @@
+package blockutils_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blocktest"
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func prepareKeyBlock(seqno uint32, set *cell.Cell) *blocktest.Block {
+	return &blocktest.Block{
+		Workchain: -1,
+		Seqno:     seqno,
+		Config:    map[uint32]*cell.Cell{34: set},
+	}
+}
+
+func TestExtractMainValidatorsEpochHash(t *testing.T) {
+	firstSet := blocktest.ValidatorSet(1000, blocktest.NewValidators(40, 30, 20, 10))
+	secondSet := blocktest.ValidatorSet(2000, blocktest.NewValidators(50, 50))
+
+	validators, totalWeight, first, err := blockutils.ExtractMainValidators(prepareKeyBlock(100, firstSet).Parse(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(validators) != 4 || totalWeight != 100 {
+		t.Fatalf("unexpected validators: %d, total weight %d", len(validators), totalWeight)
+	}
+	if !bytes.Equal(first, firstSet.Hash()) {
+		t.Fatalf("epoch hash %x is not the hash of the validator set %x", first, firstSet.Hash())
+	}
+
+	_, _, second, err := blockutils.ExtractMainValidators(prepareKeyBlock(200, secondSet).Parse(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bytes.Equal(first, second) {
+		t.Fatalf("different validator sets have the same epoch hash %x", first)
+	}
+}
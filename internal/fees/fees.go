@@ -0,0 +1,203 @@
+package fees
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	tagGasPrices    = 0xdd
+	tagGasPricesExt = 0xde
+	tagGasFlatPfx   = 0xd1
+	tagMsgPrices    = 0xea
+
+	// WalletGasUsed is an upper estimate of the gas a wallet contract uses
+	// to accept an external message and send one internal message.
+	WalletGasUsed = 5000
+)
+
+// GasPrices are the gas limits and prices of config param 20 (masterchain) or 21 (basechain).
+// GasPrice is in nanotons per 2^16 gas units.
+type GasPrices struct {
+	FlatGasLimit uint64
+	FlatGasPrice uint64
+	GasPrice     uint64
+	GasLimit     uint64
+}
+
+// MsgPrices are the message forwarding prices of config param 24 (masterchain) or 25 (basechain).
+// BitPrice and CellPrice are in nanotons per 2^16 bits or cells.
+type MsgPrices struct {
+	LumpPrice uint64
+	BitPrice  uint64
+	CellPrice uint64
+}
+
+// Prices are the gas and forwarding prices of a workchain.
+type Prices struct {
+	Gas *GasPrices
+	Msg *MsgPrices
+}
+
+// LoadPrices reads the prices of the workchain from the config of the last masterchain block.
+func LoadPrices(ctx context.Context, api ton.APIClientWrapped, workchain int32) (*Prices, error) {
+	gasParam, msgParam := int32(21), int32(25)
+	if workchain == address.MasterchainID {
+		gasParam, msgParam = 20, 24
+	}
+
+	master, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	config, err := api.GetBlockchainConfig(ctx, master, gasParam, msgParam)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config params %d and %d: %w", gasParam, msgParam, err)
+	}
+
+	gasCell, msgCell := config.Get(gasParam), config.Get(msgParam)
+	if gasCell == nil || msgCell == nil {
+		return nil, fmt.Errorf("config params %d and %d are not set", gasParam, msgParam)
+	}
+	gas, err := ParseGasPrices(gasCell)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config param %d: %w", gasParam, err)
+	}
+	msg, err := ParseMsgPrices(msgCell)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config param %d: %w", msgParam, err)
+	}
+	return &Prices{Gas: gas, Msg: msg}, nil
+}
+
+// ParseGasPrices parses GasLimitsPrices, with or without the flat gas prefix.
+func ParseGasPrices(c *cell.Cell) (*GasPrices, error) {
+	s := c.BeginParse()
+	var p GasPrices
+
+	tag, err := s.LoadUInt(8)
+	if err != nil {
+		return nil, err
+	}
+	if tag == tagGasFlatPfx {
+		if p.FlatGasLimit, err = s.LoadUInt(64); err != nil {
+			return nil, err
+		}
+		if p.FlatGasPrice, err = s.LoadUInt(64); err != nil {
+			return nil, err
+		}
+		if tag, err = s.LoadUInt(8); err != nil {
+			return nil, err
+		}
+	}
+	if tag != tagGasPrices && tag != tagGasPricesExt {
+		return nil, fmt.Errorf("unexpected gas prices tag %02x", tag)
+	}
+
+	if p.GasPrice, err = s.LoadUInt(64); err != nil {
+		return nil, err
+	}
+	if p.GasLimit, err = s.LoadUInt(64); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ParseMsgPrices parses MsgForwardPrices.
+func ParseMsgPrices(c *cell.Cell) (*MsgPrices, error) {
+	s := c.BeginParse()
+	var p MsgPrices
+
+	tag, err := s.LoadUInt(8)
+	if err != nil {
+		return nil, err
+	}
+	if tag != tagMsgPrices {
+		return nil, fmt.Errorf("unexpected msg forward prices tag %02x", tag)
+	}
+	if p.LumpPrice, err = s.LoadUInt(64); err != nil {
+		return nil, err
+	}
+	if p.BitPrice, err = s.LoadUInt(64); err != nil {
+		return nil, err
+	}
+	if p.CellPrice, err = s.LoadUInt(64); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GasFee returns the fee in nanotons for the gas used.
+func (p *GasPrices) GasFee(gasUsed uint64) *big.Int {
+	fee := new(big.Int).SetUint64(p.FlatGasPrice)
+	if gasUsed <= p.FlatGasLimit {
+		return fee
+	}
+	extra := new(big.Int).Mul(new(big.Int).SetUint64(p.GasPrice), new(big.Int).SetUint64(gasUsed-p.FlatGasLimit))
+	return fee.Add(fee, shiftCeil(extra))
+}
+
+// MaxGasFee returns the fee for the gas limit of a transaction.
+func (p *GasPrices) MaxGasFee() *big.Int {
+	return p.GasFee(p.GasLimit)
+}
+
+// ForwardFee returns the fee in nanotons for a message of the size.
+// The same formula gives the import fee of external messages.
+func (p *MsgPrices) ForwardFee(bits, cells uint64) *big.Int {
+	size := new(big.Int).Mul(new(big.Int).SetUint64(p.BitPrice), new(big.Int).SetUint64(bits))
+	size.Add(size, new(big.Int).Mul(new(big.Int).SetUint64(p.CellPrice), new(big.Int).SetUint64(cells)))
+	return size.Add(shiftCeil(size), new(big.Int).SetUint64(p.LumpPrice))
+}
+
+// MsgSize returns the number of bits and distinct cells of the message,
+// without the root cell, as they are counted for forwarding fees.
+func MsgSize(msg *cell.Cell) (bits, cells uint64) {
+	seen := map[string]bool{}
+	var walk func(c *cell.Cell)
+	walk = func(c *cell.Cell) {
+		for i := 0; i < int(c.RefsNum()); i++ {
+			ref := c.MustPeekRef(i)
+			key := string(ref.Hash())
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			bits += uint64(ref.BitsSize())
+			cells++
+			walk(ref)
+		}
+	}
+	walk(msg)
+	return bits, cells
+}
+
+// shiftCeil returns ceil(v / 2^16).
+func shiftCeil(v *big.Int) *big.Int {
+	r := new(big.Int).Add(v, big.NewInt(1<<16-1))
+	return r.Rsh(r, 16)
+}
+
+// Estimate is the cost of an external message to the wallet and the internal message
+// the wallet sends. ContractGas is the most the compute phase of the destination can
+// take, limited by the attached value. Storage fees are not included.
+type Estimate struct {
+	ImportFee   *big.Int
+	WalletGas   *big.Int
+	ForwardFee  *big.Int
+	ContractGas *big.Int
+}
+
+// Total returns the sum of the fees.
+func (e *Estimate) Total() *big.Int {
+	total := new(big.Int)
+	for _, fee := range []*big.Int{e.ImportFee, e.WalletGas, e.ForwardFee, e.ContractGas} {
+		total.Add(total, fee)
+	}
+	return total
+}
@@ -0,0 +1,59 @@
+package fees_test
+
+import (
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/fees"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestGasPrices(t *testing.T) {
+	// Basechain prices: 100 flat gas for 40000 nanotons, then 400 nanotons per gas.
+	param := cell.BeginCell().
+		MustStoreUInt(0xd1, 8).MustStoreUInt(100, 64).MustStoreUInt(40000, 64).
+		MustStoreUInt(0xde, 8).MustStoreUInt(400<<16, 64).MustStoreUInt(1_000_000, 64).
+		EndCell()
+
+	prices, err := fees.ParseGasPrices(param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee := prices.GasFee(50); fee.Uint64() != 40000 {
+		t.Fatalf("expected the flat fee for 50 gas, got %v", fee)
+	}
+	if fee := prices.GasFee(1000); fee.Uint64() != 400000 {
+		t.Fatalf("expected 400000 for 1000 gas, got %v", fee)
+	}
+	if fee := prices.MaxGasFee(); fee.Uint64() != 40000+999_900*400 {
+		t.Fatalf("unexpected max gas fee %v", fee)
+	}
+
+	if _, err := fees.ParseGasPrices(cell.BeginCell().MustStoreUInt(0xea, 8).EndCell()); err == nil {
+		t.Fatal("expected an error for an unknown tag")
+	}
+}
+
+func TestForwardFee(t *testing.T) {
+	param := cell.BeginCell().
+		MustStoreUInt(0xea, 8).
+		MustStoreUInt(400000, 64).MustStoreUInt(26214400, 64).MustStoreUInt(2621440000, 64).
+		EndCell()
+
+	prices, err := fees.ParseMsgPrices(param)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	child := cell.BeginCell().MustStoreUInt(0, 100).EndCell()
+	msg := cell.BeginCell().MustStoreUInt(0, 32).MustStoreRef(child).MustStoreRef(child).EndCell()
+	bits, cells := fees.MsgSize(msg)
+	if bits != 100 || cells != 1 {
+		t.Fatalf("expected 100 bits in 1 cell, got %d bits in %d cells", bits, cells)
+	}
+	if fee := prices.ForwardFee(bits, cells); fee.Uint64() != 480000 {
+		t.Fatalf("expected forward fee 480000, got %v", fee)
+	}
+	if fee := prices.ForwardFee(0, 0); fee.Uint64() != 400000 {
+		t.Fatalf("expected the lump price for an empty message, got %v", fee)
+	}
+}
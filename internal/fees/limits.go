@@ -0,0 +1,82 @@
+package fees
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const paramSizeLimits = 43
+
+// MsgLimits are the message size limits of config param 43.
+type MsgLimits struct {
+	MaxMsgBits  uint64
+	MaxMsgCells uint64
+	// MaxExtMsgSize is the limit of the BOC of an external message in bytes.
+	MaxExtMsgSize  uint64
+	MaxExtMsgDepth uint64
+}
+
+// DefaultMsgLimits are the limits of the node when config param 43 is not set.
+var DefaultMsgLimits = MsgLimits{
+	MaxMsgBits:     1 << 21,
+	MaxMsgCells:    1 << 13,
+	MaxExtMsgSize:  65535,
+	MaxExtMsgDepth: 512,
+}
+
+// LoadMsgLimits reads the message size limits from the config of the last masterchain block.
+func LoadMsgLimits(ctx context.Context, api ton.APIClientWrapped) (*MsgLimits, error) {
+	master, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	config, err := api.GetBlockchainConfig(ctx, master, paramSizeLimits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config param %d: %w", paramSizeLimits, err)
+	}
+
+	param := config.Get(paramSizeLimits)
+	if param == nil {
+		limits := DefaultMsgLimits
+		return &limits, nil
+	}
+	limits, err := ParseMsgLimits(param)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config param %d: %w", paramSizeLimits, err)
+	}
+	return limits, nil
+}
+
+// ParseMsgLimits parses SizeLimitsConfig of both versions.
+func ParseMsgLimits(c *cell.Cell) (*MsgLimits, error) {
+	s := c.BeginParse()
+	var l MsgLimits
+
+	tag, err := s.LoadUInt(8)
+	if err != nil {
+		return nil, err
+	}
+	if tag != 0x01 && tag != 0x02 {
+		return nil, fmt.Errorf("unexpected size limits tag %02x", tag)
+	}
+	if l.MaxMsgBits, err = s.LoadUInt(32); err != nil {
+		return nil, err
+	}
+	if l.MaxMsgCells, err = s.LoadUInt(32); err != nil {
+		return nil, err
+	}
+	// max_library_cells:uint32 max_vm_data_depth:uint16
+	if _, err = s.LoadUInt(48); err != nil {
+		return nil, err
+	}
+	if l.MaxExtMsgSize, err = s.LoadUInt(32); err != nil {
+		return nil, err
+	}
+	if l.MaxExtMsgDepth, err = s.LoadUInt(16); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
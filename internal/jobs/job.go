@@ -0,0 +1,100 @@
+package jobs
+
+import (
+	"time"
+)
+
+// Kind is the kind of message a job submits.
+type Kind string
+
+const (
+	// KindKeyBlock submits new_key_block of a key block to a LiteClient.
+	KindKeyBlock Kind = "key_block"
+	// KindCheckTx submits check_transaction of a transaction to a TxChecker.
+	KindCheckTx Kind = "check_tx"
+)
+
+// State is the progress of a job.
+type State string
+
+const (
+	// StatePending jobs are sent when their next attempt is due.
+	StatePending State = "pending"
+	// StateSending jobs have their external message built and its hash saved,
+	// but it may not have been sent yet.
+	StateSending State = "sending"
+	// StateSent jobs wait for the wallet to process their external message.
+	StateSent State = "sent"
+	// StateConfirmed jobs were accepted by the contract.
+	StateConfirmed State = "confirmed"
+	// StateRejected jobs failed in the contract or can not be sent at all.
+	StateRejected State = "rejected"
+	// StateBounced jobs were rejected by a bounced message.
+	StateBounced State = "bounced"
+	// StateCancelled jobs were cancelled by the operator.
+	StateCancelled State = "cancelled"
+)
+
+// States are the names of all states.
+var States = []State{StatePending, StateSending, StateSent, StateConfirmed, StateRejected, StateBounced, StateCancelled}
+
+// Final reports whether the runner leaves jobs in the state alone.
+func (s State) Final() bool {
+	return s != StatePending && !s.InFlight()
+}
+
+// InFlight reports whether the wallet may still process the external message of jobs in the state.
+func (s State) InFlight() bool {
+	return s == StateSending || s == StateSent
+}
+
+// Job is a message submitted to a contract of the target network, from the moment
+// its object was seen in the source network until the contract answered.
+type Job struct {
+	ID      uint64 `json:"id"`
+	Kind    Kind   `json:"kind"`
+	Network string `json:"network"`
+	// Target is the address of the contract the message is sent to.
+	Target string `json:"target"`
+	// Key identifies the submitted object among the jobs of the kind and target:
+	// the seqno of the key block or the hash of the transaction.
+	Key string `json:"key"`
+
+	// Seqno is the seqno of the key block of KindKeyBlock jobs.
+	Seqno uint32 `json:"seqno,omitempty"`
+	// Account and LT locate the transaction of KindCheckTx jobs.
+	Account string `json:"account,omitempty"`
+	LT      uint64 `json:"lt,omitempty"`
+
+	State State `json:"state"`
+	// InMsgHash is the hash of the last external message sent by the wallet.
+	InMsgHash string    `json:"in_msg_hash,omitempty"`
+	SentAt    time.Time `json:"sent_at,omitempty"`
+	// WalletTx is the hash of the wallet transaction that processed the message.
+	WalletTx    string    `json:"wallet_tx,omitempty"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+	// LastError is the last send failure or the reason the contract rejected the message.
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Backoff is the delay before the next attempt of a job,
+// doubled with every attempt up to Max.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// Delay returns the delay after the given number of attempts.
+func (b Backoff) Delay(attempts int) time.Duration {
+	delay := b.Initial
+	for i := 1; i < attempts && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if b.Max > 0 && delay > b.Max {
+		delay = b.Max
+	}
+	return delay
+}
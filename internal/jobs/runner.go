@@ -0,0 +1,249 @@
+package jobs
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var (
+	// ErrNotLanded is returned by Confirm while no wallet transaction processed the external message.
+	ErrNotLanded = errors.New("external message is not processed by the wallet")
+	// ErrPermanent marks send errors that attempts can not fix, such as a transaction
+	// that can not be proven. The job is rejected instead of retried.
+	ErrPermanent = errors.New("job can not be sent")
+)
+
+// Outcome is the answer of the contract to a sent job.
+type Outcome struct {
+	State    State
+	WalletTx []byte
+	Reason   string
+}
+
+// Runner sends the due jobs and confirms the sent ones. A job is sent again with backoff
+// if the send fails before its message is built, or if its external message expires before
+// the wallet processes it. The hash of the message is saved before the message is sent,
+// so a job interrupted while sending is confirmed by the hash instead of being sent twice.
+type Runner struct {
+	Store   *Store
+	Backoff Backoff
+	// Expiry is how long a sent message may stay unprocessed before the job is sent again.
+	// It must exceed the TTL of the external messages of the wallet.
+	Expiry time.Duration
+	// PollInterval is the delay between the attempts to confirm a sent job.
+	PollInterval time.Duration
+
+	// Send builds the external message of the job, calls sending with the hash of its payload,
+	// as wallet.SendManyGetInMsgHash returns it, and sends the message only if sending succeeds.
+	Send func(ctx context.Context, job *Job, sending func(inMsgHash []byte) error) error
+	// Confirm finds the wallet transaction of the external message with the hash
+	// and judges the answer of the contract. It returns ErrNotLanded if there is no such transaction yet.
+	Confirm func(ctx context.Context, job *Job, inMsgHash []byte) (*Outcome, error)
+	// Fatal reports whether a send error must stop the runner, leaving the job due.
+	Fatal func(err error) bool
+}
+
+// Run steps the job until it reaches a final state.
+func (r *Runner) Run(ctx context.Context, job *Job) (*Job, error) {
+	for !job.State.Final() {
+		next, err := r.Step(ctx, job)
+		if err != nil {
+			return job, err
+		}
+		job = next
+		if job.State.Final() {
+			break
+		}
+
+		wait := r.PollInterval
+		if job.State == StatePending {
+			wait = time.Until(job.NextAttempt)
+		}
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	return job, nil
+}
+
+// RunDue steps every pending, sending and sent job that matches once, so that the jobs left
+// by a previous run or retried by the operator are resumed. It stops only on fatal errors.
+func (r *Runner) RunDue(ctx context.Context, match func(*Job) bool) error {
+	list, err := r.Store.List(StatePending, StateSending, StateSent)
+	if err != nil {
+		return err
+	}
+	for _, job := range list {
+		if !match(job) {
+			continue
+		}
+		if _, err = r.Step(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Step sends the job if it is pending and due, or confirms it if it is sending or sent.
+// The job is reloaded first, as the operator may have retried or cancelled it.
+// Failures are recorded in the job; only fatal and context errors are returned.
+func (r *Runner) Step(ctx context.Context, job *Job) (*Job, error) {
+	job, err := r.Store.Get(job.ID)
+	if err != nil {
+		return nil, err
+	}
+	switch job.State {
+	case StatePending:
+		if r.Store.now().Before(job.NextAttempt) {
+			return job, nil
+		}
+		return r.send(ctx, job)
+	case StateSending, StateSent:
+		return r.confirm(ctx, job)
+	}
+	return job, nil
+}
+
+func (r *Runner) send(ctx context.Context, job *Job) (*Job, error) {
+	var sending *Job
+	sendErr := r.Send(ctx, job, func(inMsgHash []byte) error {
+		updated, err := r.Store.Update(job.ID, func(job *Job) error {
+			if job.State != StatePending {
+				return fmt.Errorf("%w: job %d is %s", ErrInvalidState, job.ID, job.State)
+			}
+			job.State = StateSending
+			job.Attempts++
+			job.InMsgHash = hex.EncodeToString(inMsgHash)
+			job.SentAt = r.Store.now()
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save job %d: %w", job.ID, err)
+		}
+		sending = updated
+		return nil
+	})
+	if sending != nil {
+		return r.sent(ctx, sending, sendErr)
+	}
+	if ctx.Err() != nil {
+		return job, ctx.Err()
+	}
+	if sendErr == nil {
+		sendErr = fmt.Errorf("message of job %d was not built", job.ID)
+	}
+	if r.Fatal != nil && r.Fatal(sendErr) {
+		return job, sendErr
+	}
+
+	updated, err := r.Store.Update(job.ID, func(job *Job) error {
+		if job.State != StatePending {
+			// cancelled while it was sent
+			return nil
+		}
+		job.Attempts++
+		job.LastError = sendErr.Error()
+		if errors.Is(sendErr, ErrPermanent) {
+			job.State = StateRejected
+		} else {
+			job.NextAttempt = r.Store.now().Add(r.Backoff.Delay(job.Attempts))
+		}
+		return nil
+	})
+	if err != nil {
+		return job, fmt.Errorf("failed to save job %d: %w", job.ID, err)
+	}
+	switch updated.State {
+	case StateCancelled:
+		log.Printf("Attention: job %d (%s %s) was cancelled while it was sent", updated.ID, updated.Kind, updated.Key)
+	case StateRejected:
+		log.Printf("Attention: job %d (%s %s) can not be sent: %v", updated.ID, updated.Kind, updated.Key, sendErr)
+	default:
+		log.Printf("job %d (%s %s) failed, attempt %d, retrying at %s: %v",
+			updated.ID, updated.Kind, updated.Key, updated.Attempts, updated.NextAttempt.Format(time.RFC3339), sendErr)
+	}
+	return updated, nil
+}
+
+// sent records the result of sending the message of a sending job. If the send fails,
+// the message may still have reached the network, so the job stays sending
+// and is confirmed until the message expires.
+func (r *Runner) sent(ctx context.Context, job *Job, sendErr error) (*Job, error) {
+	if ctx.Err() != nil {
+		return job, ctx.Err()
+	}
+	updated, err := r.Store.Update(job.ID, func(job *Job) error {
+		if job.State != StateSending {
+			return nil
+		}
+		if sendErr != nil {
+			job.LastError = sendErr.Error()
+			return nil
+		}
+		job.State = StateSent
+		return nil
+	})
+	if err != nil {
+		return job, fmt.Errorf("failed to save job %d: %w", job.ID, err)
+	}
+	if sendErr != nil {
+		log.Printf("Attention: failed to send external message %s of job %d (%s %s), confirming it until it expires: %v",
+			updated.InMsgHash, updated.ID, updated.Kind, updated.Key, sendErr)
+		return updated, nil
+	}
+	log.Printf("job %d (%s %s) sent with external message %s", updated.ID, updated.Kind, updated.Key, updated.InMsgHash)
+	return updated, nil
+}
+
+func (r *Runner) confirm(ctx context.Context, job *Job) (*Job, error) {
+	inMsgHash, err := hex.DecodeString(job.InMsgHash)
+	if err != nil {
+		return job, fmt.Errorf("job %d has invalid in msg hash: %w", job.ID, err)
+	}
+	outcome, confirmErr := r.Confirm(ctx, job, inMsgHash)
+	if ctx.Err() != nil {
+		return job, ctx.Err()
+	}
+
+	expired := errors.Is(confirmErr, ErrNotLanded) && r.Store.now().Sub(job.SentAt) > r.Expiry
+	if confirmErr != nil && !expired {
+		if !errors.Is(confirmErr, ErrNotLanded) {
+			log.Printf("failed to confirm job %d (%s %s): %v", job.ID, job.Kind, job.Key, confirmErr)
+		}
+		return job, nil
+	}
+
+	updated, err := r.Store.Update(job.ID, func(job *Job) error {
+		if !job.State.InFlight() {
+			return nil
+		}
+		if expired {
+			job.State = StatePending
+			job.NextAttempt = r.Store.now().Add(r.Backoff.Delay(job.Attempts))
+			job.LastError = fmt.Sprintf("external message %s expired", job.InMsgHash)
+			return nil
+		}
+		job.State = outcome.State
+		job.WalletTx = hex.EncodeToString(outcome.WalletTx)
+		job.LastError = outcome.Reason
+		return nil
+	})
+	if err != nil {
+		return job, fmt.Errorf("failed to save job %d: %w", job.ID, err)
+	}
+	switch {
+	case updated.State == StateCancelled:
+	case expired:
+		log.Printf("Attention: external message of job %d (%s %s) expired, sending it again at %s",
+			updated.ID, updated.Kind, updated.Key, updated.NextAttempt.Format(time.RFC3339))
+	default:
+		log.Printf("job %d (%s %s) %s in wallet transaction %s", updated.ID, updated.Kind, updated.Key, updated.State, updated.WalletTx)
+	}
+	return updated, nil
+}
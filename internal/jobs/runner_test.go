@@ -0,0 +1,261 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/jobs"
+)
+
+func TestRunnerSavesHashBeforeSending(t *testing.T) {
+	store := openStore(t)
+	job, _, err := store.Add(keyBlockJob("100"))
+	if err != nil {
+		t.Fatalf("failed to add job: %v", err)
+	}
+
+	var sent int
+	runner := &jobs.Runner{
+		Store:  store,
+		Expiry: time.Hour,
+		Send: func(ctx context.Context, job *jobs.Job, sending func(inMsgHash []byte) error) error {
+			sent++
+			if err := sending([]byte{0x01}); err != nil {
+				return err
+			}
+			saved, err := store.Get(job.ID)
+			if err != nil {
+				return err
+			}
+			if saved.State != jobs.StateSending || saved.InMsgHash != "01" {
+				t.Errorf("hash is not saved before sending: %+v", saved)
+			}
+			// the liteserver may have received the message before the connection broke
+			return errors.New("connection reset")
+		},
+		Confirm: func(ctx context.Context, job *jobs.Job, inMsgHash []byte) (*jobs.Outcome, error) {
+			return &jobs.Outcome{State: jobs.StateConfirmed, WalletTx: []byte{0xaa}}, nil
+		},
+	}
+
+	job, err = runner.Step(context.Background(), job)
+	if err != nil {
+		t.Fatalf("failed to step job: %v", err)
+	}
+	if job.State != jobs.StateSending || job.Attempts != 1 || job.LastError == "" {
+		t.Fatalf("expected sending job with the send error, got %+v", job)
+	}
+
+	job, err = runner.Run(context.Background(), job)
+	if err != nil {
+		t.Fatalf("failed to run job: %v", err)
+	}
+	if sent != 1 || job.State != jobs.StateConfirmed || job.Attempts != 1 || job.InMsgHash != "01" || job.WalletTx != "aa" {
+		t.Fatalf("unexpected job after %d sends: %+v", sent, job)
+	}
+}
+
+func TestRunnerConfirmsSendingJobAfterRestart(t *testing.T) {
+	store := openStore(t)
+	job, _, err := store.Add(keyBlockJob("100"))
+	if err != nil {
+		t.Fatalf("failed to add job: %v", err)
+	}
+
+	// the process stops after the hash is saved, before the message is sent
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := &jobs.Runner{
+		Store: store,
+		Send: func(ctx context.Context, job *jobs.Job, sending func(inMsgHash []byte) error) error {
+			if err := sending([]byte{0x01}); err != nil {
+				return err
+			}
+			cancel()
+			return ctx.Err()
+		},
+	}
+	if _, err = stopped.Step(ctx, job); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if _, err = store.Retry(job.ID); !errors.Is(err, jobs.ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState for a sending job, got %v", err)
+	}
+
+	var confirms int
+	restarted := &jobs.Runner{
+		Store:  store,
+		Expiry: time.Hour,
+		Send: func(ctx context.Context, job *jobs.Job, sending func(inMsgHash []byte) error) error {
+			t.Errorf("sending job %d is sent again", job.ID)
+			return errors.New("sent again")
+		},
+		Confirm: func(ctx context.Context, job *jobs.Job, inMsgHash []byte) (*jobs.Outcome, error) {
+			confirms++
+			if confirms == 1 {
+				return nil, jobs.ErrNotLanded
+			}
+			return &jobs.Outcome{State: jobs.StateConfirmed, WalletTx: []byte{0xaa}}, nil
+		},
+	}
+	all := func(*jobs.Job) bool { return true }
+	for _, expected := range []jobs.State{jobs.StateSending, jobs.StateConfirmed} {
+		if err = restarted.RunDue(context.Background(), all); err != nil {
+			t.Fatalf("failed to run due jobs: %v", err)
+		}
+		if job, err = store.Get(job.ID); err != nil || job.State != expected {
+			t.Fatalf("expected %s job, got %+v, %v", expected, job, err)
+		}
+	}
+	if job.Attempts != 1 || job.WalletTx != "aa" {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+}
+
+func TestRunnerDoesNotSendCancelledJob(t *testing.T) {
+	store := openStore(t)
+	job, _, err := store.Add(keyBlockJob("100"))
+	if err != nil {
+		t.Fatalf("failed to add job: %v", err)
+	}
+
+	runner := &jobs.Runner{
+		Store: store,
+		Send: func(ctx context.Context, job *jobs.Job, sending func(inMsgHash []byte) error) error {
+			// the operator cancels the job while its message is built
+			if _, err := store.Cancel(job.ID); err != nil {
+				return err
+			}
+			err := sending([]byte{0x01})
+			if err == nil {
+				t.Errorf("cancelled job %d is sent", job.ID)
+			}
+			return err
+		},
+	}
+	job, err = runner.Step(context.Background(), job)
+	if err != nil {
+		t.Fatalf("failed to step job: %v", err)
+	}
+	if job.State != jobs.StateCancelled || job.Attempts != 0 || job.InMsgHash != "" {
+		t.Fatalf("expected cancelled job, got %+v", job)
+	}
+}
+
+func TestRunnerWaitsForMessageUntilExpiry(t *testing.T) {
+	store := openStore(t)
+	job, _, err := store.Add(keyBlockJob("100"))
+	if err != nil {
+		t.Fatalf("failed to add job: %v", err)
+	}
+
+	var sent int
+	runner := &jobs.Runner{
+		Store:   store,
+		Backoff: jobs.Backoff{Initial: time.Hour, Max: time.Hour},
+		Expiry:  time.Hour,
+		Send: func(ctx context.Context, job *jobs.Job, sending func(inMsgHash []byte) error) error {
+			sent++
+			return sending([]byte{byte(sent)})
+		},
+		Confirm: func(ctx context.Context, job *jobs.Job, inMsgHash []byte) (*jobs.Outcome, error) {
+			return nil, jobs.ErrNotLanded
+		},
+	}
+	for i := 0; i < 3; i++ {
+		if job, err = runner.Step(context.Background(), job); err != nil {
+			t.Fatalf("failed to step job: %v", err)
+		}
+	}
+	if sent != 1 || job.State != jobs.StateSent || job.InMsgHash != "01" {
+		t.Fatalf("unexpected job after %d sends: %+v", sent, job)
+	}
+}
+
+func TestRunnerResendsExpiredMessageWithBackoff(t *testing.T) {
+	store := openStore(t)
+	job, _, err := store.Add(keyBlockJob("100"))
+	if err != nil {
+		t.Fatalf("failed to add job: %v", err)
+	}
+
+	var sent int
+	var confirmed [][]byte
+	runner := &jobs.Runner{
+		Store:   store,
+		Backoff: jobs.Backoff{Initial: time.Hour, Max: time.Hour},
+		Send: func(ctx context.Context, job *jobs.Job, sending func(inMsgHash []byte) error) error {
+			sent++
+			return sending([]byte{byte(sent)})
+		},
+		Confirm: func(ctx context.Context, job *jobs.Job, inMsgHash []byte) (*jobs.Outcome, error) {
+			confirmed = append(confirmed, inMsgHash)
+			// the message of the first attempt never lands
+			if inMsgHash[0] == 1 {
+				return nil, jobs.ErrNotLanded
+			}
+			return &jobs.Outcome{State: jobs.StateConfirmed, WalletTx: []byte{0xaa}}, nil
+		},
+	}
+
+	// sent, then expired at once as Expiry is zero
+	for i := 0; i < 2; i++ {
+		if job, err = runner.Step(context.Background(), job); err != nil {
+			t.Fatalf("failed to step job: %v", err)
+		}
+	}
+	if job.State != jobs.StatePending || job.Attempts != 1 || !strings.Contains(job.LastError, "expired") ||
+		job.NextAttempt.Before(time.Now().Add(59*time.Minute)) {
+		t.Fatalf("expected pending job after the expiry, got %+v", job)
+	}
+
+	// the next attempt is not due yet
+	if job, err = runner.Step(context.Background(), job); err != nil {
+		t.Fatalf("failed to step job: %v", err)
+	}
+	if sent != 1 || job.State != jobs.StatePending {
+		t.Fatalf("expired job is sent before its next attempt: %+v", job)
+	}
+
+	if _, err = store.Retry(job.ID); err != nil {
+		t.Fatalf("failed to retry job: %v", err)
+	}
+	if job, err = runner.Run(context.Background(), job); err != nil {
+		t.Fatalf("failed to run job: %v", err)
+	}
+	if sent != 2 || job.State != jobs.StateConfirmed || job.Attempts != 2 || job.InMsgHash != "02" {
+		t.Fatalf("unexpected job after %d sends: %+v", sent, job)
+	}
+	if len(confirmed) != 2 || confirmed[0][0] != 1 || confirmed[1][0] != 2 {
+		t.Fatalf("unexpected confirmed messages: %x", confirmed)
+	}
+}
+
+func TestRunnerRetriesFailedSendWithBackoff(t *testing.T) {
+	store := openStore(t)
+	job, _, err := store.Add(keyBlockJob("100"))
+	if err != nil {
+		t.Fatalf("failed to add job: %v", err)
+	}
+
+	var attempts int
+	runner := &jobs.Runner{
+		Store:   store,
+		Backoff: jobs.Backoff{Initial: time.Hour, Max: time.Hour},
+		Send: func(ctx context.Context, job *jobs.Job, sending func(inMsgHash []byte) error) error {
+			attempts++
+			return errors.New("block is not committed yet")
+		},
+	}
+	for i := 0; i < 2; i++ {
+		if job, err = runner.Step(context.Background(), job); err != nil {
+			t.Fatalf("failed to step job: %v", err)
+		}
+	}
+	if attempts != 1 || job.State != jobs.StatePending || job.Attempts != 1 || job.InMsgHash != "" ||
+		job.NextAttempt.Before(time.Now().Add(59*time.Minute)) {
+		t.Fatalf("unexpected job after %d attempts: %+v", attempts, job)
+	}
+}
@@ -0,0 +1,228 @@
+package jobs
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	ErrNotFound     = errors.New("job not found")
+	ErrInvalidState = errors.New("operation is not allowed in the job state")
+	ErrLocked       = errors.New("jobs database is used by another process")
+)
+
+var (
+	bucketJobs = []byte("jobs")
+	// bucketKeys maps the kind, network, target and key of a job to its id.
+	bucketKeys = []byte("keys")
+)
+
+// Store keeps jobs in a bbolt database. The database is opened for every operation only,
+// so that the jobs of a running relay can be listed and changed by another process.
+type Store struct {
+	path string
+	now  func() time.Time
+}
+
+// lockTimeout is how long an operation waits for another process to release the database.
+const lockTimeout = 5 * time.Second
+
+// Open creates the database at path and its directory if needed.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create jobs dir: %w", err)
+	}
+	s := &Store{path: path, now: time.Now}
+	err := s.update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(bucketJobs); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(bucketKeys)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to init jobs database: %w", err)
+	}
+	return s, nil
+}
+
+func (s *Store) open(readOnly bool) (*bolt.DB, error) {
+	db, err := bolt.Open(s.path, 0o600, &bolt.Options{Timeout: lockTimeout, ReadOnly: readOnly})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("%w: %s", ErrLocked, s.path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open jobs database: %w", err)
+	}
+	return db, nil
+}
+
+func (s *Store) view(fn func(*bolt.Tx) error) error {
+	db, err := s.open(true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+func (s *Store) update(fn func(*bolt.Tx) error) error {
+	db, err := s.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(fn)
+}
+
+// Add stores the job as pending and due now, unless there is a job with the same
+// kind, network, target and key. It returns the stored job and whether it was added.
+func (s *Store) Add(job *Job) (*Job, bool, error) {
+	var stored *Job
+	var added bool
+	err := s.update(func(tx *bolt.Tx) error {
+		keys := tx.Bucket(bucketKeys)
+		uniqueKey := []byte(fmt.Sprintf("%s/%s/%s/%s", job.Kind, job.Network, job.Target, job.Key))
+		if id := keys.Get(uniqueKey); id != nil {
+			var err error
+			stored, err = getJob(tx, binary.BigEndian.Uint64(id))
+			return err
+		}
+
+		id, err := tx.Bucket(bucketJobs).NextSequence()
+		if err != nil {
+			return err
+		}
+		now := s.now()
+		stored = &Job{
+			ID:          id,
+			Kind:        job.Kind,
+			Network:     job.Network,
+			Target:      job.Target,
+			Key:         job.Key,
+			Seqno:       job.Seqno,
+			Account:     job.Account,
+			LT:          job.LT,
+			State:       StatePending,
+			NextAttempt: now,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if err = keys.Put(uniqueKey, idKey(id)); err != nil {
+			return err
+		}
+		added = true
+		return putJob(tx, stored)
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to add job: %w", err)
+	}
+	return stored, added, nil
+}
+
+// Get returns the job with the id.
+func (s *Store) Get(id uint64) (*Job, error) {
+	var job *Job
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		job, err = getJob(tx, id)
+		return err
+	})
+	return job, err
+}
+
+// List returns the jobs in one of the states, or all jobs without states, in the order they were added.
+func (s *Store) List(states ...State) ([]*Job, error) {
+	var list []*Job
+	err := s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketJobs).ForEach(func(_, value []byte) error {
+			var job Job
+			if err := json.Unmarshal(value, &job); err != nil {
+				return fmt.Errorf("failed to parse job: %w", err)
+			}
+			if len(states) == 0 || slices.Contains(states, job.State) {
+				list = append(list, &job)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	return list, nil
+}
+
+// Update changes the job with the id by update in one transaction.
+// The job is not stored if update fails.
+func (s *Store) Update(id uint64, update func(*Job) error) (*Job, error) {
+	var job *Job
+	err := s.update(func(tx *bolt.Tx) error {
+		var err error
+		if job, err = getJob(tx, id); err != nil {
+			return err
+		}
+		if err = update(job); err != nil {
+			return err
+		}
+		job.UpdatedAt = s.now()
+		return putJob(tx, job)
+	})
+	return job, err
+}
+
+// Retry makes a pending, rejected, bounced or cancelled job due now.
+// Sending and sent jobs can not be retried, as their message may still be processed.
+func (s *Store) Retry(id uint64) (*Job, error) {
+	return s.Update(id, func(job *Job) error {
+		if job.State.InFlight() || job.State == StateConfirmed {
+			return fmt.Errorf("%w: job %d is %s", ErrInvalidState, job.ID, job.State)
+		}
+		job.State = StatePending
+		job.NextAttempt = s.now()
+		return nil
+	})
+}
+
+// Cancel stops the runner from sending or confirming a pending, sending or sent job.
+// The message of a sending or sent job may still be processed by the contract.
+func (s *Store) Cancel(id uint64) (*Job, error) {
+	return s.Update(id, func(job *Job) error {
+		if job.State.Final() {
+			return fmt.Errorf("%w: job %d is %s", ErrInvalidState, job.ID, job.State)
+		}
+		job.State = StateCancelled
+		return nil
+	})
+}
+
+func idKey(id uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, id)
+}
+
+func getJob(tx *bolt.Tx, id uint64) (*Job, error) {
+	value := tx.Bucket(bucketJobs).Get(idKey(id))
+	if value == nil {
+		return nil, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	var job Job
+	if err := json.Unmarshal(value, &job); err != nil {
+		return nil, fmt.Errorf("failed to parse job %d: %w", id, err)
+	}
+	return &job, nil
+}
+
+func putJob(tx *bolt.Tx, job *Job) error {
+	value, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job %d: %w", job.ID, err)
+	}
+	return tx.Bucket(bucketJobs).Put(idKey(job.ID), value)
+}
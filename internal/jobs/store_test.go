@@ -0,0 +1,141 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/jobs"
+)
+
+func openStore(t *testing.T) *jobs.Store {
+	t.Helper()
+	store, err := jobs.Open(filepath.Join(t.TempDir(), "jobs", "jobs.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	return store
+}
+
+func keyBlockJob(seqno string) *jobs.Job {
+	return &jobs.Job{Kind: jobs.KindKeyBlock, Network: "testnet", Target: "EQ-lite-client", Key: seqno}
+}
+
+func TestStoreAddDeduplicates(t *testing.T) {
+	store := openStore(t)
+
+	first, added, err := store.Add(keyBlockJob("100"))
+	if err != nil || !added {
+		t.Fatalf("expected the job to be added, got %v, %v", added, err)
+	}
+	if first.State != jobs.StatePending || first.ID != 1 {
+		t.Fatalf("unexpected job: %+v", first)
+	}
+
+	again, added, err := store.Add(keyBlockJob("100"))
+	if err != nil || added || again.ID != first.ID {
+		t.Fatalf("expected the existing job, got %+v, %v, %v", again, added, err)
+	}
+
+	other, added, err := store.Add(keyBlockJob("200"))
+	if err != nil || !added || other.ID != 2 {
+		t.Fatalf("expected a new job, got %+v, %v, %v", other, added, err)
+	}
+
+	list, err := store.List(jobs.StatePending)
+	if err != nil {
+		t.Fatalf("failed to list jobs: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != 1 || list[1].ID != 2 {
+		t.Fatalf("unexpected jobs: %+v", list)
+	}
+}
+
+func TestStoreRetryAndCancel(t *testing.T) {
+	store := openStore(t)
+	job, _, err := store.Add(keyBlockJob("100"))
+	if err != nil {
+		t.Fatalf("failed to add job: %v", err)
+	}
+
+	if job, err = store.Cancel(job.ID); err != nil || job.State != jobs.StateCancelled {
+		t.Fatalf("expected cancelled job, got %+v, %v", job, err)
+	}
+	if _, err = store.Cancel(job.ID); !errors.Is(err, jobs.ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+	if job, err = store.Retry(job.ID); err != nil || job.State != jobs.StatePending {
+		t.Fatalf("expected pending job, got %+v, %v", job, err)
+	}
+	if _, err = store.Retry(42); !errors.Is(err, jobs.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := jobs.Backoff{Initial: time.Second, Max: 5 * time.Second}
+	for attempts, expected := range []time.Duration{time.Second, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if delay := b.Delay(attempts); delay != expected {
+			t.Fatalf("attempt %d: expected %s, got %s", attempts, expected, delay)
+		}
+	}
+}
+
+func TestRunnerResendsExpiredMessage(t *testing.T) {
+	store := openStore(t)
+	job, _, err := store.Add(keyBlockJob("100"))
+	if err != nil {
+		t.Fatalf("failed to add job: %v", err)
+	}
+
+	var sent int
+	runner := &jobs.Runner{
+		Store: store,
+		Send: func(ctx context.Context, job *jobs.Job, sending func(inMsgHash []byte) error) error {
+			sent++
+			if sent == 1 {
+				return errors.New("liteserver is down")
+			}
+			return sending([]byte{byte(sent)})
+		},
+		Confirm: func(ctx context.Context, job *jobs.Job, inMsgHash []byte) (*jobs.Outcome, error) {
+			// the message of the second attempt never lands
+			if inMsgHash[0] == 2 {
+				return nil, jobs.ErrNotLanded
+			}
+			return &jobs.Outcome{State: jobs.StateConfirmed, WalletTx: []byte{0xaa}}, nil
+		},
+	}
+
+	job, err = runner.Run(context.Background(), job)
+	if err != nil {
+		t.Fatalf("failed to run job: %v", err)
+	}
+	if sent != 3 || job.State != jobs.StateConfirmed || job.Attempts != 3 || job.InMsgHash != "03" || job.WalletTx != "aa" {
+		t.Fatalf("unexpected job after %d sends: %+v", sent, job)
+	}
+}
+
+func TestRunnerRejectsPermanentFailure(t *testing.T) {
+	store := openStore(t)
+	job, _, err := store.Add(keyBlockJob("100"))
+	if err != nil {
+		t.Fatalf("failed to add job: %v", err)
+	}
+
+	runner := &jobs.Runner{
+		Store: store,
+		Send: func(ctx context.Context, job *jobs.Job, sending func(inMsgHash []byte) error) error {
+			return jobs.ErrPermanent
+		},
+	}
+	job, err = runner.Run(context.Background(), job)
+	if err != nil {
+		t.Fatalf("failed to run job: %v", err)
+	}
+	if job.State != jobs.StateRejected || job.LastError == "" {
+		t.Fatalf("expected rejected job, got %+v", job)
+	}
+}
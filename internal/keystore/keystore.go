@@ -0,0 +1,284 @@
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
+	"golang.org/x/crypto/scrypt"
+)
+
+var (
+	ErrNotFound        = errors.New("key not found")
+	ErrExists          = errors.New("key already exists")
+	ErrWrongPassphrase = errors.New("wrong passphrase or corrupted key file")
+	ErrInvalidName     = errors.New("invalid key name")
+)
+
+const (
+	fileVersion = 1
+	fileExt     = ".json"
+
+	// scrypt parameters of new keys: about 128MB of memory and a second of CPU time.
+	scryptN      = 1 << 17
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// Key is the public part of a stored wallet key.
+type Key struct {
+	Name      string
+	PublicKey ed25519.PublicKey
+	CreatedAt time.Time
+}
+
+// Secret is the mnemonic of a wallet with its optional password.
+type Secret struct {
+	Mnemonic []string `json:"mnemonic"`
+	Password string   `json:"password,omitempty"`
+}
+
+// PrivateKey derives the private key of the wallet.
+func (s *Secret) PrivateKey() (ed25519.PrivateKey, error) {
+	return wallet.SeedToPrivateKey(s.Mnemonic, s.Password)
+}
+
+// keyFile is a key in the keystore directory. The secret is encrypted with AES-256-GCM
+// with a key derived from the passphrase by scrypt, the public key is authenticated with it.
+type keyFile struct {
+	Version    int       `json:"version"`
+	Name       string    `json:"name"`
+	PublicKey  string    `json:"public_key"`
+	CreatedAt  time.Time `json:"created_at"`
+	KDF        kdfParams `json:"kdf"`
+	Nonce      string    `json:"nonce"`
+	Ciphertext string    `json:"ciphertext"`
+}
+
+type kdfParams struct {
+	Name string `json:"name"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt string `json:"salt"`
+}
+
+// Keystore keeps encrypted wallet keys in a directory, a file per key.
+type Keystore struct {
+	dir string
+}
+
+func Open(dir string) *Keystore {
+	return &Keystore{dir: dir}
+}
+
+// Add encrypts the secret with the passphrase and stores it under the name.
+func (ks *Keystore) Add(name string, secret *Secret, passphrase []byte) (*Key, error) {
+	if !namePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: %q, use letters, digits, '_', '.' and '-'", ErrInvalidName, name)
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase must not be empty")
+	}
+	privateKey, err := secret.PrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+
+	if err = os.MkdirAll(ks.dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create keystore directory: %w", err)
+	}
+
+	plaintext, err := json.Marshal(secret)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 32)
+	if _, err = rand.Read(salt); err != nil {
+		return nil, err
+	}
+	kdf := kdfParams{Name: "scrypt", N: scryptN, R: scryptR, P: scryptP, Salt: hex.EncodeToString(salt)}
+	aead, err := kdf.cipher(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	f := &keyFile{
+		Version:    fileVersion,
+		Name:       name,
+		PublicKey:  hex.EncodeToString(publicKey),
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+		KDF:        kdf,
+		Nonce:      hex.EncodeToString(nonce),
+		Ciphertext: hex.EncodeToString(aead.Seal(nil, nonce, plaintext, publicKey)),
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	// O_EXCL keeps an existing key from being overwritten
+	file, err := os.OpenFile(ks.path(name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return nil, fmt.Errorf("%w: %s", ErrExists, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key file: %w", err)
+	}
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+		os.Remove(ks.path(name))
+		return nil, fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err = file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write key file: %w", err)
+	}
+	return &Key{Name: name, PublicKey: publicKey, CreatedAt: f.CreatedAt}, nil
+}
+
+// Get returns the public part of the key.
+func (ks *Keystore) Get(name string) (*Key, error) {
+	f, err := ks.load(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.key()
+}
+
+// List returns the keys sorted by name.
+func (ks *Keystore) List() ([]*Key, error) {
+	entries, err := os.ReadDir(ks.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore directory: %w", err)
+	}
+
+	var keys []*Key
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), fileExt)
+		if e.IsDir() || !ok || !namePattern.MatchString(name) {
+			continue
+		}
+		key, err := ks.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	return keys, nil
+}
+
+// Decrypt returns the secret of the key.
+func (ks *Keystore) Decrypt(name string, passphrase []byte) (*Secret, error) {
+	f, err := ks.load(name)
+	if err != nil {
+		return nil, err
+	}
+	key, err := f.key()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(f.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce in key file: %w", err)
+	}
+	ciphertext, err := hex.DecodeString(f.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext in key file: %w", err)
+	}
+
+	aead, err := f.KDF.cipher(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size in key file: %d", len(nonce))
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, key.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWrongPassphrase, name)
+	}
+
+	var secret Secret
+	if err = json.Unmarshal(plaintext, &secret); err != nil {
+		return nil, fmt.Errorf("invalid secret in key file: %w", err)
+	}
+	return &secret, nil
+}
+
+func (ks *Keystore) path(name string) string {
+	return filepath.Join(ks.dir, name+fileExt)
+}
+
+func (ks *Keystore) load(name string) (*keyFile, error) {
+	if !namePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	data, err := os.ReadFile(ks.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	var f keyFile
+	if err = json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse key file %s: %w", name, err)
+	}
+	if f.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported key file version %d of %s", f.Version, name)
+	}
+	return &f, nil
+}
+
+func (f *keyFile) key() (*Key, error) {
+	publicKey, err := hex.DecodeString(f.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key in key file %s", f.Name)
+	}
+	return &Key{Name: f.Name, PublicKey: publicKey, CreatedAt: f.CreatedAt}, nil
+}
+
+// cipher derives the encryption key from the passphrase.
+func (p *kdfParams) cipher(passphrase []byte) (cipher.AEAD, error) {
+	if p.Name != "scrypt" {
+		return nil, fmt.Errorf("unsupported kdf %q", p.Name)
+	}
+	salt, err := hex.DecodeString(p.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kdf salt: %w", err)
+	}
+	key, err := scrypt.Key(passphrase, salt, p.N, p.R, p.P, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,95 @@
+package keystore_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/keystore"
+	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
+	"github.com/spf13/viper"
+)
+
+func TestKeystore(t *testing.T) {
+	ks := keystore.Open(t.TempDir())
+	secret := &keystore.Secret{Mnemonic: wallet.NewSeed()}
+	passphrase := []byte("correct horse")
+
+	key, err := ks.Add("relay", secret, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey, err := secret.PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key.PublicKey, privateKey.Public().(ed25519.PublicKey)) {
+		t.Fatal("public key does not match the mnemonic")
+	}
+
+	if _, err = ks.Add("relay", secret, passphrase); !errors.Is(err, keystore.ErrExists) {
+		t.Fatalf("expected ErrExists, got %v", err)
+	}
+	if _, err = ks.Add("../relay", secret, passphrase); !errors.Is(err, keystore.ErrInvalidName) {
+		t.Fatalf("expected ErrInvalidName, got %v", err)
+	}
+	if _, err = ks.Get("missing"); !errors.Is(err, keystore.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	keys, err := ks.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].Name != "relay" || !bytes.Equal(keys[0].PublicKey, key.PublicKey) {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+
+	if _, err = ks.Decrypt("relay", []byte("wrong")); !errors.Is(err, keystore.ErrWrongPassphrase) {
+		t.Fatalf("expected ErrWrongPassphrase, got %v", err)
+	}
+	decrypted, err := ks.Decrypt("relay", passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(decrypted.Mnemonic, secret.Mnemonic) {
+		t.Fatal("decrypted mnemonic does not match")
+	}
+}
+
+func TestListEmpty(t *testing.T) {
+	keys, err := keystore.Open(t.TempDir() + "/missing").List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("expected no keys, got %d", len(keys))
+	}
+}
+
+func TestReadPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passphrase")
+	if err := os.WriteFile(path, []byte("from file\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("wallet_passphrase", "from env")
+	viper.Set("wallet_passphrase_file", path)
+	defer func() {
+		viper.Set("wallet_passphrase", "")
+		viper.Set("wallet_passphrase_file", "")
+	}()
+
+	// the file takes precedence over the environment
+	passphrase, err := keystore.ReadPassphrase("Passphrase", true)
+	if err != nil || string(passphrase) != "from file" {
+		t.Fatalf("unexpected passphrase %q: %v", passphrase, err)
+	}
+	viper.Set("wallet_passphrase_file", "")
+	if passphrase, err = keystore.ReadPassphrase("Passphrase", true); err != nil || string(passphrase) != "from env" {
+		t.Fatalf("unexpected passphrase %q: %v", passphrase, err)
+	}
+}
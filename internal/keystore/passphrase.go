@@ -0,0 +1,59 @@
+package keystore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+var ErrNoPassphrase = errors.New("passphrase is not set")
+
+// ReadPassphrase reads a passphrase from the file of the wallet_passphrase_file config key,
+// the wallet_passphrase config key, usually set by the WALLET_PASSPHRASE environment variable,
+// or a terminal prompt. With confirm the prompt asks for the passphrase twice.
+func ReadPassphrase(prompt string, confirm bool) ([]byte, error) {
+	if path := viper.GetString("wallet_passphrase_file"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase file: %w", err)
+		}
+		return bytes.TrimRight(data, "\r\n"), nil
+	}
+	if passphrase := viper.GetString("wallet_passphrase"); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	passphrase, err := ReadSecret(prompt)
+	if err != nil {
+		return nil, err
+	}
+	if confirm {
+		again, err := ReadSecret("Repeat " + prompt)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(passphrase, again) {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+// ReadSecret reads a line from the terminal without echo. It fails if stdin is not a terminal.
+func ReadSecret(prompt string) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("%w: stdin is not a terminal, set wallet_passphrase_file or WALLET_PASSPHRASE", ErrNoPassphrase)
+	}
+	fmt.Fprintf(os.Stderr, "%s: ", prompt)
+	secret, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", prompt, err)
+	}
+	return secret, nil
+}
@@ -5,8 +5,8 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
 
+	"github.com/rsquad/trustless-bridge-cli/internal/msgtrace"
 	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
 	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
 	"github.com/spf13/viper"
@@ -23,6 +23,34 @@ const (
 	opCodeCheckBlockAnswer  = 0xce02b807
 )
 
+// BlockAnswer is the body of new_key_block_answer and check_block_answer:
+// op:uint32 query_id:uint64 block_hash:uint256.
+type BlockAnswer struct {
+	Op        uint32
+	QueryID   uint64
+	BlockHash []byte
+}
+
+func ParseBlockAnswer(body *cell.Cell) (*BlockAnswer, error) {
+	s := body.BeginParse()
+	op, err := s.LoadUInt(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load op: %w", err)
+	}
+	if op != opCodeNewKeyBlockAnswer && op != opCodeCheckBlockAnswer {
+		return nil, fmt.Errorf("unexpected op %08x", op)
+	}
+	queryID, err := s.LoadUInt(64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load query id: %w", err)
+	}
+	blockHash, err := s.LoadSlice(256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load block hash: %w", err)
+	}
+	return &BlockAnswer{Op: uint32(op), QueryID: queryID, BlockHash: blockHash}, nil
+}
+
 type LiteClientContract struct {
 	Addr      *address.Address
 	tonClient *tonclient.TonClient
@@ -60,8 +88,15 @@ func (c *LiteClientContract) SendNewKeyBlock(
 	blockProofCell *cell.Cell,
 	signaturesDict *cell.Dictionary,
 ) (*tlb.Transaction, *ton.BlockIDExt, error) {
-	w := c.tonClient.GetWallet()
+	return c.tonClient.SendWaitTransaction(ctx, c.NewKeyBlockMessage(fileHash, blockProofCell, signaturesDict))
+}
 
+// NewKeyBlockMessage returns the new_key_block message of the wallet.
+func (c *LiteClientContract) NewKeyBlockMessage(
+	fileHash []byte,
+	blockProofCell *cell.Cell,
+	signaturesDict *cell.Dictionary,
+) *wallet.Message {
 	payload := cell.BeginCell().
 		MustStoreUInt(opCodeNewKeyBlock, 32).
 		MustStoreUInt(0, 64).
@@ -72,9 +107,7 @@ func (c *LiteClientContract) SendNewKeyBlock(
 		MustStoreDict(signaturesDict).
 		EndCell()
 
-	message := wallet.SimpleMessage(c.Addr, tlb.MustFromTON("1"), payload)
-
-	return w.SendWaitTransaction(ctx, message)
+	return wallet.SimpleMessage(c.Addr, tlb.MustFromTON("1"), payload)
 }
 
 func (c *LiteClientContract) SendCheckBlock(
@@ -83,8 +116,6 @@ func (c *LiteClientContract) SendCheckBlock(
 	blockProofCell *cell.Cell,
 	signaturesDict *cell.Dictionary,
 ) (*tlb.Transaction, *ton.BlockIDExt, error) {
-	w := c.tonClient.GetWallet()
-
 	payload := cell.BeginCell().
 		MustStoreUInt(opCodeCheckBlock, 32).
 		MustStoreUInt(0, 64).
@@ -99,27 +130,48 @@ func (c *LiteClientContract) SendCheckBlock(
 
 	message := wallet.SimpleMessage(c.Addr, tlb.MustFromTON("0.2"), payload)
 
-	return w.SendWaitTransaction(ctx, message)
+	return c.tonClient.SendWaitTransaction(ctx, message)
 }
 
-func DeployLiteClient(ctx context.Context, tonClient *tonclient.TonClient, wc byte, initData *InitData) (*address.Address, error) {
-	wallet := tonClient.GetWallet()
+// NewKeyBlockVerdict follows the new_key_block message sent by the wallet transaction
+// and checks that the LiteClient answered with new_key_block_answer.
+func (c *LiteClientContract) NewKeyBlockVerdict(ctx context.Context, tx *tlb.Transaction) (*msgtrace.Verdict, error) {
+	return msgtrace.Follow(ctx, c.tonClient.API, tx, 1, opCodeNewKeyBlockAnswer)
+}
 
-	msgBody := cell.BeginCell().EndCell()
+// CheckBlockVerdict follows the check_block message sent by the wallet transaction
+// and checks that the LiteClient answered with check_block_answer.
+func (c *LiteClientContract) CheckBlockVerdict(ctx context.Context, tx *tlb.Transaction) (*msgtrace.Verdict, error) {
+	return msgtrace.Follow(ctx, c.tonClient.API, tx, 1, opCodeCheckBlockAnswer)
+}
 
+// LoadCode parses the code of the contract from the lite_client_code config key.
+func LoadCode() (*cell.Cell, error) {
 	codeHex := viper.GetString("lite_client_code")
+	if codeHex == "" {
+		return nil, fmt.Errorf("%w: lite_client_code is not set", tonclient.ErrContractConfig)
+	}
 	codeBytes, err := hex.DecodeString(codeHex)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode lite client code: %w", err)
+		return nil, fmt.Errorf("%w: failed to decode lite client code: %v", tonclient.ErrContractConfig, err)
 	}
 	codeCell, err := cell.FromBOC(codeBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse lite client code: %w", err)
+		return nil, fmt.Errorf("%w: failed to parse lite client code: %v", tonclient.ErrContractConfig, err)
 	}
+	return codeCell, nil
+}
 
-	addr, _, _, err := tonclient.DeployContractWaitTransaction(
-		context.Background(),
-		wallet,
+func DeployLiteClient(ctx context.Context, tonClient *tonclient.TonClient, wc byte, initData *InitData) (*address.Address, error) {
+	msgBody := cell.BeginCell().EndCell()
+
+	codeCell, err := LoadCode()
+	if err != nil {
+		return nil, err
+	}
+
+	addr, _, _, err := tonClient.DeployContractWaitTransaction(
+		ctx,
 		wc,
 		tlb.MustFromTON("0.2"),
 		msgBody,
@@ -145,7 +197,7 @@ func (c *LiteClientContract) GetStorage(
 
 	validatorDict := res.MustCell(0).AsDict(256)
 	validatorsTotalWeight := res.MustInt(1).Uint64()
-	epochHash := res.MustInt(2).Bytes()
+	epochHash := res.MustInt(2).FillBytes(make([]byte, 32))
 
 	return &InitData{
 		EpochHash:             epochHash,
@@ -175,10 +227,7 @@ func (c *LiteClientContract) GetValidators(
 func ValidatorDictToJSON(dict *cell.Dictionary) (string, error) {
 	data := make(map[string]string)
 
-	log.Printf("dict: %v", dict)
-
 	kvs, err := dict.LoadAll()
-	log.Printf("kvs: %v", kvs)
 	if err != nil {
 		return "", fmt.Errorf("failed to load dict kvs: %w", err)
 	}
@@ -0,0 +1,200 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The metrics of the bridge operations, exposed in the Prometheus text format by Handler.
+var (
+	EpochLag = NewGauge(
+		"bridge_epoch_lag_key_blocks",
+		"Key blocks of the source network that rotate validators and are not accepted by the LiteClient yet.",
+	)
+	LastKeyBlockSeqno = NewGauge(
+		"bridge_last_submitted_key_block_seqno",
+		"Seqno of the last key block sent to the LiteClient.",
+	)
+	ProofBuildSeconds = NewHistogram(
+		"bridge_proof_build_seconds",
+		"Time to fetch a key block with its signatures and build its proof.",
+		[]float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	)
+	LiteserverErrors = NewCounterVec(
+		"bridge_liteserver_errors_total",
+		"Failed liteserver queries by method.",
+		"method",
+	)
+	WalletBalance = NewGauge(
+		"bridge_wallet_balance_ton",
+		"Balance of the relayer wallet in TON.",
+	)
+)
+
+var (
+	registryMu sync.Mutex
+	registry   []metric
+)
+
+type metric interface {
+	write(w io.Writer) error
+}
+
+func register(m metric) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, m)
+}
+
+// WriteText writes all metrics in the Prometheus text format.
+// Gauges that were never set are skipped.
+func WriteText(w io.Writer) error {
+	registryMu.Lock()
+	metrics := append([]metric(nil), registry...)
+	registryMu.Unlock()
+
+	for _, m := range metrics {
+		if err := m.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Gauge struct {
+	name, help string
+
+	mu    sync.Mutex
+	value float64
+	set   bool
+}
+
+func NewGauge(name, help string) *Gauge {
+	g := &Gauge{name: name, help: help}
+	register(g)
+	return g
+}
+
+func (g *Gauge) Set(value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.value, g.set = value, true
+}
+
+// Value returns the value of the gauge and whether it was set.
+func (g *Gauge) Value() (float64, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.value, g.set
+}
+
+func (g *Gauge) write(w io.Writer) error {
+	value, set := g.Value()
+	if !set {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", g.name, g.help, g.name, g.name, formatFloat(value))
+	return err
+}
+
+// CounterVec is a set of counters partitioned by the value of one label.
+type CounterVec struct {
+	name, help, label string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func NewCounterVec(name, help, label string) *CounterVec {
+	c := &CounterVec{name: name, help: help, label: label, values: map[string]float64{}}
+	register(c)
+	return c
+}
+
+func (c *CounterVec) Inc(labelValue string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[labelValue]++
+}
+
+func (c *CounterVec) Value(labelValue string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[labelValue]
+}
+
+func (c *CounterVec) write(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name); err != nil {
+		return err
+	}
+	labelValues := make([]string, 0, len(c.values))
+	for v := range c.values {
+		labelValues = append(labelValues, v)
+	}
+	sort.Strings(labelValues)
+	for _, v := range labelValues {
+		if _, err := fmt.Fprintf(w, "%s{%s=%s} %s\n", c.name, c.label, strconv.Quote(v), formatFloat(c.values[v])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Histogram struct {
+	name, help string
+	buckets    []float64
+
+	mu     sync.Mutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram creates a histogram with the given upper bounds of the buckets in ascending order.
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	h := &Histogram{name: name, help: help, buckets: buckets, counts: make([]uint64, len(buckets))}
+	register(h)
+	return h
+}
+
+func (h *Histogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	for i, bound := range h.buckets {
+		fmt.Fprintf(&b, "%s_bucket{le=%q} %d\n", h.name, formatFloat(bound), h.counts[i])
+	}
+	fmt.Fprintf(&b, "%s_bucket{le=\"+Inf\"} %d\n", h.name, h.count)
+	fmt.Fprintf(&b, "%s_sum %s\n%s_count %d\n", h.name, formatFloat(h.sum), h.name, h.count)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,68 @@
+package metrics_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/metrics"
+)
+
+func TestWriteText(t *testing.T) {
+	gauge := metrics.NewGauge("test_gauge", "Test gauge.")
+	unset := metrics.NewGauge("test_unset_gauge", "Never set.")
+	counter := metrics.NewCounterVec("test_errors_total", "Test counter.", "method")
+	histogram := metrics.NewHistogram("test_seconds", "Test histogram.", []float64{1, 5})
+
+	gauge.Set(2.5)
+	counter.Inc("GetBlockData")
+	counter.Inc("GetBlockData")
+	counter.Inc("LookupBlock")
+	histogram.Observe(0.5)
+	histogram.Observe(3)
+
+	var buf bytes.Buffer
+	if err := metrics.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, line := range []string{
+		"# TYPE test_gauge gauge\ntest_gauge 2.5\n",
+		"# TYPE test_errors_total counter\n",
+		"test_errors_total{method=\"GetBlockData\"} 2\ntest_errors_total{method=\"LookupBlock\"} 1\n",
+		"# TYPE test_seconds histogram\n",
+		"test_seconds_bucket{le=\"1\"} 1\ntest_seconds_bucket{le=\"5\"} 2\ntest_seconds_bucket{le=\"+Inf\"} 2\n",
+		"test_seconds_sum 3.5\ntest_seconds_count 2\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Fatalf("output does not contain %q:\n%s", line, out)
+		}
+	}
+	if _, set := unset.Value(); set || strings.Contains(out, "test_unset_gauge") {
+		t.Fatalf("unset gauge is written:\n%s", out)
+	}
+}
+
+func TestEpochLagHealth(t *testing.T) {
+	handler := metrics.HealthHandler(metrics.EpochLagCheck(1))
+	status := func() int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		return rec.Code
+	}
+
+	if code := status(); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 before the lag is measured, got %d", code)
+	}
+	metrics.EpochLag.Set(1)
+	if code := status(); code != http.StatusOK {
+		t.Fatalf("expected 200 for lag 1, got %d", code)
+	}
+	metrics.EpochLag.Set(2)
+	if code := status(); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 for lag 2, got %d", code)
+	}
+}
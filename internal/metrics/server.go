@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if err := WriteText(w); err != nil {
+			log.Printf("failed to write metrics: %v", err)
+		}
+	})
+}
+
+// HealthHandler responds with 200 if check returns nil and with 503 and the error otherwise.
+func HealthHandler(check func() error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := check(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+}
+
+// EpochLagCheck fails when the LiteClient is more than maxLag key blocks behind
+// the source network, or the lag was not measured yet.
+func EpochLagCheck(maxLag int) func() error {
+	return func() error {
+		lag, ok := EpochLag.Value()
+		if !ok {
+			return errors.New("epoch lag is not measured yet")
+		}
+		if lag > float64(maxLag) {
+			return fmt.Errorf("LiteClient is %d key blocks behind, more than %d", int(lag), maxLag)
+		}
+		return nil
+	}
+}
+
+// Serve starts an HTTP server with /metrics and /healthz on addr.
+// The server runs until it is closed.
+func Serve(addr string, health func() error) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	mux.Handle("/healthz", HealthHandler(health))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("metrics server stopped: %v", err)
+		}
+	}()
+	return server, nil
+}
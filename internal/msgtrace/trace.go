@@ -0,0 +1,169 @@
+package msgtrace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+var ErrRejected = errors.New("message was rejected")
+
+// pollInterval is how often the receiver of a message is looked up.
+var pollInterval = 3 * time.Second
+
+// Hop is an internal message of the chain and the transaction that processed it.
+type Hop struct {
+	Msg *tlb.InternalMessage
+	Tx  *tlb.Transaction
+}
+
+// Trace follows the internal messages sent by the transaction and returns
+// the transactions that processed them, breadth first, up to depth messages deep.
+// A message whose transaction did not appear before ctx is done ends the trace with an error,
+// and the hops found so far are returned with it.
+func Trace(ctx context.Context, api ton.APIClientWrapped, tx *tlb.Transaction, depth int) ([]*Hop, error) {
+	var hops []*Hop
+	queue := []*tlb.Transaction{tx}
+	for level := 0; level < depth && len(queue) > 0; level++ {
+		var next []*tlb.Transaction
+		for _, t := range queue {
+			msgs, err := OutInternal(t)
+			if err != nil {
+				return hops, err
+			}
+			for _, msg := range msgs {
+				received, err := FindReceiver(ctx, api, msg)
+				if err != nil {
+					return hops, err
+				}
+				hops = append(hops, &Hop{Msg: msg, Tx: received})
+				next = append(next, received)
+			}
+		}
+		queue = next
+	}
+	return hops, nil
+}
+
+// Sent returns the internal messages the account of the transaction sent to other accounts,
+// following the messages it sent to itself. Highload wallets send large batches this way.
+func Sent(ctx context.Context, api ton.APIClientWrapped, tx *tlb.Transaction) ([]*tlb.InternalMessage, error) {
+	var sent []*tlb.InternalMessage
+	queue := []*tlb.Transaction{tx}
+	for len(queue) > 0 {
+		msgs, err := OutInternal(queue[0])
+		if err != nil {
+			return nil, err
+		}
+		queue = queue[1:]
+
+		for _, msg := range msgs {
+			if !msg.DstAddr.Equals(msg.SrcAddr) {
+				sent = append(sent, msg)
+				continue
+			}
+			received, err := FindReceiver(ctx, api, msg)
+			if err != nil {
+				return sent, err
+			}
+			queue = append(queue, received)
+		}
+	}
+	return sent, nil
+}
+
+// OutInternal returns the internal messages sent by the transaction.
+func OutInternal(tx *tlb.Transaction) ([]*tlb.InternalMessage, error) {
+	if tx.IO.Out == nil {
+		return nil, nil
+	}
+	list, err := tx.IO.Out.ToSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load out messages: %w", err)
+	}
+
+	var msgs []*tlb.InternalMessage
+	for _, m := range list {
+		if m.MsgType == tlb.MsgTypeInternal {
+			msgs = append(msgs, m.AsInternal())
+		}
+	}
+	return msgs, nil
+}
+
+// FindReceiver waits for the transaction of the destination account
+// that processed the internal message.
+func FindReceiver(ctx context.Context, api ton.APIClientWrapped, msg *tlb.InternalMessage) (*tlb.Transaction, error) {
+	for {
+		tx, err := findReceiver(ctx, api, msg)
+		if err != nil {
+			return nil, err
+		}
+		if tx != nil {
+			return tx, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("transaction of %s with message lt %d was not found: %w", msg.DstAddr, msg.CreatedLT, ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func findReceiver(ctx context.Context, api ton.APIClientWrapped, msg *tlb.InternalMessage) (*tlb.Transaction, error) {
+	block, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	acc, err := api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, msg.DstAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account %s: %w", msg.DstAddr, err)
+	}
+
+	// the receiving transaction always has a greater lt than the message
+	lt, hash := acc.LastTxLT, acc.LastTxHash
+	for lt > msg.CreatedLT {
+		txs, err := api.ListTransactions(ctx, msg.DstAddr, 10, lt, hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list transactions of %s: %w", msg.DstAddr, err)
+		}
+		if len(txs) == 0 {
+			return nil, nil
+		}
+		for _, tx := range txs {
+			if tx.IO.In == nil || tx.IO.In.MsgType != tlb.MsgTypeInternal {
+				continue
+			}
+			in := tx.IO.In.AsInternal()
+			if in.CreatedLT == msg.CreatedLT && in.SrcAddr.Equals(msg.SrcAddr) {
+				return tx, nil
+			}
+		}
+		lt, hash = txs[0].PrevTxLT, txs[0].PrevTxHash
+	}
+	return nil, nil
+}
+
+// ExitCode returns the exit code of the compute phase and whether the transaction
+// was processed successfully, including its action phase.
+func ExitCode(tx *tlb.Transaction) (int32, bool) {
+	desc, ok := tx.Description.(tlb.TransactionDescriptionOrdinary)
+	if !ok {
+		return 0, false
+	}
+
+	vm, ok := desc.ComputePhase.Phase.(tlb.ComputePhaseVM)
+	if !ok {
+		// compute phase was skipped, e.g. because there is no state
+		return 0, false
+	}
+	if desc.ActionPhase != nil && !desc.ActionPhase.Success {
+		return desc.ActionPhase.ResultCode, false
+	}
+	return vm.Details.ExitCode, vm.Success && !desc.Aborted
+}
@@ -0,0 +1,108 @@
+package msgtrace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+// Verdict is the outcome of a message chain started by a wallet transaction.
+type Verdict struct {
+	Accepted bool
+	// Bounced is set if the chain was rejected by a bounced message.
+	Bounced bool
+	Reason  string
+	// Answer is the message with the expected op, set if the chain was accepted.
+	Answer *tlb.InternalMessage
+	Hops   []*Hop
+
+	// decided is set when a hop accepted or rejected the chain,
+	// so the messages after it do not matter.
+	decided bool
+}
+
+// Follow traces the chain of the wallet transaction depth messages deep and judges it.
+// If a message of the chain is not processed in time, the verdict is still returned
+// when the hops before it have decided it.
+func Follow(ctx context.Context, api ton.APIClientWrapped, tx *tlb.Transaction, depth int, answerOp uint32) (*Verdict, error) {
+	hops, traceErr := Trace(ctx, api, tx, depth)
+	return judgeTrace(hops, traceErr, answerOp)
+}
+
+// FollowMessage is Follow for the chain started by one internal message,
+// such as a message of a batch sent by a highload wallet.
+func FollowMessage(ctx context.Context, api ton.APIClientWrapped, msg *tlb.InternalMessage, depth int, answerOp uint32) (*Verdict, error) {
+	received, err := FindReceiver(ctx, api, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to trace messages: %w", err)
+	}
+	hops, traceErr := Trace(ctx, api, received, depth-1)
+	return judgeTrace(append([]*Hop{{Msg: msg, Tx: received}}, hops...), traceErr, answerOp)
+}
+
+func judgeTrace(hops []*Hop, traceErr error, answerOp uint32) (*Verdict, error) {
+	v, err := Judge(hops, answerOp)
+	if err != nil {
+		return nil, err
+	}
+	if traceErr != nil && !v.decided {
+		return nil, fmt.Errorf("failed to trace messages: %w", traceErr)
+	}
+	return v, nil
+}
+
+// Judge looks through the hops in the chain order. The chain is rejected at the first
+// bounced message or failed transaction, and accepted at the first transaction
+// that sends a message with answerOp.
+func Judge(hops []*Hop, answerOp uint32) (*Verdict, error) {
+	v := &Verdict{Hops: hops, decided: true}
+	for _, hop := range hops {
+		if hop.Msg.Bounced {
+			v.Bounced = true
+			v.Reason = fmt.Sprintf("message from %s bounced", hop.Msg.SrcAddr)
+			return v, nil
+		}
+		if code, ok := ExitCode(hop.Tx); !ok {
+			v.Reason = fmt.Sprintf("%s rejected the message with exit code %d", hop.Msg.DstAddr, code)
+			return v, nil
+		}
+
+		msgs, err := OutInternal(hop.Tx)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range msgs {
+			if op, ok := Op(msg); ok && op == answerOp {
+				v.Accepted = true
+				v.Answer = msg
+				return v, nil
+			}
+		}
+	}
+
+	v.decided = false
+	v.Reason = fmt.Sprintf("no message with op %08x in the chain", answerOp)
+	return v, nil
+}
+
+// Err returns nil for accepted chains, and ErrRejected with the reason otherwise.
+func (v *Verdict) Err() error {
+	if v.Accepted {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrRejected, v.Reason)
+}
+
+// Op returns the op code of the message body, if the body has one.
+func Op(msg *tlb.InternalMessage) (uint32, bool) {
+	if msg.Body == nil {
+		return 0, false
+	}
+	op, err := msg.Body.BeginParse().LoadUInt(32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(op), true
+}
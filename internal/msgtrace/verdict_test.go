@@ -0,0 +1,102 @@
+package msgtrace_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/msgtrace"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const answerOp = 0xce02b807
+
+var (
+	walletAddr     = address.MustParseAddr("EQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAM9c")
+	liteClientAddr = address.MustParseRawAddr("0:1111111111111111111111111111111111111111111111111111111111111111")
+)
+
+func prepareMessage(src, dst *address.Address, op uint32, bounced bool) *tlb.InternalMessage {
+	return &tlb.InternalMessage{
+		IHRDisabled: true,
+		Bounced:     bounced,
+		SrcAddr:     src,
+		DstAddr:     dst,
+		Amount:      tlb.MustFromTON("0.1"),
+		Body:        cell.BeginCell().MustStoreUInt(uint64(op), 32).MustStoreUInt(0, 64).EndCell(),
+	}
+}
+
+func prepareTx(success bool, exitCode int32, out ...*tlb.InternalMessage) *tlb.Transaction {
+	vm := tlb.ComputePhaseVM{Success: success}
+	vm.Details.ExitCode = exitCode
+
+	tx := &tlb.Transaction{
+		Description: tlb.TransactionDescriptionOrdinary{
+			ComputePhase: tlb.ComputePhase{Phase: vm},
+			ActionPhase:  &tlb.ActionPhase{Success: true},
+		},
+	}
+	if len(out) > 0 {
+		list := cell.NewDict(15)
+		for i, msg := range out {
+			msgCell, err := tlb.ToCell(msg)
+			if err != nil {
+				panic(err)
+			}
+			list.SetIntKey(
+				big.NewInt(int64(i)),
+				cell.BeginCell().MustStoreRef(msgCell).EndCell(),
+			)
+		}
+		tx.IO.Out = &tlb.MessagesList{List: list}
+	}
+	return tx
+}
+
+func TestJudgeAccepted(t *testing.T) {
+	hops := []*msgtrace.Hop{{
+		Msg: prepareMessage(walletAddr, liteClientAddr, 0x8eaa9d76, false),
+		Tx:  prepareTx(true, 0, prepareMessage(liteClientAddr, walletAddr, answerOp, false)),
+	}}
+
+	v, err := msgtrace.Judge(hops, answerOp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !v.Accepted || v.Answer == nil || v.Err() != nil {
+		t.Fatalf("expected accepted verdict, got %+v", v)
+	}
+}
+
+func TestJudgeRejected(t *testing.T) {
+	hops := []*msgtrace.Hop{{
+		Msg: prepareMessage(walletAddr, liteClientAddr, 0x8eaa9d76, false),
+		Tx:  prepareTx(false, 1001, prepareMessage(liteClientAddr, walletAddr, 0xffffffff, true)),
+	}}
+
+	v, err := msgtrace.Judge(hops, answerOp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Accepted || !errors.Is(v.Err(), msgtrace.ErrRejected) {
+		t.Fatalf("expected rejected verdict, got %+v", v)
+	}
+}
+
+func TestJudgeBounced(t *testing.T) {
+	hops := []*msgtrace.Hop{{
+		Msg: prepareMessage(liteClientAddr, walletAddr, 0xffffffff, true),
+		Tx:  prepareTx(true, 0),
+	}}
+
+	v, err := msgtrace.Judge(hops, answerOp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Accepted || !v.Bounced {
+		t.Fatalf("expected bounced verdict, got %+v", v)
+	}
+}
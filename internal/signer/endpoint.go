@@ -0,0 +1,30 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidEndpoint = errors.New("invalid signer endpoint")
+
+// parseEndpoint splits an endpoint of "unix:///path/to/socket", "http://host:port"
+// or "host:port" into the network and the address to listen on or dial.
+func parseEndpoint(endpoint string) (network, addr string, err error) {
+	switch {
+	case strings.HasPrefix(endpoint, "unix://"):
+		network, addr = "unix", strings.TrimPrefix(endpoint, "unix://")
+	case strings.HasPrefix(endpoint, "unix:"):
+		network, addr = "unix", strings.TrimPrefix(endpoint, "unix:")
+	case strings.HasPrefix(endpoint, "http://"):
+		network, addr = "tcp", strings.TrimSuffix(strings.TrimPrefix(endpoint, "http://"), "/")
+	case strings.Contains(endpoint, "://"):
+		return "", "", fmt.Errorf("%w: %q, use unix:// or http://", ErrInvalidEndpoint, endpoint)
+	default:
+		network, addr = "tcp", endpoint
+	}
+	if addr == "" {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidEndpoint, endpoint)
+	}
+	return network, addr, nil
+}
@@ -0,0 +1,114 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+var ErrUnavailable = errors.New("signer is unavailable")
+
+const requestTimeout = 30 * time.Second
+
+// Remote is a wallet.Signer that asks a signing daemon for signatures,
+// so that the private key never enters this process.
+type Remote struct {
+	client    *http.Client
+	url       string
+	token     string
+	publicKey ed25519.PublicKey
+}
+
+// Dial connects to the signing daemon at the endpoint and fetches its public key.
+func Dial(ctx context.Context, endpoint, token string) (*Remote, error) {
+	network, addr, err := parseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Remote{
+		client: &http.Client{Timeout: requestTimeout},
+		url:    "http://" + addr,
+		token:  token,
+	}
+	if network == "unix" {
+		// the host of the url is ignored, every request goes to the socket
+		r.url = "http://signer"
+		r.client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", addr)
+			},
+		}
+	}
+
+	var resp publicKeyResponse
+	if err = r.do(ctx, http.MethodGet, "/public-key", nil, &resp); err != nil {
+		return nil, err
+	}
+	publicKey, err := hex.DecodeString(resp.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("signer returned an invalid public key %q", resp.PublicKey)
+	}
+	r.publicKey = publicKey
+	return r, nil
+}
+
+func (r *Remote) PublicKey() ed25519.PublicKey {
+	return r.publicKey
+}
+
+func (r *Remote) Sign(ctx context.Context, hash []byte) ([]byte, error) {
+	req := signRequest{PublicKey: hex.EncodeToString(r.publicKey), Hash: hex.EncodeToString(hash)}
+	var resp signResponse
+	if err := r.do(ctx, http.MethodPost, "/sign", req, &resp); err != nil {
+		return nil, err
+	}
+	signature, err := hex.DecodeString(resp.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("signer returned an invalid signature: %w", err)
+	}
+	return signature, nil
+}
+
+func (r *Remote) do(ctx context.Context, method, path string, body, result any) error {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.url+path, &reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+		if json.NewDecoder(resp.Body).Decode(&e) != nil || e.Error == "" {
+			e.Error = resp.Status
+		}
+		return fmt.Errorf("signer refused %s: %s", path, e.Error)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to parse signer response: %w", err)
+	}
+	return nil
+}
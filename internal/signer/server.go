@@ -0,0 +1,133 @@
+package signer
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
+)
+
+// The signing protocol: GET /public-key returns the public key of the signer,
+// POST /sign signs a 32 byte message hash. Keys and signatures are hex encoded.
+type publicKeyResponse struct {
+	PublicKey string `json:"public_key"`
+}
+
+type signRequest struct {
+	PublicKey string `json:"public_key"`
+	Hash      string `json:"hash"`
+}
+
+type signResponse struct {
+	Signature string `json:"signature"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler serves the signing protocol with the signer. If token is not empty,
+// requests must carry it as a bearer token.
+func Handler(s wallet.Signer, token string) http.Handler {
+	publicKey := hex.EncodeToString(s.PublicKey())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /public-key", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, publicKeyResponse{PublicKey: publicKey})
+	})
+	mux.HandleFunc("POST /sign", func(w http.ResponseWriter, r *http.Request) {
+		var req signRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+			return
+		}
+		if req.PublicKey != publicKey {
+			writeError(w, http.StatusBadRequest, errors.New("unknown public key"))
+			return
+		}
+		hash, err := hex.DecodeString(req.Hash)
+		if err != nil || len(hash) != 32 {
+			writeError(w, http.StatusBadRequest, errors.New("hash must be 32 bytes in hexadecimal format"))
+			return
+		}
+
+		signature, err := s.Sign(r.Context(), hash)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		log.Printf("Signed message hash %x", hash)
+		writeJSON(w, http.StatusOK, signResponse{Signature: hex.EncodeToString(signature)})
+	})
+
+	if token == "" {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid token"))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// Listen listens on the endpoint. A unix socket is created with mode 0600,
+// so that only the owner can connect to it, its directory is created if needed.
+func Listen(endpoint string) (net.Listener, error) {
+	network, addr, err := parseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if network == "unix" {
+		if err = os.MkdirAll(filepath.Dir(addr), 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create socket directory: %w", err)
+		}
+		removeStaleSocket(addr)
+	}
+	listener, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", endpoint, err)
+	}
+	if network == "unix" {
+		if err = os.Chmod(addr, 0o600); err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("failed to set permissions of %s: %w", addr, err)
+		}
+	}
+	return listener, nil
+}
+
+// removeStaleSocket removes the socket left by a daemon that did not stop cleanly.
+// A socket that still accepts connections is kept, so listening on it fails.
+func removeStaleSocket(path string) {
+	info, err := os.Stat(path)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return
+	}
+	os.Remove(path)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
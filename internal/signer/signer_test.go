@@ -0,0 +1,88 @@
+package signer_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/signer"
+	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
+)
+
+// serve starts a signing daemon with a new key on a unix socket and returns its endpoint.
+func serve(t *testing.T, token string) (string, ed25519.PrivateKey) {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := "unix://" + filepath.Join(t.TempDir(), "signer.sock")
+	listener, err := signer.Listen(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: signer.Handler(wallet.NewKeySigner(privateKey), token)}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return endpoint, privateKey
+}
+
+func TestRemoteSign(t *testing.T) {
+	endpoint, privateKey := serve(t, "secret")
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	ctx := context.Background()
+
+	remote, err := signer.Dial(ctx, endpoint, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !publicKey.Equal(remote.PublicKey()) {
+		t.Fatal("unexpected public key")
+	}
+
+	hash := make([]byte, 32)
+	signature, err := remote.Sign(ctx, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(publicKey, hash, signature) {
+		t.Fatal("invalid signature")
+	}
+
+	if _, err = remote.Sign(ctx, hash[:16]); err == nil {
+		t.Fatal("expected an error for a short hash")
+	}
+}
+
+func TestRemoteWrongToken(t *testing.T) {
+	endpoint, _ := serve(t, "secret")
+	_, err := signer.Dial(context.Background(), endpoint, "wrong")
+	if err == nil || !strings.Contains(err.Error(), "invalid token") {
+		t.Fatalf("expected invalid token, got %v", err)
+	}
+}
+
+func TestRemoteWalletAddress(t *testing.T) {
+	endpoint, privateKey := serve(t, "")
+	remote, err := signer.Dial(context.Background(), endpoint, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local, err := wallet.FromPrivateKey(nil, privateKey, wallet.V4R2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := wallet.FromSigner(nil, remote, wallet.V4R2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.WalletAddress().Equals(local.WalletAddress()) {
+		t.Fatalf("expected address %s, got %s", local.WalletAddress(), w.WalletAddress())
+	}
+	if w.PrivateKey() != nil {
+		t.Fatal("remote wallet must not hold the private key")
+	}
+}
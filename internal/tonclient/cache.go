@@ -0,0 +1,104 @@
+package tonclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blockcache"
+	"github.com/spf13/viper"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// ErrOffline is returned for liteserver requests in offline mode,
+// where only the data in the cache is available.
+var ErrOffline = errors.New("liteserver request in offline mode")
+
+// openCache opens the cache of the network in <cache_dir>/<network>.
+// It returns nil if cache_dir is empty.
+func openCache(network string) (*blockcache.Cache, error) {
+	dir := viper.GetString("cache_dir")
+	if dir == "" {
+		return nil, nil
+	}
+	cache, err := blockcache.Open(filepath.Join(dir, network), viper.GetInt64("cache_max_size"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache: %w", err)
+	}
+	return cache, nil
+}
+
+// Cache returns the cache of the client, nil if caching is disabled.
+func (tc *TonClient) Cache() *blockcache.Cache {
+	return tc.cache
+}
+
+// LookupBlock looks up a block by seqno. Found ids are kept in the cache.
+func (tc *TonClient) LookupBlock(ctx context.Context, workchain int32, shard int64, seqno uint32) (*ton.BlockIDExt, error) {
+	if tc.cache != nil {
+		if id, err := tc.cache.BlockID(workchain, shard, seqno); err == nil {
+			return id, nil
+		}
+	}
+
+	id, err := tc.API.LookupBlock(ctx, workchain, shard, seqno)
+	if err != nil {
+		return nil, err
+	}
+	if tc.cache != nil {
+		logCacheError("block id", tc.cache.PutBlockID(workchain, shard, seqno, id))
+	}
+	return id, nil
+}
+
+// GetBlockData returns the parsed block. Unlike API.GetBlockData, the block BOC
+// is taken from the cache if possible.
+func (tc *TonClient) GetBlockData(ctx context.Context, block *ton.BlockIDExt) (*tlb.Block, error) {
+	boc, err := tc.GetBlockBOC(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	root, err := cell.FromBOC(boc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse block BOC: %w", err)
+	}
+	var data tlb.Block
+	if err = tlb.LoadFromCell(&data, root.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse block: %w", err)
+	}
+	return &data, nil
+}
+
+func logCacheError(what string, err error) {
+	if err != nil {
+		log.Printf("failed to cache %s: %v", what, err)
+	}
+}
+
+// offlineLiteClient fails every request, so that only cached data is served.
+type offlineLiteClient struct{}
+
+func (offlineLiteClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	return ErrOffline
+}
+
+func (offlineLiteClient) StickyContext(ctx context.Context) context.Context {
+	return ctx
+}
+
+func (offlineLiteClient) StickyContextNextNode(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (offlineLiteClient) StickyContextNextNodeBalanced(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (offlineLiteClient) StickyNodeID(ctx context.Context) uint32 {
+	return 0
+}
@@ -0,0 +1,63 @@
+package tonclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// defaultHighloadTTL is how long an external message of a highload v3 wallet is valid, in seconds.
+	defaultHighloadTTL = 180
+	// highloadClockSkew is subtracted from created_at, so that the message is not
+	// rejected by validators whose clock is behind.
+	highloadClockSkew = 10 * time.Second
+	// highloadQueryIDTick is the time between query ids. The 23-bit ids wrap around
+	// in about 23 hours, much later than the wallet forgets processed ids.
+	highloadQueryIDTick = 10 * time.Millisecond
+)
+
+// highloadTTL returns the wallet_highload_ttl config key or the default.
+func highloadTTL() uint32 {
+	if ttl := viper.GetUint32("wallet_highload_ttl"); ttl != 0 {
+		return ttl
+	}
+	return defaultHighloadTTL
+}
+
+// MessageTTL returns how long an external message of the wallet stays valid: the 3 minutes
+// of regular wallets, or the TTL of a highload v3 wallet if it is longer.
+func MessageTTL() time.Duration {
+	return time.Duration(max(180, highloadTTL())) * time.Second
+}
+
+// queryIDs issues query ids of a highload v3 wallet. An id is the current time in ticks,
+// so ids are not reused by later runs, and it is increased when several messages
+// are built within a tick.
+type queryIDs struct {
+	mu   sync.Mutex
+	last uint32
+	now  func() time.Time
+}
+
+var highloadQueryIDs = &queryIDs{now: time.Now}
+
+// next returns the query id and created_at of a new external message.
+func (q *queryIDs) next(ctx context.Context, subwallet uint32) (uint32, int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	id := uint32(now.UnixNano()/int64(highloadQueryIDTick)) % (1 << 23)
+	if id <= q.last && q.last-id < 1<<22 {
+		id = (q.last + 1) % (1 << 23)
+	}
+	// bit number 1023 of a shift is not accepted by the wallet
+	if id&1023 == 1023 {
+		id = (id + 1) % (1 << 23)
+	}
+	q.last = id
+	return id, now.Add(-highloadClockSkew).Unix(), nil
+}
@@ -0,0 +1,101 @@
+package tonclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/keystore"
+	"github.com/rsquad/trustless-bridge-cli/internal/signer"
+	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
+	"github.com/spf13/viper"
+)
+
+const signerDialTimeout = 10 * time.Second
+
+var (
+	unlockedMu sync.Mutex
+	// unlocked keeps the keys decrypted from the keystore, so that
+	// the passphrase is asked once per process.
+	unlocked = map[string]ed25519.PrivateKey{}
+)
+
+// walletSigner returns the signer of the wallet: the signing daemon at wallet_signer,
+// or a key in memory, see WalletKey.
+func walletSigner() (wallet.Signer, error) {
+	endpoint := viper.GetString("wallet_signer")
+	if endpoint == "" {
+		key, err := WalletKey()
+		if err != nil {
+			return nil, err
+		}
+		return wallet.NewKeySigner(key), nil
+	}
+	if viper.GetString("wallet_name") != "" || viper.GetString("wallet_mnemonic") != "" {
+		return nil, fmt.Errorf("%w: wallet_signer is set together with wallet_name or wallet_mnemonic", ErrWalletConfig)
+	}
+
+	var token string
+	if path := viper.GetString("wallet_signer_token_file"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signer token file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), signerDialTimeout)
+	defer cancel()
+	return signer.Dial(ctx, endpoint, token)
+}
+
+// WalletKey returns the private key of the wallet: the key named wallet_name
+// in the keystore in keystore_dir, or the key of the plaintext wallet_mnemonic.
+func WalletKey() (ed25519.PrivateKey, error) {
+	name := viper.GetString("wallet_name")
+	mnemonic := viper.GetString("wallet_mnemonic")
+	switch {
+	case name != "" && mnemonic != "":
+		return nil, fmt.Errorf("%w: both wallet_name and wallet_mnemonic are set", ErrWalletConfig)
+	case name == "" && mnemonic == "":
+		return nil, fmt.Errorf("%w: none of wallet_signer, wallet_name and wallet_mnemonic is set", ErrWalletConfig)
+	case mnemonic != "":
+		key, err := wallet.SeedToPrivateKey(strings.Split(mnemonic, " "), "")
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrWalletConfig, err)
+		}
+		return key, nil
+	}
+
+	unlockedMu.Lock()
+	defer unlockedMu.Unlock()
+	if key, ok := unlocked[name]; ok {
+		return key, nil
+	}
+
+	dir := viper.GetString("keystore_dir")
+	if dir == "" {
+		return nil, fmt.Errorf("%w: keystore_dir is not set", ErrWalletConfig)
+	}
+	ks := keystore.Open(dir)
+	if _, err := ks.Get(name); err != nil {
+		return nil, err
+	}
+	passphrase, err := keystore.ReadPassphrase(fmt.Sprintf("Passphrase of key %s", name), false)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := ks.Decrypt(name, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	key, err := secret.PrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic of key %s: %w", name, err)
+	}
+	unlocked[name] = key
+	return key, nil
+}
@@ -0,0 +1,54 @@
+package tonclient_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/keystore"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
+	"github.com/spf13/viper"
+)
+
+func TestWalletKey(t *testing.T) {
+	dir := t.TempDir()
+	seed := wallet.NewSeed()
+	if _, err := keystore.Open(dir).Add("relayer", &keystore.Secret{Mnemonic: seed}, []byte("correct horse")); err != nil {
+		t.Fatal(err)
+	}
+	want, err := wallet.SeedToPrivateKey(seed, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("keystore_dir", dir)
+	viper.Set("wallet_name", "relayer")
+	defer func() {
+		for _, key := range []string{"keystore_dir", "wallet_name", "wallet_mnemonic", "wallet_passphrase"} {
+			viper.Set(key, "")
+		}
+	}()
+
+	viper.Set("wallet_passphrase", "wrong")
+	if _, err = tonclient.WalletKey(); !errors.Is(err, keystore.ErrWrongPassphrase) {
+		t.Fatalf("expected ErrWrongPassphrase, got %v", err)
+	}
+	viper.Set("wallet_passphrase", "correct horse")
+	key, err := tonclient.WalletKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(key, want) {
+		t.Fatal("key does not match the mnemonic")
+	}
+
+	viper.Set("wallet_mnemonic", strings.Join(seed, " "))
+	if _, err = tonclient.WalletKey(); !errors.Is(err, tonclient.ErrWalletConfig) {
+		t.Fatalf("expected ErrWalletConfig with both wallet_name and wallet_mnemonic, got %v", err)
+	}
+	viper.Set("wallet_name", "")
+	if key, err = tonclient.WalletKey(); err != nil || !bytes.Equal(key, want) {
+		t.Fatalf("unexpected key of the mnemonic: %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package tonclient
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/metrics"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+// errorCounter is a ton.LiteClient that counts failed queries by method
+// in metrics.LiteserverErrors. Error responses of the liteserver are failures too.
+type errorCounter struct {
+	ton.LiteClient
+}
+
+func (c errorCounter) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	err := c.LiteClient.QueryLiteserver(ctx, payload, result)
+	if err == nil {
+		if _, ok := reflect.ValueOf(result).Elem().Interface().(ton.LSError); !ok {
+			return nil
+		}
+	}
+
+	method, _, decodeErr := decodeRequest(payload)
+	if decodeErr != nil {
+		method = "unknown"
+	}
+	metrics.LiteserverErrors.Inc(method)
+	return err
+}
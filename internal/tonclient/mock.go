@@ -0,0 +1,238 @@
+package tonclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+var ErrNoFixture = errors.New("no fixture for liteserver request")
+
+// Exchange is a single recorded liteserver request with its response.
+// Both are boxed TL objects in hex. An exchange without a request matches
+// any request of the method, which is used for requests that depend on time,
+// such as external messages signed by a wallet.
+type Exchange struct {
+	Method   string `json:"method"`
+	Request  string `json:"request,omitempty"`
+	Response string `json:"response"`
+}
+
+// Fixtures is the content of a fixture file of a single network.
+type Fixtures struct {
+	Exchanges []*Exchange `json:"exchanges"`
+}
+
+func LoadFixtures(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+
+	var f Fixtures
+	if err = json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse fixtures: %w", err)
+	}
+	return &f, nil
+}
+
+func (f *Fixtures) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal fixtures: %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Add appends an exchange that is served only for exactly the same request.
+func (f *Fixtures) Add(request, response tl.Serializable) error {
+	return f.add(request, response, true)
+}
+
+// AddMethod appends an exchange that is served for any request of the same method.
+func (f *Fixtures) AddMethod(request, response tl.Serializable) error {
+	return f.add(request, response, false)
+}
+
+func (f *Fixtures) add(request, response tl.Serializable, exact bool) error {
+	method, reqData, err := decodeRequest(request)
+	if err != nil {
+		return err
+	}
+	respData, err := tl.Serialize(response, true)
+	if err != nil {
+		return fmt.Errorf("failed to serialize response: %w", err)
+	}
+
+	e := &Exchange{Method: method, Response: hex.EncodeToString(respData)}
+	if exact {
+		e.Request = hex.EncodeToString(reqData)
+	}
+	f.Exchanges = append(f.Exchanges, e)
+	return nil
+}
+
+// MockLiteServer is an in-process ton.LiteClient that answers requests from fixtures
+// instead of the network. Exchanges matching the same request are served in the
+// order they were recorded, and the last one is repeated when they run out.
+type MockLiteServer struct {
+	fixtures *Fixtures
+	served   map[*Exchange]bool
+	mx       sync.Mutex
+}
+
+func NewMockLiteServer(fixtures *Fixtures) *MockLiteServer {
+	return &MockLiteServer{
+		fixtures: fixtures,
+		served:   map[*Exchange]bool{},
+	}
+}
+
+// NewTonClientFixtures returns a client whose requests are served from the fixtures,
+// as with fixtures_dir in NewTonClientNetwork.
+func NewTonClientFixtures(fixtures *Fixtures) *TonClient {
+	return newTonClientUnsafe(nil, NewMockLiteServer(fixtures))
+}
+
+func (m *MockLiteServer) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	method, reqData, err := decodeRequest(payload)
+	if err != nil {
+		return err
+	}
+	reqHex := hex.EncodeToString(reqData)
+
+	m.mx.Lock()
+	e := m.match(method, reqHex)
+	m.mx.Unlock()
+	if e == nil {
+		return fmt.Errorf("%w: %s %s", ErrNoFixture, method, reqHex)
+	}
+
+	respData, err := hex.DecodeString(e.Response)
+	if err != nil {
+		return fmt.Errorf("invalid fixture response of %s: %w", method, err)
+	}
+	var resp tl.Serializable
+	if _, err = tl.Parse(&resp, respData, true); err != nil {
+		return fmt.Errorf("failed to parse fixture response of %s: %w", method, err)
+	}
+	reflect.ValueOf(result).Elem().Set(reflect.ValueOf(resp))
+	return nil
+}
+
+func (m *MockLiteServer) match(method, reqHex string) *Exchange {
+	var last *Exchange
+	for _, exact := range []bool{true, false} {
+		for _, e := range m.fixtures.Exchanges {
+			if e.Method != method {
+				continue
+			}
+			if exact && e.Request != reqHex || !exact && e.Request != "" {
+				continue
+			}
+			if !m.served[e] {
+				m.served[e] = true
+				return e
+			}
+			last = e
+		}
+		if last != nil {
+			return last
+		}
+	}
+	return nil
+}
+
+func (m *MockLiteServer) StickyContext(ctx context.Context) context.Context {
+	return ctx
+}
+
+func (m *MockLiteServer) StickyContextNextNode(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *MockLiteServer) StickyContextNextNodeBalanced(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *MockLiteServer) StickyNodeID(ctx context.Context) uint32 {
+	return 0
+}
+
+// Recorder is a ton.LiteClient that passes requests to the underlying client
+// and writes every exchange to a fixture file, so it can be replayed by MockLiteServer.
+type Recorder struct {
+	ton.LiteClient
+	fixtures *Fixtures
+	path     string
+	mx       sync.Mutex
+}
+
+func NewRecorder(client ton.LiteClient, path string) *Recorder {
+	return &Recorder{
+		LiteClient: client,
+		fixtures:   &Fixtures{},
+		path:       path,
+	}
+}
+
+func (r *Recorder) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	if err := r.LiteClient.QueryLiteserver(ctx, payload, result); err != nil {
+		return err
+	}
+
+	resp, ok := reflect.ValueOf(result).Elem().Interface().(tl.Serializable)
+	if !ok {
+		return nil
+	}
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	method, _, err := decodeRequest(payload)
+	if err != nil {
+		return err
+	}
+	if err = r.fixtures.add(payload, resp, !volatileMethods[method]); err != nil {
+		return err
+	}
+	return r.fixtures.Save(r.path)
+}
+
+// volatileMethods are recorded without the request,
+// because their payload changes on every run.
+var volatileMethods = map[string]bool{
+	"SendMessage": true,
+}
+
+// waitMasterchainSeqnoID is the TL id of liteServer.waitMasterchainSeqno,
+// which is prepended to requests made through APIClient.WaitForBlock.
+var waitMasterchainSeqnoID = tl.CRC("liteServer.waitMasterchainSeqno seqno:int timeout_ms:int = Object")
+
+// decodeRequest returns the method name and the boxed request without
+// the waitMasterchainSeqno prefix, which contains a timeout and is not stable.
+func decodeRequest(payload tl.Serializable) (string, []byte, error) {
+	data, err := tl.Serialize(payload, true)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to serialize request: %w", err)
+	}
+	if len(data) >= 12 && bytes.Equal(data[:4], binary.LittleEndian.AppendUint32(nil, waitMasterchainSeqnoID)) {
+		data = data[12:]
+	}
+
+	var req tl.Serializable
+	if _, err = tl.Parse(&req, data, true); err != nil {
+		return "", nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+	return reflect.Indirect(reflect.ValueOf(req)).Type().Name(), data, nil
+}
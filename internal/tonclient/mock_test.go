@@ -0,0 +1,89 @@
+package tonclient_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+func prepareBlockID(seqno uint32) *ton.BlockIDExt {
+	return &ton.BlockIDExt{
+		Workchain: -1,
+		Shard:     -9223372036854775808,
+		SeqNo:     seqno,
+		RootHash:  make([]byte, 32),
+		FileHash:  make([]byte, 32),
+	}
+}
+
+func prepareMasterchainInfo(seqno uint32) ton.MasterchainInfo {
+	return ton.MasterchainInfo{
+		Last:          prepareBlockID(seqno),
+		StateRootHash: make([]byte, 32),
+		Init:          &ton.ZeroStateIDExt{Workchain: -1, RootHash: make([]byte, 32), FileHash: make([]byte, 32)},
+	}
+}
+
+func TestMockLiteServerOrder(t *testing.T) {
+	fixtures := &tonclient.Fixtures{}
+	for _, seqno := range []uint32{10, 11} {
+		if err := fixtures.Add(ton.GetMasterchainInf{}, prepareMasterchainInfo(seqno)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	api := ton.NewAPIClient(tonclient.NewMockLiteServer(fixtures), ton.ProofCheckPolicyUnsafe)
+
+	// the last exchange is repeated when all of them are served
+	for _, want := range []uint32{10, 11, 11} {
+		block, err := api.GetMasterchainInfo(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if block.SeqNo != want {
+			t.Fatalf("expected seqno %d, got %d", want, block.SeqNo)
+		}
+	}
+
+	if _, err := api.LookupBlock(context.Background(), -1, 0, 10); !errors.Is(err, tonclient.ErrNoFixture) {
+		t.Fatalf("expected ErrNoFixture, got %v", err)
+	}
+}
+
+func TestRecorderReplay(t *testing.T) {
+	source := &tonclient.Fixtures{}
+	err := source.Add(
+		ton.LookupBlock{Mode: 1, ID: &ton.BlockInfoShort{Workchain: -1, Shard: 0, Seqno: 5}},
+		ton.BlockHeader{ID: prepareBlockID(5), HeaderProof: []byte{}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "testnet.json")
+	recording := ton.NewAPIClient(tonclient.NewRecorder(tonclient.NewMockLiteServer(source), path), ton.ProofCheckPolicyUnsafe)
+	// requests made through WaitForBlock must be recorded without the wait prefix
+	if _, err = recording.WaitForBlock(5).LookupBlock(context.Background(), -1, 0, 5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	recorded, err := tonclient.LoadFixtures(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded.Exchanges) != 1 || recorded.Exchanges[0].Method != "LookupBlock" {
+		t.Fatalf("unexpected recorded exchanges: %+v", recorded.Exchanges)
+	}
+
+	replay := ton.NewAPIClient(tonclient.NewMockLiteServer(recorded), ton.ProofCheckPolicyUnsafe)
+	block, err := replay.LookupBlock(context.Background(), -1, 0, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if block.SeqNo != 5 {
+		t.Fatalf("expected seqno 5, got %d", block.SeqNo)
+	}
+}
@@ -0,0 +1,110 @@
+package tonclient
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/data"
+	"github.com/spf13/viper"
+	"github.com/xssnick/tonutils-go/liteclient"
+)
+
+// NetworkProfile describes how to connect to a network. Profiles are read from
+// the networks section of the config file; testnet and fastnet are built in.
+type NetworkProfile struct {
+	Name string `mapstructure:"-"`
+	// Config is a path or an URL of the global config of the network.
+	Config      string              `mapstructure:"config"`
+	Liteservers []LiteserverProfile `mapstructure:"liteservers"`
+	// Source is the network blocks are taken from when this network is the target.
+	Source string `mapstructure:"source"`
+
+	embeddedConfig string
+}
+
+type LiteserverProfile struct {
+	// IP is either a dotted address or an integer as in the global config.
+	IP   string `mapstructure:"ip"`
+	Port int    `mapstructure:"port"`
+	// Key is the base64 ed25519 public key of the liteserver.
+	Key string `mapstructure:"key"`
+}
+
+var builtinNetworks = map[string]NetworkProfile{
+	"testnet": {Source: "fastnet", embeddedConfig: data.TestnetConfig},
+	"fastnet": {Source: "testnet", embeddedConfig: data.FastnetConfig},
+}
+
+func LoadNetworkProfile(name string) (*NetworkProfile, error) {
+	profile, builtin := builtinNetworks[name]
+	key := "networks." + name
+	if !builtin && !viper.IsSet(key) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownNetwork, name)
+	}
+	if viper.IsSet(key) {
+		if err := viper.UnmarshalKey(key, &profile); err != nil {
+			return nil, fmt.Errorf("failed to parse network %s: %w", name, err)
+		}
+	}
+	profile.Name = name
+	return &profile, nil
+}
+
+// GlobalConfig returns the liteservers of the network. Inline liteservers take
+// priority over the config path, and both take priority over the built-in config.
+func (p *NetworkProfile) GlobalConfig(ctx context.Context) (*liteclient.GlobalConfig, error) {
+	switch {
+	case len(p.Liteservers) > 0:
+		cfg := &liteclient.GlobalConfig{}
+		for _, ls := range p.Liteservers {
+			ip, err := parseLiteserverIP(ls.IP)
+			if err != nil {
+				return nil, fmt.Errorf("network %s: %w", p.Name, err)
+			}
+			cfg.Liteservers = append(cfg.Liteservers, liteclient.LiteserverConfig{
+				IP:   ip,
+				Port: ls.Port,
+				ID:   liteclient.ServerID{Type: "pub.ed25519", Key: ls.Key},
+			})
+		}
+		return cfg, nil
+
+	case strings.HasPrefix(p.Config, "http://") || strings.HasPrefix(p.Config, "https://"):
+		cfg, err := liteclient.GetConfigFromUrl(ctx, p.Config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download config of network %s: %w", p.Name, err)
+		}
+		return cfg, nil
+
+	case p.Config != "":
+		cfg, err := liteclient.GetConfigFromFile(p.Config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config of network %s: %w", p.Name, err)
+		}
+		return cfg, nil
+
+	case p.embeddedConfig != "":
+		var cfg liteclient.GlobalConfig
+		if err := json.Unmarshal([]byte(p.embeddedConfig), &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config data: %w", err)
+		}
+		return &cfg, nil
+	}
+	return nil, fmt.Errorf("network %s has neither config nor liteservers", p.Name)
+}
+
+func parseLiteserverIP(s string) (int64, error) {
+	if ip := net.ParseIP(s).To4(); ip != nil {
+		return int64(int32(binary.BigEndian.Uint32(ip))), nil
+	}
+	ip, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid liteserver ip: %s", s)
+	}
+	return ip, nil
+}
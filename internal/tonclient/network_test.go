@@ -0,0 +1,65 @@
+package tonclient_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/spf13/viper"
+)
+
+func TestLoadNetworkProfile(t *testing.T) {
+	viper.Set("networks", map[string]any{
+		"private": map[string]any{
+			"source": "testnet",
+			"liteservers": []any{
+				map[string]any{"ip": "10.0.0.1", "port": 4924, "key": "sU7QavX2F964iI9oToP9gffQpCQIoOLppeqL/pdPvpM="},
+				map[string]any{"ip": -1468571697, "port": 27787, "key": "Y/QVf6G5VDiKTZOKitbFVm067WsuocTN8Vg036A4zGk="},
+			},
+		},
+	})
+	defer viper.Set("networks", nil)
+
+	profile, err := tonclient.LoadNetworkProfile("private")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if profile.Source != "testnet" {
+		t.Fatalf("unexpected source: %s", profile.Source)
+	}
+
+	cfg, err := profile.GlobalConfig(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Liteservers) != 2 {
+		t.Fatalf("expected 2 liteservers, got %d", len(cfg.Liteservers))
+	}
+	if cfg.Liteservers[0].IP != 0x0a000001 || cfg.Liteservers[0].Port != 4924 {
+		t.Fatalf("unexpected liteserver: %+v", cfg.Liteservers[0])
+	}
+	if cfg.Liteservers[1].IP != -1468571697 {
+		t.Fatalf("unexpected liteserver: %+v", cfg.Liteservers[1])
+	}
+}
+
+func TestLoadBuiltinNetworkProfile(t *testing.T) {
+	profile, err := tonclient.LoadNetworkProfile("fastnet")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if profile.Source != "testnet" {
+		t.Fatalf("unexpected source: %s", profile.Source)
+	}
+	cfg, err := profile.GlobalConfig(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Liteservers) == 0 {
+		t.Fatalf("built-in config has no liteservers")
+	}
+
+	if _, err = tonclient.LoadNetworkProfile("mainnet"); err == nil {
+		t.Fatalf("expected error for unknown network")
+	}
+}
@@ -0,0 +1,273 @@
+package tonclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/fees"
+	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// ErrDryRun is returned instead of sending a message in dry-run mode.
+var ErrDryRun = errors.New("dry run, the message is not sent")
+
+const (
+	// walletTxsLimit is the number of wallet transactions requested by one ListTransactions call.
+	walletTxsLimit = 16
+	// walletClockSkew is how much earlier than the local time of sending a wallet transaction
+	// may be timed, as the transactions are timed by the validators.
+	walletClockSkew = time.Minute
+)
+
+// SendOptions change how SendWaitTransaction sends messages of the wallet.
+type SendOptions struct {
+	// DryRun builds and reports the external message without sending it.
+	DryRun bool
+	// EstimateFees adds the fee estimate to the report.
+	EstimateFees bool
+	// Amount overrides the value attached to every internal message.
+	Amount *tlb.Coins
+	// Report is called with every external message before it is sent.
+	Report func(*PreparedMessage)
+}
+
+// PreparedMessage is an external message of the wallet with the internal messages it carries.
+type PreparedMessage struct {
+	Wallet   *address.Address
+	External *cell.Cell
+	Messages []*tlb.InternalMessage
+	// Estimate is set with the EstimateFees option.
+	Estimate *fees.Estimate
+}
+
+func (tc *TonClient) SetSendOptions(opts SendOptions) {
+	tc.sendOptions = opts
+}
+
+// SendWaitTransaction sends the message from the wallet and waits for the wallet transaction,
+// or returns ErrDryRun after reporting the message in dry-run mode.
+func (tc *TonClient) SendWaitTransaction(ctx context.Context, message *wallet.Message) (*tlb.Transaction, *ton.BlockIDExt, error) {
+	return tc.SendManyWaitTransaction(ctx, []*wallet.Message{message})
+}
+
+// SendManyWaitTransaction sends the messages in one external message of the wallet,
+// like SendWaitTransaction. Highload wallets may send them in later transactions.
+func (tc *TonClient) SendManyWaitTransaction(ctx context.Context, messages []*wallet.Message) (*tlb.Transaction, *ton.BlockIDExt, error) {
+	ext, err := tc.BuildExternal(ctx, messages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx, block, _, err := tc.API.SendExternalMessageWaitTransaction(ctx, ext)
+	return tx, block, err
+}
+
+// SendExternal sends the external message built by BuildExternal without waiting
+// for the wallet transaction. The transaction can be found by the hash of the message payload
+// with FindWalletTransaction.
+func (tc *TonClient) SendExternal(ctx context.Context, ext *tlb.ExternalMessage) error {
+	if err := tc.API.SendExternalMessage(ctx, ext); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
+
+// FindWalletTransaction returns the wallet transaction that processed the external message
+// with the payload hash, or ton.ErrTxWasNotFound if the wallet has not processed it yet.
+// The wallet history is searched back to the transactions made before the message was sent at since.
+func (tc *TonClient) FindWalletTransaction(ctx context.Context, inMsgHash []byte, since time.Time) (*tlb.Transaction, error) {
+	w, err := tc.GetWallet()
+	if err != nil {
+		return nil, err
+	}
+	master, err := tc.API.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	acc, err := tc.API.GetAccount(ctx, master, w.WalletAddress())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet account: %w", err)
+	}
+
+	oldest := since.Add(-walletClockSkew).Unix()
+	for lt, hash := acc.LastTxLT, acc.LastTxHash; lt != 0; {
+		txs, err := tc.API.ListTransactions(ctx, w.WalletAddress(), walletTxsLimit, lt, hash)
+		if errors.Is(err, ton.ErrNoTransactionsWereFound) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list wallet transactions: %w", err)
+		}
+
+		// transactions are ordered from the oldest one
+		for i := len(txs) - 1; i >= 0; i-- {
+			tx := txs[i]
+			in := tx.IO.In
+			if in != nil && in.MsgType == tlb.MsgTypeExternalIn && bytes.Equal(in.Msg.Payload().Hash(), inMsgHash) {
+				return tx, nil
+			}
+			if int64(tx.Now) < oldest {
+				return nil, ton.ErrTxWasNotFound
+			}
+		}
+		lt, hash = txs[0].PrevTxLT, txs[0].PrevTxHash
+	}
+	return nil, ton.ErrTxWasNotFound
+}
+
+// BuildExternal builds the external message of the wallet with the send options applied,
+// reports it and returns ErrDryRun in dry-run mode. The hash of its payload identifies
+// the message before it is sent, as wallet.SendManyGetInMsgHash returns it.
+func (tc *TonClient) BuildExternal(ctx context.Context, messages []*wallet.Message) (*tlb.ExternalMessage, error) {
+	w, err := tc.GetWallet()
+	if err != nil {
+		return nil, err
+	}
+	opts := tc.sendOptions
+	if opts.Amount != nil {
+		for _, message := range messages {
+			message.InternalMessage.Amount = *opts.Amount
+		}
+	}
+
+	ext, err := w.BuildExternalMessageForMany(ctx, messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build external message: %w", err)
+	}
+
+	if opts.Report != nil {
+		prepared, err := tc.prepareMessage(ctx, w, ext, messages, opts.EstimateFees)
+		if err != nil {
+			return nil, err
+		}
+		opts.Report(prepared)
+	}
+	if opts.DryRun {
+		return nil, ErrDryRun
+	}
+	return ext, nil
+}
+
+func (tc *TonClient) prepareMessage(
+	ctx context.Context,
+	w *wallet.Wallet,
+	ext *tlb.ExternalMessage,
+	messages []*wallet.Message,
+	estimateFees bool,
+) (*PreparedMessage, error) {
+	extCell, err := tlb.ToCell(ext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize external message: %w", err)
+	}
+	prepared := &PreparedMessage{Wallet: w.WalletAddress(), External: extCell}
+	for _, message := range messages {
+		prepared.Messages = append(prepared.Messages, message.InternalMessage)
+	}
+	if !estimateFees {
+		return prepared, nil
+	}
+
+	walletWc := w.WalletAddress().Workchain()
+	prices := map[int32]*fees.Prices{}
+	loadPrices := func(workchain int32) (*fees.Prices, error) {
+		if p, ok := prices[workchain]; ok {
+			return p, nil
+		}
+		p, err := fees.LoadPrices(ctx, tc.API, workchain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load prices: %w", err)
+		}
+		prices[workchain] = p
+		return p, nil
+	}
+	walletPrices, err := loadPrices(walletWc)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &fees.Estimate{
+		ImportFee:   walletPrices.Msg.ForwardFee(fees.MsgSize(extCell)),
+		WalletGas:   walletPrices.Gas.GasFee(fees.WalletGasUsed * uint64(len(messages))),
+		ForwardFee:  new(big.Int),
+		ContractGas: new(big.Int),
+	}
+	for _, msg := range prepared.Messages {
+		dstPrices, err := loadPrices(msg.DstAddr.Workchain())
+		if err != nil {
+			return nil, err
+		}
+		msgCell, err := tlb.ToCell(msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize internal message: %w", err)
+		}
+
+		// Messages to or from the masterchain are forwarded at masterchain prices.
+		fwdPrices := walletPrices.Msg
+		if msg.DstAddr.Workchain() == address.MasterchainID {
+			fwdPrices = dstPrices.Msg
+		}
+		estimate.ForwardFee.Add(estimate.ForwardFee, fwdPrices.ForwardFee(fees.MsgSize(msgCell)))
+
+		contractGas := dstPrices.Gas.MaxGasFee()
+		if value := msg.Amount.Nano(); value.Cmp(contractGas) < 0 {
+			contractGas = value
+		}
+		estimate.ContractGas.Add(estimate.ContractGas, contractGas)
+	}
+	prepared.Estimate = estimate
+	return prepared, nil
+}
+
+// DeployContractWaitTransaction sends the contract code and data with the message body
+// to the address computed from them. The address is returned in dry-run mode too.
+func (tc *TonClient) DeployContractWaitTransaction(
+	ctx context.Context,
+	wc byte,
+	amount tlb.Coins,
+	msgBody,
+	contractCode,
+	contractData *cell.Cell,
+) (*address.Address, *tlb.Transaction, *ton.BlockIDExt, error) {
+	state, addr, err := ContractStateInit(wc, contractCode, contractData)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	tx, block, err := tc.SendWaitTransaction(ctx, &wallet.Message{
+		Mode: wallet.PayGasSeparately + wallet.IgnoreErrors,
+		InternalMessage: &tlb.InternalMessage{
+			IHRDisabled: true,
+			Bounce:      false,
+			DstAddr:     addr,
+			Amount:      amount,
+			Body:        msgBody,
+			StateInit:   state,
+		},
+	})
+	if err != nil {
+		return addr, nil, nil, err
+	}
+	return addr, tx, block, nil
+}
+
+// ContractStateInit returns the StateInit of a contract and the address it is deployed to in the workchain.
+func ContractStateInit(wc byte, code, data *cell.Cell) (*tlb.StateInit, *address.Address, error) {
+	state := &tlb.StateInit{
+		Data: data,
+		Code: code,
+	}
+
+	stateCell, err := tlb.ToCell(state)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to serialize state init: %w", err)
+	}
+	return state, address.NewAddress(0, wc, stateCell.Hash()), nil
+}
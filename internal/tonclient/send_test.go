@@ -0,0 +1,125 @@
+package tonclient_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blocktest"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
+	"github.com/spf13/viper"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// prepareWalletHistory configures a v4r2 wallet and adds its state with n transactions
+// processing external messages, oldest first, listed by pages of 16. It returns the payloads.
+func prepareWalletHistory(t *testing.T, fixtures *tonclient.Fixtures, n int) []*cell.Cell {
+	seed := wallet.NewSeed()
+	key, err := wallet.SeedToPrivateKey(seed, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := wallet.AddressFromPubKey(key.Public().(ed25519.PublicKey), wallet.V4R2, wallet.DefaultSubwallet, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("wallet_version", "v4r2")
+	viper.Set("wallet_mnemonic", strings.Join(seed, " "))
+	t.Cleanup(func() {
+		viper.Set("wallet_version", "")
+		viper.Set("wallet_mnemonic", "")
+	})
+
+	var payloads, txs []*cell.Cell
+	for i := 0; i < n; i++ {
+		payload := cell.BeginCell().MustStoreUInt(uint64(i), 32).EndCell()
+		in, err := tlb.ToCell(&tlb.ExternalMessage{DstAddr: addr, Body: payload})
+		if err != nil {
+			t.Fatal(err)
+		}
+		lt := uint64(1000 + 10*i)
+		if i == 0 {
+			txs = append(txs, blocktest.Transaction(addr.Data(), lt, in))
+		} else {
+			txs = append(txs, blocktest.NextTransaction(txs[i-1], lt, in))
+		}
+		payloads = append(payloads, payload)
+	}
+
+	master := (&blocktest.Block{Workchain: -1, Seqno: 200}).ID()
+	err = fixtures.Add(ton.GetMasterchainInf{}, ton.MasterchainInfo{
+		Last:          master,
+		StateRootHash: make([]byte, 32),
+		Init:          &ton.ZeroStateIDExt{Workchain: -1, RootHash: make([]byte, 32), FileHash: make([]byte, 32)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := txs[n-1]
+	account := blocktest.Account(addr, 1, nil, nil)
+	accounts := cell.NewDict(256)
+	err = accounts.Set(
+		cell.BeginCell().MustStoreSlice(addr.Data(), 256).EndCell(),
+		blocktest.ShardAccount(account, 1, uint64(1000+10*(n-1)), last.Hash()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateProof := cell.BeginCell().MustStoreRef(blocktest.ShardState(0, 200, accounts)).EndCell()
+	err = fixtures.Add(
+		ton.GetAccountState{ID: master, Account: ton.AccountID{Workchain: 0, ID: addr.Data()}},
+		ton.AccountState{ID: master, Shard: master, Proof: []*cell.Cell{cell.BeginCell().EndCell(), stateProof}, State: account},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for end := n; end > 0; end -= 16 {
+		var page []*cell.Cell
+		for i := end - 1; i >= max(end-16, 0); i-- {
+			page = append(page, txs[i])
+		}
+		err = fixtures.Add(
+			ton.GetTransactions{
+				Limit:  16,
+				AccID:  &ton.AccountID{Workchain: 0, ID: addr.Data()},
+				LT:     int64(1000 + 10*(end-1)),
+				TxHash: txs[end-1].Hash(),
+			},
+			ton.TransactionList{IDs: []*ton.BlockIDExt{}, Transactions: cell.ToBOCWithFlags(page, false)},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return payloads
+}
+
+func TestFindWalletTransaction(t *testing.T) {
+	fixtures := &tonclient.Fixtures{}
+	payloads := prepareWalletHistory(t, fixtures, 40)
+	client := tonclient.NewTonClientFixtures(fixtures)
+	// the transactions of blocktest are made at 1700000000
+	sentAt := time.Unix(1700000000, 0)
+
+	// the message is processed 39 transactions ago, in the third page
+	tx, err := client.FindWalletTransaction(context.Background(), payloads[0].Hash(), sentAt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx.LT != 1000 {
+		t.Fatalf("unexpected transaction %d", tx.LT)
+	}
+
+	// the transactions are older than the message, so the search stops at the first one
+	_, err = client.FindWalletTransaction(context.Background(), make([]byte, 32), sentAt.Add(time.Hour))
+	if !errors.Is(err, ton.ErrTxWasNotFound) {
+		t.Fatalf("expected ErrTxWasNotFound, got %v", err)
+	}
+}
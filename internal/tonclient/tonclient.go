@@ -2,25 +2,31 @@ package tonclient
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"path/filepath"
 	"strings"
 
-	"github.com/rsquad/trustless-bridge-cli/internal/data"
+	"github.com/rsquad/trustless-bridge-cli/internal/blockcache"
 	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
 	"github.com/spf13/viper"
-	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/liteclient"
 	"github.com/xssnick/tonutils-go/tl"
-	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/ton"
-	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var (
+	ErrUnknownNetwork = errors.New("unknown network")
+	ErrWalletConfig   = errors.New("invalid wallet config")
+	ErrContractConfig = errors.New("invalid contract code config")
 )
 
 type TonClient struct {
 	connPool *liteclient.ConnectionPool
 	API      *ton.APIClient
+	cache    *blockcache.Cache
+
+	sendOptions SendOptions
 }
 
 func NewTonClient(cfg *liteclient.GlobalConfig) (*TonClient, error) {
@@ -30,33 +36,84 @@ func NewTonClient(cfg *liteclient.GlobalConfig) (*TonClient, error) {
 	if err != nil {
 		return nil, err
 	}
-	apiWrapped := ton.NewAPIClient(connPool).WithRetry(3)
+	apiWrapped := ton.NewAPIClient(errorCounter{connPool}).WithRetry(3)
 	api, _ := apiWrapped.(*ton.APIClient)
 
 	return &TonClient{connPool: connPool, API: api}, nil
 }
 
+// NewTonClientNetwork connects to the liteservers of the network profile.
+// If fixtures_dir is set, requests are served from <fixtures_dir>/<network>.json instead,
+// and if record_fixtures_dir is set, the real exchanges are recorded to such a file.
+// Both modes skip liteserver proof checks, so recorded requests match replayed ones.
+// Otherwise blocks, block proofs and validator sets are kept in <cache_dir>/<network>,
+// and in offline mode only the cached data is available.
 func NewTonClientNetwork(network string) (*TonClient, error) {
-	var configDataStr string
-	switch network {
-	case "testnet":
-		configDataStr = data.TestnetConfig
-	case "fastnet":
-		configDataStr = data.FastnetConfig
-	default:
-		log.Fatalf("unknown network: %s", network)
-	}
-
-	var globalConfig liteclient.GlobalConfig
-	err := json.Unmarshal([]byte(configDataStr), &globalConfig)
+	profile, err := LoadNetworkProfile(network)
 	if err != nil {
-		log.Fatalf("failed to parse config data: %v", err)
+		return nil, err
+	}
+
+	if dir := viper.GetString("fixtures_dir"); dir != "" {
+		fixtures, err := LoadFixtures(filepath.Join(dir, network+".json"))
+		if err != nil {
+			return nil, err
+		}
+		return NewTonClientFixtures(fixtures), nil
 	}
 
-	return NewTonClient(&globalConfig)
+	if viper.GetBool("offline") {
+		cache, err := openCache(network)
+		if err != nil {
+			return nil, err
+		}
+		if cache == nil {
+			return nil, fmt.Errorf("offline mode requires cache_dir to be set")
+		}
+		tc := newTonClientUnsafe(nil, offlineLiteClient{})
+		tc.cache = cache
+		return tc, nil
+	}
+
+	globalConfig, err := profile.GlobalConfig(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	if dir := viper.GetString("record_fixtures_dir"); dir != "" {
+		connPool := liteclient.NewConnectionPool()
+		if err = connPool.AddConnectionsFromConfig(context.Background(), globalConfig); err != nil {
+			return nil, err
+		}
+		return newTonClientUnsafe(connPool, NewRecorder(connPool, filepath.Join(dir, network+".json"))), nil
+	}
+
+	cache, err := openCache(network)
+	if err != nil {
+		return nil, err
+	}
+	tc, err := NewTonClient(globalConfig)
+	if err != nil {
+		return nil, err
+	}
+	tc.cache = cache
+	return tc, nil
+}
+
+func newTonClientUnsafe(connPool *liteclient.ConnectionPool, client ton.LiteClient) *TonClient {
+	apiWrapped := ton.NewAPIClient(errorCounter{client}, ton.ProofCheckPolicyUnsafe).WithRetry(3)
+	api, _ := apiWrapped.(*ton.APIClient)
+
+	return &TonClient{connPool: connPool, API: api}
 }
 
 func (tc *TonClient) GetBlockProofExt(ctx context.Context, known, target *ton.BlockIDExt) (*ton.PartialBlockProof, error) {
+	if tc.cache != nil {
+		if proof, err := tc.cache.BlockProof(known, target); err == nil {
+			return proof, nil
+		}
+	}
+
 	var resp tl.Serializable
 	err := tc.API.Client().QueryLiteserver(ctx, ton.GetBlockProof{
 		Mode:        0x1001,
@@ -69,6 +126,9 @@ func (tc *TonClient) GetBlockProofExt(ctx context.Context, known, target *ton.Bl
 
 	switch t := resp.(type) {
 	case ton.PartialBlockProof:
+		if tc.cache != nil {
+			logCacheError("block proof", tc.cache.PutBlockProof(known, target, &t))
+		}
 		return &t, nil
 	case ton.LSError:
 		return nil, t
@@ -77,6 +137,12 @@ func (tc *TonClient) GetBlockProofExt(ctx context.Context, known, target *ton.Bl
 }
 
 func (tc *TonClient) GetBlockBOC(ctx context.Context, block *ton.BlockIDExt) ([]byte, error) {
+	if tc.cache != nil {
+		if boc, err := tc.cache.BlockBOC(block); err == nil {
+			return boc, nil
+		}
+	}
+
 	var resp tl.Serializable
 	err := tc.API.Client().QueryLiteserver(ctx, ton.GetBlockData{ID: block}, &resp)
 	if err != nil {
@@ -85,88 +151,61 @@ func (tc *TonClient) GetBlockBOC(ctx context.Context, block *ton.BlockIDExt) ([]
 
 	switch t := resp.(type) {
 	case ton.BlockData:
+		if tc.cache != nil {
+			logCacheError("block", tc.cache.PutBlockBOC(block, t.Payload))
+		}
 		return t.Payload, nil
 	case ton.LSError:
 		return nil, t
 	}
-	panic("should not happen")
+	return nil, fmt.Errorf("unknown response type")
 }
 
-func (tc *TonClient) GetWallet() *wallet.Wallet {
-	mnemonic := viper.GetString("wallet_mnemonic")
-	if mnemonic == "" {
-		panic("wallet_mnemonic is not set")
-	}
+// GetWallet creates the wallet from the wallet_version and wallet_workchain config keys
+// and the signer of walletSigner.
+// The address of a highload v3 wallet also depends on wallet_highload_ttl.
+func (tc *TonClient) GetWallet() (*wallet.Wallet, error) {
 	walletVersion := viper.GetString("wallet_version")
 	if walletVersion == "" {
-		panic("wallet_version is not set")
+		return nil, fmt.Errorf("%w: wallet_version is not set", ErrWalletConfig)
 	}
 	walletWc := viper.GetInt("wallet_workchain")
 	if walletWc != 0 && walletWc != -1 {
-		panic("wallet_workchain is not set correctly")
-	}
-
-	versionMap := map[string]wallet.Version{
-		"v1r1":      wallet.V1R1,
-		"v1r2":      wallet.V1R2,
-		"v1r3":      wallet.V1R3,
-		"v2r1":      wallet.V2R1,
-		"v2r2":      wallet.V2R2,
-		"v3r1":      wallet.V3R1,
-		"v3r2":      wallet.V3R2,
-		"v3":        wallet.V3,
-		"v4r1":      wallet.V4R1,
-		"v4r2":      wallet.V4R2,
-		"v5r1beta":  wallet.V5R1Beta,
-		"v5r1final": wallet.V5R1Final,
+		return nil, fmt.Errorf("%w: wallet_workchain is not set correctly", ErrWalletConfig)
+	}
+
+	versionMap := map[string]wallet.VersionConfig{
+		"v1r1":         wallet.V1R1,
+		"v1r2":         wallet.V1R2,
+		"v1r3":         wallet.V1R3,
+		"v2r1":         wallet.V2R1,
+		"v2r2":         wallet.V2R2,
+		"v3r1":         wallet.V3R1,
+		"v3r2":         wallet.V3R2,
+		"v3":           wallet.V3,
+		"v4r1":         wallet.V4R1,
+		"v4r2":         wallet.V4R2,
+		"v5r1beta":     wallet.V5R1Beta,
+		"v5r1final":    wallet.V5R1Final,
+		"highloadv2r2": wallet.HighloadV2R2,
+		"highloadv3": wallet.ConfigHighloadV3{
+			MessageTTL:     highloadTTL(),
+			MessageBuilder: highloadQueryIDs.next,
+		},
 	}
 
 	version, exists := versionMap[strings.ToLower(walletVersion)]
 	if !exists {
-		panic(fmt.Sprintf("unsupported wallet type: %s", walletVersion))
-	}
-
-	w, err := wallet.FromSeed(tc.API, strings.Split(mnemonic, " "), version, byte(walletWc))
-	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("%w: unsupported wallet type: %s", ErrWalletConfig, walletVersion)
 	}
-	return w
-}
 
-func DeployContractWaitTransaction(
-	ctx context.Context,
-	w *wallet.Wallet,
-	wc byte,
-	amount tlb.Coins,
-	msgBody,
-	contractCode,
-	contractData *cell.Cell,
-) (*address.Address, *tlb.Transaction, *ton.BlockIDExt, error) {
-	state := &tlb.StateInit{
-		Data: contractData,
-		Code: contractCode,
-	}
-
-	stateCell, err := tlb.ToCell(state)
+	signer, err := walletSigner()
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
 	}
-
-	addr := address.NewAddress(0, wc, stateCell.Hash())
-
-	tx, block, err := w.SendWaitTransaction(ctx, &wallet.Message{
-		Mode: wallet.PayGasSeparately + wallet.IgnoreErrors,
-		InternalMessage: &tlb.InternalMessage{
-			IHRDisabled: true,
-			Bounce:      false,
-			DstAddr:     addr,
-			Amount:      amount,
-			Body:        msgBody,
-			StateInit:   state,
-		},
-	})
+	w, err := wallet.FromSigner(tc.API, signer, version, byte(walletWc))
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, fmt.Errorf("%w: %v", ErrWalletConfig, err)
 	}
-	return addr, tx, block, nil
+	return w, nil
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 
+	"github.com/rsquad/trustless-bridge-cli/internal/msgtrace"
 	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
 	"github.com/rsquad/trustless-bridge-cli/internal/wallet"
 	"github.com/spf13/viper"
@@ -15,7 +16,8 @@ import (
 )
 
 const (
-	opCodeCheckTx = 0x91d555f7
+	opCodeCheckTx            = 0x91d555f7
+	opCodeTransactionChecked = 0x756adff1
 )
 
 type TxCheckerContract struct {
@@ -51,8 +53,12 @@ func (c *TxCheckerContract) SendCheckTx(
 	proofCell *cell.Cell,
 	blockCell *cell.Cell,
 ) (*tlb.Transaction, *ton.BlockIDExt, error) {
-	w := c.tonClient.GetWallet()
+	return c.tonClient.SendWaitTransaction(ctx, c.CheckTxMessage(txCell, proofCell, blockCell))
+}
 
+// CheckTxMessage returns the check_transaction message of the wallet,
+// to be sent alone or in a batch.
+func (c *TxCheckerContract) CheckTxMessage(txCell, proofCell, blockCell *cell.Cell) *wallet.Message {
 	payload := cell.BeginCell().
 		MustStoreUInt(opCodeCheckTx, 32).
 		MustStoreRef(txCell).
@@ -60,29 +66,87 @@ func (c *TxCheckerContract) SendCheckTx(
 		MustStoreRef(blockCell).
 		EndCell()
 
-	message := wallet.SimpleMessage(c.Addr, tlb.MustFromTON("1"), payload)
+	return wallet.SimpleMessage(c.Addr, tlb.MustFromTON("1"), payload)
+}
 
-	return w.SendWaitTransaction(ctx, message)
+// CheckTxVerdict follows the check_transaction message sent by the wallet transaction
+// through wallet -> tx_checker -> lite_client -> tx_checker and checks that the TxChecker
+// sent transaction_checked.
+func (c *TxCheckerContract) CheckTxVerdict(ctx context.Context, tx *tlb.Transaction) (*msgtrace.Verdict, error) {
+	return msgtrace.Follow(ctx, c.tonClient.API, tx, 3, opCodeTransactionChecked)
 }
 
-func DeployTxChecker(ctx context.Context, tonClient *tonclient.TonClient, wc byte, initData *InitData) (*address.Address, error) {
-	wallet := tonClient.GetWallet()
+// CheckTxMessageVerdict is CheckTxVerdict for a check_transaction message of a batch.
+func (c *TxCheckerContract) CheckTxMessageVerdict(ctx context.Context, msg *tlb.InternalMessage) (*msgtrace.Verdict, error) {
+	return msgtrace.FollowMessage(ctx, c.tonClient.API, msg, 3, opCodeTransactionChecked)
+}
 
-	msgBody := cell.BeginCell().EndCell()
+// GetLiteClientAddr returns the address of the LiteClient that checks blocks for the TxChecker,
+// which is kept in the contract data.
+func (c *TxCheckerContract) GetLiteClientAddr(ctx context.Context) (*address.Address, error) {
+	block, err := c.tonClient.API.GetMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	acc, err := c.tonClient.API.GetAccount(ctx, block, c.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TxChecker account: %w", err)
+	}
+	if !acc.IsActive || acc.Data == nil {
+		return nil, fmt.Errorf("TxChecker %s is not active", c.Addr)
+	}
+	addr, err := acc.Data.BeginParse().LoadAddr()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse LiteClient address: %w", err)
+	}
+	return addr, nil
+}
+
+// ParseTransactionChecked returns the transaction cell of the
+// transaction_checked#756adff1 transaction:^Cell answer.
+func ParseTransactionChecked(body *cell.Cell) (*cell.Cell, error) {
+	s := body.BeginParse()
+	op, err := s.LoadUInt(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load op: %w", err)
+	}
+	if op != opCodeTransactionChecked {
+		return nil, fmt.Errorf("unexpected op %08x", op)
+	}
+	txCell, err := s.LoadRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load transaction: %w", err)
+	}
+	return txCell.ToCell()
+}
 
+// LoadCode parses the code of the contract from the tx_checker_code config key.
+func LoadCode() (*cell.Cell, error) {
 	codeHex := viper.GetString("tx_checker_code")
+	if codeHex == "" {
+		return nil, fmt.Errorf("%w: tx_checker_code is not set", tonclient.ErrContractConfig)
+	}
 	codeBytes, err := hex.DecodeString(codeHex)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode tx checker code: %w", err)
+		return nil, fmt.Errorf("%w: failed to decode tx checker code: %v", tonclient.ErrContractConfig, err)
 	}
 	codeCell, err := cell.FromBOC(codeBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse tx checker code: %w", err)
+		return nil, fmt.Errorf("%w: failed to parse tx checker code: %v", tonclient.ErrContractConfig, err)
+	}
+	return codeCell, nil
+}
+
+func DeployTxChecker(ctx context.Context, tonClient *tonclient.TonClient, wc byte, initData *InitData) (*address.Address, error) {
+	msgBody := cell.BeginCell().EndCell()
+
+	codeCell, err := LoadCode()
+	if err != nil {
+		return nil, err
 	}
 
-	addr, _, _, err := tonclient.DeployContractWaitTransaction(
-		context.Background(),
-		wallet,
+	addr, _, _, err := tonClient.DeployContractWaitTransaction(
+		ctx,
 		wc,
 		tlb.MustFromTON("0.2"),
 		msgBody,
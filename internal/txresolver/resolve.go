@@ -0,0 +1,179 @@
+package txresolver
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/txutils"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+const (
+	// maxScannedTxs limits the number of account transactions scanned from the last one.
+	maxScannedTxs = 1024
+	// listTxsLimit is the number of transactions requested by one ListTransactions call.
+	listTxsLimit = 16
+)
+
+// Query selects a transaction of an account by its LT, its hash, or the hash of its
+// inbound message. Exactly one of LT, TxHash and InMsgHash must be set.
+type Query struct {
+	Account *address.Address
+	LT      uint64
+	TxHash  []byte
+	// InMsgHash is the hash of the inbound message cell or of its body.
+	InMsgHash []byte
+}
+
+// Location is a transaction with the block that contains it and the masterchain block
+// that is this block or registers it in ShardHashes.
+type Location struct {
+	Tx          *tlb.Transaction
+	Block       *ton.BlockIDExt
+	Masterchain *ton.BlockIDExt
+}
+
+func (q *Query) Validate() error {
+	if q.Account == nil {
+		return fmt.Errorf("account is not set")
+	}
+	selectors := 0
+	for _, set := range []bool{q.LT != 0, q.TxHash != nil, q.InMsgHash != nil} {
+		if set {
+			selectors++
+		}
+	}
+	if selectors != 1 {
+		return fmt.Errorf("exactly one of lt, tx hash and in msg hash must be set")
+	}
+	return nil
+}
+
+func (q *Query) String() string {
+	switch {
+	case q.TxHash != nil:
+		return fmt.Sprintf("%s with hash %x", q.Account, q.TxHash)
+	case q.InMsgHash != nil:
+		return fmt.Sprintf("%s with in msg hash %x", q.Account, q.InMsgHash)
+	}
+	return fmt.Sprintf("%s with lt %d", q.Account, q.LT)
+}
+
+// Resolve finds the transaction among the last transactions of the account, looks up
+// the block that contains it by LT and the masterchain block that commits this block.
+func Resolve(ctx context.Context, tonClient *tonclient.TonClient, q *Query) (*Location, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	tx, err := findTx(ctx, tonClient, q)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := lookupBlockByLT(ctx, tonClient, q.Account, tx.LT)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup block by lt %d: %w", tx.LT, err)
+	}
+
+	loc := &Location{Tx: tx, Block: block, Masterchain: block}
+	if block.Workchain != address.MasterchainID {
+		loc.Masterchain, err = blockutils.FindRegisteringMasterBlock(ctx, tonClient, block)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return loc, nil
+}
+
+func findTx(ctx context.Context, tonClient *tonclient.TonClient, q *Query) (*tlb.Transaction, error) {
+	master, err := tonClient.API.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	acc, err := tonClient.API.GetAccount(ctx, master, q.Account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	scanned := 0
+	for lt, hash := acc.LastTxLT, acc.LastTxHash; lt != 0 && scanned < maxScannedTxs; {
+		txs, err := tonClient.API.ListTransactions(ctx, q.Account, listTxsLimit, lt, hash)
+		if errors.Is(err, ton.ErrNoTransactionsWereFound) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list transactions: %w", err)
+		}
+
+		// transactions are ordered from the oldest one
+		for i := len(txs) - 1; i >= 0; i-- {
+			tx := txs[i]
+			if q.matches(tx) {
+				return tx, nil
+			}
+			if q.LT != 0 && tx.LT < q.LT {
+				return nil, fmt.Errorf("%w: %s", txutils.ErrTxNotFound, q)
+			}
+		}
+		scanned += len(txs)
+		lt, hash = txs[0].PrevTxLT, txs[0].PrevTxHash
+	}
+
+	if scanned >= maxScannedTxs {
+		return nil, fmt.Errorf("%w: %s in the last %d transactions", txutils.ErrTxNotFound, q, scanned)
+	}
+	return nil, fmt.Errorf("%w: %s", txutils.ErrTxNotFound, q)
+}
+
+func (q *Query) matches(tx *tlb.Transaction) bool {
+	switch {
+	case q.TxHash != nil:
+		return bytes.Equal(tx.Hash, q.TxHash)
+	case q.InMsgHash != nil:
+		if tx.IO.In == nil {
+			return false
+		}
+		if payload := tx.IO.In.Msg.Payload(); payload != nil && bytes.Equal(payload.Hash(), q.InMsgHash) {
+			return true
+		}
+		msgCell, err := tlb.ToCell(tx.IO.In)
+		return err == nil && bytes.Equal(msgCell.Hash(), q.InMsgHash)
+	}
+	return tx.LT == q.LT
+}
+
+// lookupBlockByLT returns the block of the account shard with the transaction LT.
+func lookupBlockByLT(ctx context.Context, tonClient *tonclient.TonClient, account *address.Address, lt uint64) (*ton.BlockIDExt, error) {
+	var resp tl.Serializable
+	err := tonClient.API.Client().QueryLiteserver(ctx, ton.LookupBlock{
+		Mode: 2,
+		ID: &ton.BlockInfoShort{
+			Workchain: account.Workchain(),
+			Shard:     int64(binary.BigEndian.Uint64(account.Data()[:8])),
+		},
+		LT: lt,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case ton.BlockHeader:
+		return t.ID, nil
+	case ton.LSError:
+		if t.Code == 651 {
+			return nil, ton.ErrBlockNotFound
+		}
+		return nil, t
+	}
+	return nil, fmt.Errorf("unknown response type")
+}
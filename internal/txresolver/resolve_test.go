@@ -0,0 +1,258 @@
+package txresolver_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blocktest"
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/tonclient"
+	"github.com/rsquad/trustless-bridge-cli/internal/txresolver"
+	"github.com/rsquad/trustless-bridge-cli/internal/txutils"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// listTxsLimit is the number of transactions requested by one ListTransactions call of the resolver.
+const listTxsLimit = 16
+
+var (
+	masterAccount = address.NewAddress(0, 0xff, bytes.Repeat([]byte{0x11}, 32))
+	shardAccount  = address.NewAddress(0, 0, bytes.Repeat([]byte{0x22}, 32))
+)
+
+// prepareInMsg returns the external inbound message of the i-th transaction of the account.
+func prepareInMsg(addr *address.Address, i int) *cell.Cell {
+	msg, err := tlb.ToCell(&tlb.ExternalMessage{
+		DstAddr: addr,
+		Body:    cell.BeginCell().MustStoreUInt(uint64(0xBB00+i), 32).EndCell(),
+	})
+	if err != nil {
+		panic(err)
+	}
+	return msg
+}
+
+// prepareHistory returns n transactions of the account with lts 1000, 1010, ..., oldest first.
+func prepareHistory(addr *address.Address, n int) []*cell.Cell {
+	txs := []*cell.Cell{blocktest.Transaction(addr.Data(), 1000, prepareInMsg(addr, 0))}
+	for i := 1; i < n; i++ {
+		txs = append(txs, blocktest.NextTransaction(txs[i-1], uint64(1000+10*i), prepareInMsg(addr, i)))
+	}
+	return txs
+}
+
+// prepareAccount adds the masterchain info and the account state with the last transaction.
+func prepareAccount(fixtures *tonclient.Fixtures, addr *address.Address, last *cell.Cell) {
+	master := (&blocktest.Block{Workchain: -1, Seqno: 200}).ID()
+	err := fixtures.Add(ton.GetMasterchainInf{}, ton.MasterchainInfo{
+		Last:          master,
+		StateRootHash: make([]byte, 32),
+		Init:          &ton.ZeroStateIDExt{Workchain: -1, RootHash: make([]byte, 32), FileHash: make([]byte, 32)},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	var tx tlb.Transaction
+	if err = tlb.LoadFromCell(&tx, last.BeginParse()); err != nil {
+		panic(err)
+	}
+	account := blocktest.Account(addr, 1, nil, nil)
+	accounts := cell.NewDict(256)
+	err = accounts.Set(
+		cell.BeginCell().MustStoreSlice(addr.Data(), 256).EndCell(),
+		blocktest.ShardAccount(account, 1, tx.LT, last.Hash()),
+	)
+	if err != nil {
+		panic(err)
+	}
+	stateProof := cell.BeginCell().MustStoreRef(blocktest.ShardState(addr.Workchain(), 200, accounts)).EndCell()
+	err = fixtures.Add(
+		ton.GetAccountState{ID: master, Account: ton.AccountID{Workchain: addr.Workchain(), ID: addr.Data()}},
+		ton.AccountState{ID: master, Shard: master, Proof: []*cell.Cell{cell.BeginCell().EndCell(), stateProof}, State: account},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// prepareTxPages adds the pages of ListTransactions from the last of the transactions back to the first one.
+func prepareTxPages(fixtures *tonclient.Fixtures, addr *address.Address, txs []*cell.Cell) {
+	for end := len(txs); end > 0; end -= listTxsLimit {
+		start := max(end-listTxsLimit, 0)
+		var page []*cell.Cell
+		for i := end - 1; i >= start; i-- {
+			page = append(page, txs[i])
+		}
+		var last tlb.Transaction
+		if err := tlb.LoadFromCell(&last, txs[end-1].BeginParse()); err != nil {
+			panic(err)
+		}
+		err := fixtures.Add(
+			ton.GetTransactions{
+				Limit:  listTxsLimit,
+				AccID:  &ton.AccountID{Workchain: addr.Workchain(), ID: addr.Data()},
+				LT:     int64(last.LT),
+				TxHash: txs[end-1].Hash(),
+			},
+			ton.TransactionList{IDs: []*ton.BlockIDExt{}, Transactions: cell.ToBOCWithFlags(page, false)},
+		)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// prepareTxBlock adds the block of the account shard that LookupBlock returns for the lt.
+func prepareTxBlock(fixtures *tonclient.Fixtures, addr *address.Address, lt uint64, block *blocktest.Block) {
+	err := fixtures.Add(
+		ton.LookupBlock{
+			Mode: 2,
+			ID: &ton.BlockInfoShort{
+				Workchain: addr.Workchain(),
+				Shard:     int64(binary.BigEndian.Uint64(addr.Data()[:8])),
+			},
+			LT: lt,
+		},
+		ton.BlockHeader{ID: block.ID(), HeaderProof: []byte{}},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	txs := prepareHistory(masterAccount, 20)
+	fixtures := &tonclient.Fixtures{}
+	prepareAccount(fixtures, masterAccount, txs[19])
+	prepareTxPages(fixtures, masterAccount, txs)
+	block := &blocktest.Block{Workchain: -1, Seqno: 150}
+	client := tonclient.NewTonClientFixtures(fixtures)
+
+	body := cell.BeginCell().MustStoreUInt(0xBB00+2, 32).EndCell()
+	for name, q := range map[string]*txresolver.Query{
+		// the first page has transactions 4-19, so these are found on the second one
+		"lt":           {Account: masterAccount, LT: 1020},
+		"tx hash":      {Account: masterAccount, TxHash: txs[2].Hash()},
+		"in msg hash":  {Account: masterAccount, InMsgHash: prepareInMsg(masterAccount, 2).Hash()},
+		"in msg body":  {Account: masterAccount, InMsgHash: body.Hash()},
+		"latest by lt": {Account: masterAccount, LT: 1190},
+	} {
+		lt := q.LT
+		if lt == 0 {
+			lt = 1020
+		}
+		prepareTxBlock(fixtures, masterAccount, lt, block)
+
+		loc, err := txresolver.Resolve(context.Background(), client, q)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if loc.Tx.LT != lt {
+			t.Fatalf("%s: unexpected transaction lt %d", name, loc.Tx.LT)
+		}
+		if !loc.Block.Equals(block.ID()) || !loc.Masterchain.Equals(block.ID()) {
+			t.Fatalf("%s: unexpected blocks %d, %d", name, loc.Block.SeqNo, loc.Masterchain.SeqNo)
+		}
+	}
+}
+
+func TestResolveStopsBelowLT(t *testing.T) {
+	txs := prepareHistory(masterAccount, 20)
+	fixtures := &tonclient.Fixtures{}
+	prepareAccount(fixtures, masterAccount, txs[19])
+	// only the first page is served, so the scan must stop at lt 1180 below 1185
+	prepareTxPages(fixtures, masterAccount, txs[4:])
+	client := tonclient.NewTonClientFixtures(fixtures)
+
+	_, err := txresolver.Resolve(context.Background(), client, &txresolver.Query{Account: masterAccount, LT: 1185})
+	if !errors.Is(err, txutils.ErrTxNotFound) {
+		t.Fatalf("expected ErrTxNotFound, got %v", err)
+	}
+	_, err = txresolver.Resolve(context.Background(), client, &txresolver.Query{Account: masterAccount, TxHash: make([]byte, 32)})
+	if !errors.Is(err, tonclient.ErrNoFixture) {
+		t.Fatalf("a hash must be searched for on the next page, got %v", err)
+	}
+}
+
+func TestResolveShardBlock(t *testing.T) {
+	txs := prepareHistory(shardAccount, 3)
+	fixtures := &tonclient.Fixtures{}
+	prepareAccount(fixtures, shardAccount, txs[2])
+	prepareTxPages(fixtures, shardAccount, txs)
+	shardBlock := &blocktest.Block{Workchain: 0, Seqno: 10, MasterRef: 100}
+	if err := fixtures.Add(ton.GetBlockData{ID: shardBlock.ID()}, ton.BlockData{ID: shardBlock.ID(), Payload: shardBlock.BOC()}); err != nil {
+		t.Fatal(err)
+	}
+	prepareTxBlock(fixtures, shardAccount, 1010, shardBlock)
+	master := &blocktest.Block{
+		Workchain:   -1,
+		Seqno:       101,
+		ShardHashes: map[int32]*cell.Cell{0: blocktest.ShardDescr(10, shardBlock.Cell().Hash())},
+	}
+	err := fixtures.Add(
+		ton.LookupBlock{Mode: 1, ID: &ton.BlockInfoShort{Workchain: -1, Shard: 0, Seqno: 101}},
+		ton.BlockHeader{ID: master.ID(), HeaderProof: []byte{}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fixtures.Add(ton.GetAllShardsInfo{ID: master.ID()}, ton.AllShardsInfo{
+		ID:    master.ID(),
+		Proof: []*cell.Cell{cell.BeginCell().EndCell()},
+		Data:  cell.BeginCell().MustStoreDict(blocktest.ShardHashes(master.ShardHashes)).EndCell(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loc, err := txresolver.Resolve(context.Background(), tonclient.NewTonClientFixtures(fixtures), &txresolver.Query{Account: shardAccount, LT: 1010})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !loc.Block.Equals(shardBlock.ID()) || !loc.Masterchain.Equals(master.ID()) {
+		t.Fatalf("unexpected blocks %d, %d", loc.Block.SeqNo, loc.Masterchain.SeqNo)
+	}
+}
+
+func TestResolveShardBlockNotRegistered(t *testing.T) {
+	txs := prepareHistory(shardAccount, 1)
+	fixtures := &tonclient.Fixtures{}
+	prepareAccount(fixtures, shardAccount, txs[0])
+	prepareTxPages(fixtures, shardAccount, txs)
+	shardBlock := &blocktest.Block{Workchain: 0, Seqno: 10, MasterRef: 100}
+	if err := fixtures.Add(ton.GetBlockData{ID: shardBlock.ID()}, ton.BlockData{ID: shardBlock.ID(), Payload: shardBlock.BOC()}); err != nil {
+		t.Fatal(err)
+	}
+	prepareTxBlock(fixtures, shardAccount, 1000, shardBlock)
+	// masterchain block 101 registers the next block of the shard
+	master := (&blocktest.Block{Workchain: -1, Seqno: 101}).ID()
+	err := fixtures.Add(
+		ton.LookupBlock{Mode: 1, ID: &ton.BlockInfoShort{Workchain: -1, Shard: 0, Seqno: 101}},
+		ton.BlockHeader{ID: master, HeaderProof: []byte{}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fixtures.Add(ton.GetAllShardsInfo{ID: master}, ton.AllShardsInfo{
+		ID:    master,
+		Proof: []*cell.Cell{cell.BeginCell().EndCell()},
+		Data: cell.BeginCell().MustStoreDict(blocktest.ShardHashes(map[int32]*cell.Cell{
+			0: blocktest.ShardDescr(11, bytes.Repeat([]byte{0x0B}, 32)),
+		})).EndCell(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = txresolver.Resolve(context.Background(), tonclient.NewTonClientFixtures(fixtures), &txresolver.Query{Account: shardAccount, LT: 1000})
+	if !errors.Is(err, blockutils.ErrNotRegistered) {
+		t.Fatalf("expected ErrNotRegistered, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package txutils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var ErrOutMsgNotFound = errors.New("out msg not found")
+
+// OutMsgSelector selects an outbound message of a transaction: by the hash
+// of the message cell if Hash is set, by its index in OutMsgs otherwise.
+type OutMsgSelector struct {
+	Index uint16
+	Hash  []byte
+}
+
+func (s OutMsgSelector) String() string {
+	if s.Hash != nil {
+		return fmt.Sprintf("%x", s.Hash)
+	}
+	return fmt.Sprintf("#%d", s.Index)
+}
+
+// FindOutMsg returns the OutMsgs key and the cell of the selected outbound message of the transaction.
+func FindOutMsg(tx *tlb.Transaction, sel OutMsgSelector) (uint16, *cell.Cell, error) {
+	if tx.IO.Out == nil || tx.IO.Out.List == nil {
+		return 0, nil, fmt.Errorf("%w: transaction has no out msgs", ErrOutMsgNotFound)
+	}
+
+	if sel.Hash == nil {
+		value, err := tx.IO.Out.List.LoadValue(outMsgKey(sel.Index))
+		if err != nil {
+			return 0, nil, fmt.Errorf("%w: %s", ErrOutMsgNotFound, sel)
+		}
+		msgCell, err := value.LoadRefCell()
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to load out msg: %w", err)
+		}
+		return sel.Index, msgCell, nil
+	}
+
+	msgs, err := tx.IO.Out.List.LoadAll()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to load out msgs: %w", err)
+	}
+	for _, kv := range msgs {
+		msgCell, err := kv.Value.LoadRefCell()
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to load out msg: %w", err)
+		}
+		if bytes.Equal(msgCell.Hash(), sel.Hash) {
+			return uint16(kv.Key.MustLoadUInt(15)), msgCell, nil
+		}
+	}
+	return 0, nil, fmt.Errorf("%w: %s", ErrOutMsgNotFound, sel)
+}
+
+func outMsgKey(index uint16) *cell.Cell {
+	return cell.BeginCell().MustStoreUInt(uint64(index), 15).EndCell()
+}
+
+// proveOutMsg adds the transaction to the skeleton of its AccountBlock dict value.
+// The transaction is kept whole except for the OutMsgs dict, where only
+// the branch with the selected message is kept.
+func proveOutMsg(txValue *cell.Slice, txSk *cell.ProofSkeleton, tx *tlb.Transaction, sel OutMsgSelector) error {
+	index, _, err := FindOutMsg(tx, sel)
+	if err != nil {
+		return err
+	}
+
+	refs := txValue.RefsNum()
+	skipCC(txValue)
+	txCell, err := txValue.LoadRefCell()
+	if err != nil {
+		return fmt.Errorf("failed to load transaction: %w", err)
+	}
+	txCellSk := txSk.ProofRef(refs - txValue.RefsNum() - 1)
+
+	// ^[ in_msg:(Maybe ^(Message Any)) out_msgs:(HashmapE 15 ^(Message Any)) ] is the first ref,
+	// the remaining ones are total_fees extra currencies, state_update and description
+	for i := 1; i < int(txCell.RefsNum()); i++ {
+		txCellSk.ProofRef(i).SetRecursive()
+	}
+	ioSk := txCellSk.ProofRef(0)
+	outIdx := 0
+	if tx.IO.In != nil {
+		ioSk.ProofRef(0).SetRecursive()
+		outIdx = 1
+	}
+
+	_, msgSk, err := tx.IO.Out.List.LoadValueWithProof(outMsgKey(index), ioSk.ProofRef(outIdx))
+	if err != nil {
+		return fmt.Errorf("failed to prove out msg: %w", err)
+	}
+	msgSk.SetRecursive()
+	return nil
+}
@@ -0,0 +1,185 @@
+package txutils_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blocktest"
+	"github.com/rsquad/trustless-bridge-cli/internal/txutils"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func prepareTxWithOutMsgs(n int) (*tlb.Transaction, []*cell.Cell) {
+	outMsgs := cell.NewDict(15)
+	var msgs []*cell.Cell
+	for i := 0; i < n; i++ {
+		msg := cell.BeginCell().MustStoreUInt(uint64(0xAA00+i), 32).EndCell()
+		key := cell.BeginCell().MustStoreUInt(uint64(i), 15).EndCell()
+		if err := outMsgs.Set(key, cell.BeginCell().MustStoreRef(msg).EndCell()); err != nil {
+			panic(err)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	txCell := cell.BeginCell().
+		MustStoreUInt(0b0111, 4).
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreUInt(1000, 64).
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreUInt(999, 64).
+		MustStoreUInt(1700000000, 32).
+		MustStoreUInt(uint64(n), 15).
+		MustStoreUInt(0b10, 2). // orig_status: active
+		MustStoreUInt(0b10, 2). // end_status: active
+		MustStoreRef(cell.BeginCell().
+			MustStoreMaybeRef(nil).
+			MustStoreMaybeRef(outMsgs.AsCell()).
+			EndCell()).
+		MustStoreCoins(0).
+		MustStoreDict(nil).
+		MustStoreRef(cell.BeginCell().
+			MustStoreUInt(0x72, 8).
+			MustStoreSlice(make([]byte, 32), 256).
+			MustStoreSlice(make([]byte, 32), 256).
+			EndCell()).
+		// trans_storage$0001 storage_fees_collected:0 storage_fees_due:nothing status_change:acst_unchanged
+		MustStoreRef(cell.BeginCell().
+			MustStoreUInt(0b0001, 4).
+			MustStoreCoins(0).
+			MustStoreBoolBit(false).
+			MustStoreUInt(0, 1).
+			EndCell()).
+		EndCell()
+
+	var tx tlb.Transaction
+	if err := tlb.LoadFromCell(&tx, txCell.BeginParse()); err != nil {
+		panic(err)
+	}
+	return &tx, msgs
+}
+
+func TestFindOutMsg(t *testing.T) {
+	tx, msgs := prepareTxWithOutMsgs(3)
+
+	index, msg, err := txutils.FindOutMsg(tx, txutils.OutMsgSelector{Index: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index != 1 || string(msg.Hash()) != string(msgs[1].Hash()) {
+		t.Fatalf("unexpected out msg %d: %x", index, msg.Hash())
+	}
+
+	index, msg, err = txutils.FindOutMsg(tx, txutils.OutMsgSelector{Hash: msgs[2].Hash()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index != 2 || string(msg.Hash()) != string(msgs[2].Hash()) {
+		t.Fatalf("unexpected out msg %d: %x", index, msg.Hash())
+	}
+
+	_, _, err = txutils.FindOutMsg(tx, txutils.OutMsgSelector{Index: 3})
+	if !errors.Is(err, txutils.ErrOutMsgNotFound) {
+		t.Fatalf("expected out msg not found, got %v", err)
+	}
+	_, _, err = txutils.FindOutMsg(tx, txutils.OutMsgSelector{Hash: make([]byte, 32)})
+	if !errors.Is(err, txutils.ErrOutMsgNotFound) {
+		t.Fatalf("expected out msg not found, got %v", err)
+	}
+}
+
+func TestFindOutMsgWithoutOutMsgs(t *testing.T) {
+	tx, _ := prepareTxWithOutMsgs(0)
+
+	_, _, err := txutils.FindOutMsg(tx, txutils.OutMsgSelector{})
+	if !errors.Is(err, txutils.ErrOutMsgNotFound) {
+		t.Fatalf("expected out msg not found, got %v", err)
+	}
+}
+
+// findCell returns the cell of the proof with the hash, if it is not pruned.
+func findCell(c *cell.Cell, hash []byte) *cell.Cell {
+	if c.GetType() == cell.PrunedCellType {
+		return nil
+	}
+	if bytes.Equal(c.Hash(0), hash) {
+		return c
+	}
+	for i := 0; i < int(c.RefsNum()); i++ {
+		if found := findCell(c.MustPeekRef(i), hash); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func TestBuildTxProofWithOutMsg(t *testing.T) {
+	account := bytes.Repeat([]byte{0x42}, 32)
+	var msgs []*cell.Cell
+	for i := 0; i < 3; i++ {
+		msgs = append(msgs, cell.BeginCell().MustStoreUInt(uint64(0xAA00+i), 32).EndCell())
+	}
+	inMsg, err := tlb.ToCell(&tlb.ExternalMessage{
+		DstAddr: address.NewAddress(0, 0, account),
+		Body:    cell.BeginCell().MustStoreUInt(0xBB00, 32).EndCell(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, in := range []*cell.Cell{nil, inMsg} {
+		txCell := blocktest.Transaction(account, 5000, in, msgs...)
+		block := &blocktest.Block{Workchain: 0, Seqno: 10, Accounts: blocktest.AccountBlocks(
+			blocktest.Transaction(account, 4000, nil, msgs[0]),
+			txCell,
+			blocktest.Transaction(bytes.Repeat([]byte{0x41}, 32), 4500, nil),
+		)}
+		blockCell := block.Cell()
+
+		proof, tx, err := txutils.BuildTxProofWithOutMsg(blockCell, txCell.Hash(), txutils.OutMsgSelector{Index: 1})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tx.LT != 5000 {
+			t.Fatalf("unexpected transaction lt %d", tx.LT)
+		}
+		if _, err = ton.CheckBlockProof(proof, blockCell.Hash()); err != nil {
+			t.Fatalf("invalid proof: %v", err)
+		}
+		root, err := cell.UnwrapProof(proof, blockCell.Hash())
+		if err != nil {
+			t.Fatalf("invalid proof: %v", err)
+		}
+
+		txProof := findCell(root, txCell.Hash())
+		if txProof == nil {
+			t.Fatal("transaction is pruned")
+		}
+		// the other refs of the transaction are kept whole
+		for i := 1; i < int(txCell.RefsNum()); i++ {
+			if findCell(txProof.MustPeekRef(i), txCell.MustPeekRef(i).Hash()) == nil {
+				t.Fatalf("ref %d of the transaction is pruned", i)
+			}
+		}
+		io := txProof.MustPeekRef(0)
+		outIdx := 0
+		if in != nil {
+			if findCell(io.MustPeekRef(0), in.Hash()) == nil {
+				t.Fatal("in msg is pruned")
+			}
+			outIdx = 1
+		}
+		outMsgs := io.MustPeekRef(outIdx)
+		if findCell(outMsgs, msgs[1].Hash()) == nil {
+			t.Fatal("selected out msg is pruned")
+		}
+		for _, i := range []int{0, 2} {
+			if findCell(outMsgs, msgs[i].Hash()) != nil {
+				t.Fatalf("out msg %d is not pruned", i)
+			}
+		}
+	}
+}
@@ -2,12 +2,16 @@ package txutils
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
+var ErrTxNotFound = errors.New("tx not found")
+
 func skipCC(s *cell.Slice) {
 	var cc tlb.CurrencyCollection
 	tlb.LoadFromCellAsProof(&cc, s)
@@ -55,10 +59,31 @@ func findTxInAccountBlocks(
 			}
 		}
 	}
-	return nil, fmt.Errorf("tx not found")
+	return nil, ErrTxNotFound
 }
 
 func BuildTxProof(blockCell *cell.Cell, txHash []byte) (*cell.Cell, *tlb.Transaction, error) {
+	return buildTxProof(blockCell, txHash, func(_ *cell.Slice, txSk *cell.ProofSkeleton, _ *tlb.Transaction) error {
+		txSk.SetRecursive()
+		return nil
+	})
+}
+
+// BuildTxProofWithOutMsg builds a transaction proof like BuildTxProof, but of the OutMsgs dict
+// of the transaction only the branch with the selected outbound message is kept.
+func BuildTxProofWithOutMsg(blockCell *cell.Cell, txHash []byte, outMsg OutMsgSelector) (*cell.Cell, *tlb.Transaction, error) {
+	return buildTxProof(blockCell, txHash, func(txValue *cell.Slice, txSk *cell.ProofSkeleton, tx *tlb.Transaction) error {
+		return proveOutMsg(txValue, txSk, tx, outMsg)
+	})
+}
+
+// buildTxProof proves the path from the block to the transaction in the AccountBlocks dict,
+// proveTx adds the needed part of the transaction to the skeleton of the dict value.
+func buildTxProof(
+	blockCell *cell.Cell,
+	txHash []byte,
+	proveTx func(txValue *cell.Slice, txSk *cell.ProofSkeleton, tx *tlb.Transaction) error,
+) (*cell.Cell, *tlb.Transaction, error) {
 	rootSk := cell.CreateProofSkeleton()
 	sk := rootSk.ProofRef(3).ProofRef(2).ProofRef(0)
 
@@ -81,7 +106,7 @@ func BuildTxProof(blockCell *cell.Cell, txHash []byte) (*cell.Cell, *tlb.Transac
 	var accBlock tlb.AccountBlock
 	tlb.LoadFromCell(&accBlock, accCell)
 
-	_, txSk, err := accBlock.Transactions.LoadValueWithProof(
+	txValue, txSk, err := accBlock.Transactions.LoadValueWithProof(
 		cell.BeginCell().MustStoreUInt(tx.LT, 64).EndCell(),
 		accBlockSk,
 	)
@@ -89,7 +114,9 @@ func BuildTxProof(blockCell *cell.Cell, txHash []byte) (*cell.Cell, *tlb.Transac
 		return nil, nil, err
 	}
 
-	txSk.SetRecursive()
+	if err = proveTx(txValue, txSk, tx); err != nil {
+		return nil, nil, err
+	}
 
 	txProof, err := blockCell.CreateProof(rootSk)
 	if err != nil {
@@ -97,3 +124,42 @@ func BuildTxProof(blockCell *cell.Cell, txHash []byte) (*cell.Cell, *tlb.Transac
 	}
 	return txProof, tx, nil
 }
+
+// BuildShardTxProof builds a proof of a transaction in a shardchain block, and a proof of
+// the masterchain block that registers this shardchain block in its ShardHashes.
+// The masterchain block proof keeps the same data as blockutils.BuildBlockProof.
+// If outMsg is set, the transaction proof is built by BuildTxProofWithOutMsg.
+func BuildShardTxProof(
+	shardBlockCell *cell.Cell,
+	mcBlockBOC []byte,
+	txHash []byte,
+	outMsg *OutMsgSelector,
+) (*cell.Cell, *cell.Cell, *tlb.Transaction, error) {
+	var shardBlock tlb.Block
+	if err := tlb.LoadFromCell(&shardBlock, shardBlockCell.BeginParse()); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse shard block: %w", err)
+	}
+	workchain := shardBlock.BlockInfo.Shard.WorkchainID
+	if workchain == -1 {
+		return nil, nil, nil, fmt.Errorf("block is not a shardchain block")
+	}
+
+	var txProof *cell.Cell
+	var tx *tlb.Transaction
+	var err error
+	if outMsg != nil {
+		txProof, tx, err = BuildTxProofWithOutMsg(shardBlockCell, txHash, *outMsg)
+	} else {
+		txProof, tx, err = BuildTxProof(shardBlockCell, txHash)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	mcBlockProof, err := blockutils.BuildBlockProofWithShard(mcBlockBOC, workchain, shardBlockCell.Hash())
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to build masterchain block proof: %w", err)
+	}
+
+	return txProof, mcBlockProof, tx, nil
+}
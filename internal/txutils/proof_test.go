@@ -1,10 +1,14 @@
 package txutils_test
 
 import (
+	"bytes"
 	"math/big"
 	"testing"
 
+	"github.com/rsquad/trustless-bridge-cli/internal/blocktest"
+	"github.com/rsquad/trustless-bridge-cli/internal/txutils"
 	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
@@ -81,3 +85,41 @@ func TestCreateDict1Proof(t *testing.T) {
 		panic(err)
 	}
 }
+
+func TestBuildShardTxProof(t *testing.T) {
+	account := bytes.Repeat([]byte{0x42}, 32)
+	txCell := blocktest.Transaction(account, 5000, nil)
+	shardBlock := &blocktest.Block{Workchain: 0, Seqno: 10, Accounts: blocktest.AccountBlocks(
+		blocktest.Transaction(bytes.Repeat([]byte{0x41}, 32), 4000, nil),
+		txCell,
+	)}
+	shardCell := shardBlock.Cell()
+	mcBlock := &blocktest.Block{
+		Workchain:   -1,
+		Seqno:       100,
+		ShardHashes: map[int32]*cell.Cell{0: blocktest.ShardDescr(10, shardCell.Hash())},
+	}
+
+	txProof, mcProof, tx, err := txutils.BuildShardTxProof(shardCell, mcBlock.BOC(), txCell.Hash(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx.LT != 5000 || !bytes.Equal(tx.AccountAddr, account) {
+		t.Fatalf("unexpected transaction: lt %d of %x", tx.LT, tx.AccountAddr)
+	}
+	if _, err = ton.CheckBlockProof(txProof, shardCell.Hash()); err != nil {
+		t.Fatalf("invalid transaction proof: %v", err)
+	}
+	block, err := ton.CheckBlockProof(mcProof, mcBlock.Cell().Hash())
+	if err != nil {
+		t.Fatalf("invalid masterchain block proof: %v", err)
+	}
+	shards, err := ton.LoadShardsFromHashes(block.Extra.Custom.ShardHashes, true)
+	if err != nil || len(shards) != 1 || !bytes.Equal(shards[0].RootHash, shardCell.Hash()) {
+		t.Fatalf("shard block is not proven by the masterchain block: %+v, %v", shards, err)
+	}
+
+	if _, _, _, err = txutils.BuildShardTxProof(mcBlock.Cell(), mcBlock.BOC(), txCell.Hash(), nil); err == nil {
+		t.Fatal("expected an error for a masterchain block")
+	}
+}
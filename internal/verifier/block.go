@@ -0,0 +1,224 @@
+package verifier
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var (
+	ErrNotMerkleProof      = errors.New("block proof is not a merkle proof")
+	ErrInvalidProof        = errors.New("block proof hashes do not match")
+	ErrInvalidFileHash     = errors.New("file hash must be 256 bits")
+	ErrNoSignatures        = errors.New("signatures dict is empty")
+	ErrMalformedSignature  = errors.New("malformed signature entry")
+	ErrUnknownValidator    = errors.New("signature of a validator that is not in the set")
+	ErrInvalidSignature    = errors.New("invalid ed25519 signature")
+	ErrInsufficientWeight  = errors.New("signed weight does not exceed 2/3 of the total weight")
+	ErrNotKeyBlock         = errors.New("block is not a key block")
+	ErrNoValidatorsInProof = errors.New("config param 34 is not present in the block proof")
+)
+
+// ValidatorSet is the set of validators the LiteClient contract checks signatures against:
+// main validators of the current epoch mapped by their ed25519 public key.
+type ValidatorSet struct {
+	Weights     map[[32]byte]uint64
+	TotalWeight uint64
+}
+
+// ValidatorSetFromValidators builds a set from the main validators of a key block.
+func ValidatorSetFromValidators(validators []*tlb.ValidatorAddr) *ValidatorSet {
+	set := &ValidatorSet{Weights: make(map[[32]byte]uint64, len(validators))}
+	for _, v := range validators {
+		var key [32]byte
+		copy(key[:], v.PublicKey.Key)
+		set.Weights[key] = v.Weight
+		set.TotalWeight += v.Weight
+	}
+	return set
+}
+
+// ValidatorSetFromDict builds a set from a Dict<uint256, uint64> as it is stored in the
+// LiteClient contract. If totalWeight is zero, it is calculated as the sum of all weights.
+func ValidatorSetFromDict(dict *cell.Dictionary, totalWeight uint64) (*ValidatorSet, error) {
+	kvs, err := dict.LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load validator dict: %w", err)
+	}
+
+	set := &ValidatorSet{Weights: make(map[[32]byte]uint64, len(kvs))}
+	var sum uint64
+	for _, kv := range kvs {
+		keyBytes, err := kv.Key.LoadSlice(256)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load validator key: %w", err)
+		}
+		weight, err := kv.Value.LoadUInt(64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load validator weight: %w", err)
+		}
+		var key [32]byte
+		copy(key[:], keyBytes)
+		set.Weights[key] = weight
+		sum += weight
+	}
+
+	set.TotalWeight = totalWeight
+	if set.TotalWeight == 0 {
+		set.TotalWeight = sum
+	}
+	return set, nil
+}
+
+// Result describes a successfully verified block.
+type Result struct {
+	RootHash     []byte
+	FileHash     []byte
+	SignedWeight uint64
+	TotalWeight  uint64
+	Signatures   int
+	// EpochHash is set only for key blocks that carry config param 34.
+	EpochHash []byte
+}
+
+// VerifyBlock checks a check_block payload offline: the merkle proof of the block,
+// and that the ton.blockId of the block is signed by more than 2/3 of the set weight.
+func VerifyBlock(
+	proof *cell.Cell,
+	fileHash []byte,
+	signatures *cell.Dictionary,
+	set *ValidatorSet,
+) (*Result, error) {
+	if len(fileHash) != 32 {
+		return nil, ErrInvalidFileHash
+	}
+
+	rootHash, err := ProofRootHash(proof)
+	if err != nil {
+		return nil, err
+	}
+
+	signedWeight, count, err := VerifySignatures(rootHash, fileHash, signatures, set)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		RootHash:     rootHash,
+		FileHash:     fileHash,
+		SignedWeight: signedWeight,
+		TotalWeight:  set.TotalWeight,
+		Signatures:   count,
+	}, nil
+}
+
+// VerifyKeyBlock checks a new_key_block payload offline. In addition to VerifyBlock it
+// requires the proof to contain config param 34 and returns the epoch hash of the new set,
+// the hash of the param 34 cell kept in the proof.
+func VerifyKeyBlock(
+	proof *cell.Cell,
+	fileHash []byte,
+	signatures *cell.Dictionary,
+	set *ValidatorSet,
+) (*Result, error) {
+	res, err := VerifyBlock(proof, fileHash, signatures, set)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := ton.CheckBlockProof(proof, res.RootHash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+	if block.Extra == nil || block.Extra.Custom == nil || !block.Extra.Custom.KeyBlock {
+		return nil, ErrNotKeyBlock
+	}
+	if block.Extra.Custom.ConfigParams == nil {
+		return nil, ErrNoValidatorsInProof
+	}
+
+	_, _, epochHash, err := blockutils.ExtractMainValidators(block, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoValidatorsInProof, err)
+	}
+	res.EpochHash = epochHash
+
+	return res, nil
+}
+
+// ProofRootHash returns the hash of the block the merkle proof was created for,
+// after checking that the proof is consistent with it.
+func ProofRootHash(proof *cell.Cell) ([]byte, error) {
+	if proof.GetType() != cell.MerkleProofCellType || proof.RefsNum() != 1 {
+		return nil, ErrNotMerkleProof
+	}
+
+	rootHash := proof.MustPeekRef(0).Hash(0)
+	if _, err := cell.UnwrapProof(proof, rootHash); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+	return rootHash, nil
+}
+
+// SignedPayload returns the boxed ton.blockId that validators sign.
+func SignedPayload(rootHash, fileHash []byte) ([]byte, error) {
+	return tl.Serialize(ton.BlockID{RootHash: rootHash, FileHash: fileHash}, true)
+}
+
+// VerifySignatures checks every entry of the Dict<int256, 512> signatures cell against the
+// set and returns the signed weight and the number of signatures.
+func VerifySignatures(
+	rootHash []byte,
+	fileHash []byte,
+	signatures *cell.Dictionary,
+	set *ValidatorSet,
+) (uint64, int, error) {
+	if signatures == nil || signatures.IsEmpty() {
+		return 0, 0, ErrNoSignatures
+	}
+
+	payload, err := SignedPayload(rootHash, fileHash)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to serialize block id: %w", err)
+	}
+
+	kvs, err := signatures.LoadAll()
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %v", ErrMalformedSignature, err)
+	}
+
+	var signedWeight uint64
+	for _, kv := range kvs {
+		keyBytes, err := kv.Key.LoadSlice(256)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%w: %v", ErrMalformedSignature, err)
+		}
+		signature, err := kv.Value.LoadSlice(512)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%w: key %x: %v", ErrMalformedSignature, keyBytes, err)
+		}
+
+		var key [32]byte
+		copy(key[:], keyBytes)
+		weight, ok := set.Weights[key]
+		if !ok {
+			return 0, 0, fmt.Errorf("%w: %x", ErrUnknownValidator, keyBytes)
+		}
+		if !ed25519.Verify(keyBytes, payload, signature) {
+			return 0, 0, fmt.Errorf("%w: validator %x", ErrInvalidSignature, keyBytes)
+		}
+		signedWeight += weight
+	}
+
+	if 3*signedWeight <= 2*set.TotalWeight {
+		return 0, 0, fmt.Errorf("%w (%d/%d)", ErrInsufficientWeight, signedWeight, set.TotalWeight)
+	}
+
+	return signedWeight, len(kvs), nil
+}
@@ -0,0 +1,178 @@
+package verifier_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/blocktest"
+	"github.com/rsquad/trustless-bridge-cli/internal/blockutils"
+	"github.com/rsquad/trustless-bridge-cli/internal/verifier"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+type testValidator struct {
+	pub  ed25519.PublicKey
+	priv ed25519.PrivateKey
+}
+
+func prepareValidators(weights ...uint64) ([]testValidator, *verifier.ValidatorSet) {
+	set := &verifier.ValidatorSet{Weights: map[[32]byte]uint64{}}
+	validators := make([]testValidator, len(weights))
+	for i, w := range weights {
+		pub, priv, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			panic(err)
+		}
+		validators[i] = testValidator{pub, priv}
+
+		var key [32]byte
+		copy(key[:], pub)
+		set.Weights[key] = w
+		set.TotalWeight += w
+	}
+	return validators, set
+}
+
+func prepareProof() *cell.Cell {
+	block := cell.BeginCell().
+		MustStoreUInt(0x11ef55aa, 32).
+		MustStoreRef(cell.BeginCell().MustStoreUInt(1, 64).EndCell()).
+		MustStoreRef(cell.BeginCell().MustStoreUInt(2, 64).EndCell()).
+		EndCell()
+
+	sk := cell.CreateProofSkeleton()
+	sk.ProofRef(0)
+	proof, err := block.CreateProof(sk)
+	if err != nil {
+		panic(err)
+	}
+	return proof
+}
+
+func sign(proof *cell.Cell, fileHash []byte, signers []testValidator) *cell.Dictionary {
+	rootHash, err := verifier.ProofRootHash(proof)
+	if err != nil {
+		panic(err)
+	}
+	payload, err := verifier.SignedPayload(rootHash, fileHash)
+	if err != nil {
+		panic(err)
+	}
+
+	dict := cell.NewDict(256)
+	for _, s := range signers {
+		dict.Set(
+			cell.BeginCell().MustStoreSlice(s.pub, 256).EndCell(),
+			cell.BeginCell().MustStoreSlice(ed25519.Sign(s.priv, payload), 512).EndCell(),
+		)
+	}
+	return dict
+}
+
+func TestVerifyBlock(t *testing.T) {
+	validators, set := prepareValidators(40, 30, 20, 10)
+	proof := prepareProof()
+	fileHash := make([]byte, 32)
+	fileHash[0] = 0xAA
+
+	res, err := verifier.VerifyBlock(proof, fileHash, sign(proof, fileHash, validators[:2]), set)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.SignedWeight != 70 || res.TotalWeight != 100 || res.Signatures != 2 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestVerifyBlockInsufficientWeight(t *testing.T) {
+	validators, set := prepareValidators(30, 30, 30)
+	proof := prepareProof()
+	fileHash := make([]byte, 32)
+
+	// exactly 2/3 of the total weight is not enough
+	_, err := verifier.VerifyBlock(proof, fileHash, sign(proof, fileHash, validators[:2]), set)
+	if !errors.Is(err, verifier.ErrInsufficientWeight) {
+		t.Fatalf("expected ErrInsufficientWeight, got %v", err)
+	}
+}
+
+func TestVerifyBlockWrongFileHash(t *testing.T) {
+	validators, set := prepareValidators(40, 30, 20, 10)
+	proof := prepareProof()
+	fileHash := make([]byte, 32)
+	signatures := sign(proof, fileHash, validators)
+
+	fileHash[31] = 1
+	_, err := verifier.VerifyBlock(proof, fileHash, signatures, set)
+	if !errors.Is(err, verifier.ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+func TestVerifyBlockUnknownValidator(t *testing.T) {
+	validators, set := prepareValidators(40, 30, 20, 10)
+	outsiders, _ := prepareValidators(100)
+	proof := prepareProof()
+	fileHash := make([]byte, 32)
+
+	_, err := verifier.VerifyBlock(proof, fileHash, sign(proof, fileHash, append(validators, outsiders...)), set)
+	if !errors.Is(err, verifier.ErrUnknownValidator) {
+		t.Fatalf("expected ErrUnknownValidator, got %v", err)
+	}
+}
+
+func TestVerifyBlockNotProof(t *testing.T) {
+	_, set := prepareValidators(1)
+	_, err := verifier.VerifyBlock(cell.BeginCell().EndCell(), make([]byte, 32), cell.NewDict(256), set)
+	if !errors.Is(err, verifier.ErrNotMerkleProof) {
+		t.Fatalf("expected ErrNotMerkleProof, got %v", err)
+	}
+}
+
+func prepareKeyBlockProof(seqno uint32, set *cell.Cell) *cell.Cell {
+	block := &blocktest.Block{Workchain: -1, Seqno: seqno, Config: map[uint32]*cell.Cell{34: set}}
+	proof, err := blockutils.BuildBlockProof(block.BOC())
+	if err != nil {
+		panic(err)
+	}
+	return proof
+}
+
+func TestVerifyKeyBlockEpochHash(t *testing.T) {
+	validators, set := prepareValidators(40, 30, 20, 10)
+	fileHash := make([]byte, 32)
+
+	var epochHashes [][]byte
+	for i, weights := range [][]uint64{{40, 30, 20, 10}, {50, 50}} {
+		nextSet := blocktest.ValidatorSet(uint32(1000*i), blocktest.NewValidators(weights...))
+		proof := prepareKeyBlockProof(uint32(100*(i+1)), nextSet)
+
+		res, err := verifier.VerifyKeyBlock(proof, fileHash, sign(proof, fileHash, validators), set)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !bytes.Equal(res.EpochHash, nextSet.Hash()) {
+			t.Fatalf("epoch hash %x is not the hash of the validator set %x", res.EpochHash, nextSet.Hash())
+		}
+		epochHashes = append(epochHashes, res.EpochHash)
+	}
+	if bytes.Equal(epochHashes[0], epochHashes[1]) {
+		t.Fatalf("different validator sets have the same epoch hash %x", epochHashes[0])
+	}
+}
+
+func TestVerifyKeyBlockNotKeyBlock(t *testing.T) {
+	validators, set := prepareValidators(40, 30, 20, 10)
+	proof, err := blockutils.BuildBlockProof((&blocktest.Block{Workchain: -1, Seqno: 100}).BOC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileHash := make([]byte, 32)
+
+	_, err = verifier.VerifyKeyBlock(proof, fileHash, sign(proof, fileHash, validators), set)
+	if !errors.Is(err, verifier.ErrNotKeyBlock) {
+		t.Fatalf("expected ErrNotKeyBlock, got %v", err)
+	}
+}
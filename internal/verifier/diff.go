@@ -0,0 +1,53 @@
+package verifier
+
+import (
+	"bytes"
+	"sort"
+)
+
+// SetDiff is the difference between the validator set of a LiteClient and another set.
+type SetDiff struct {
+	// Added are the validators that are only in the other set.
+	Added [][32]byte
+	// Removed are the validators that are only in the set of the LiteClient.
+	Removed [][32]byte
+	// Reweighted are the validators of both sets with different weights.
+	Reweighted [][32]byte
+	// OverlapWeight is the weight of the validators of both sets in the set of the LiteClient.
+	OverlapWeight uint64
+	TotalWeight   uint64
+}
+
+// OverlapHolds reports whether the validators of both sets hold more than 2/3
+// of the weight of the LiteClient set, so they can sign blocks the LiteClient accepts.
+func (d *SetDiff) OverlapHolds() bool {
+	return 3*d.OverlapWeight > 2*d.TotalWeight
+}
+
+// DiffValidatorSets compares the set of a LiteClient with another set. The keys are sorted.
+func DiffValidatorSets(liteClient, other *ValidatorSet) *SetDiff {
+	diff := &SetDiff{TotalWeight: liteClient.TotalWeight}
+	for key, weight := range liteClient.Weights {
+		otherWeight, ok := other.Weights[key]
+		switch {
+		case !ok:
+			diff.Removed = append(diff.Removed, key)
+			continue
+		case otherWeight != weight:
+			diff.Reweighted = append(diff.Reweighted, key)
+		}
+		diff.OverlapWeight += weight
+	}
+	for key := range other.Weights {
+		if _, ok := liteClient.Weights[key]; !ok {
+			diff.Added = append(diff.Added, key)
+		}
+	}
+
+	for _, keys := range [][][32]byte{diff.Added, diff.Removed, diff.Reweighted} {
+		sort.Slice(keys, func(i, j int) bool {
+			return bytes.Compare(keys[i][:], keys[j][:]) < 0
+		})
+	}
+	return diff
+}
@@ -0,0 +1,40 @@
+package verifier_test
+
+import (
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/verifier"
+)
+
+func TestDiffValidatorSets(t *testing.T) {
+	validators, liteClient := prepareValidators(40, 30, 20, 10)
+	other := &verifier.ValidatorSet{Weights: map[[32]byte]uint64{}}
+	for i, v := range validators[:3] {
+		var key [32]byte
+		copy(key[:], v.pub)
+		other.Weights[key] = liteClient.Weights[key]
+		if i == 2 {
+			other.Weights[key] = 25
+		}
+	}
+	added := [32]byte{1}
+	other.Weights[added] = 5
+
+	diff := verifier.DiffValidatorSets(liteClient, other)
+	if len(diff.Added) != 1 || diff.Added[0] != added {
+		t.Fatalf("unexpected added validators: %x", diff.Added)
+	}
+	if len(diff.Removed) != 1 || len(diff.Reweighted) != 1 {
+		t.Fatalf("unexpected removed %x and reweighted %x validators", diff.Removed, diff.Reweighted)
+	}
+	if diff.OverlapWeight != 90 || !diff.OverlapHolds() {
+		t.Fatalf("unexpected overlap weight %d/%d", diff.OverlapWeight, diff.TotalWeight)
+	}
+
+	var key [32]byte
+	copy(key[:], validators[1].pub)
+	delete(other.Weights, key)
+	if diff = verifier.DiffValidatorSets(liteClient, other); diff.OverlapHolds() {
+		t.Fatalf("overlap weight %d/%d must not hold", diff.OverlapWeight, diff.TotalWeight)
+	}
+}
@@ -0,0 +1,190 @@
+package verifier
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+// Strategy selects which of the collected block signatures are sent to the LiteClient.
+type Strategy string
+
+const (
+	// StrategyMinimalByWeight takes the heaviest signers until the signed weight exceeds 2/3.
+	// It is the default.
+	StrategyMinimalByWeight Strategy = "minimal-by-weight"
+	// StrategyMinimalByCount takes as many signatures as StrategyMinimalByWeight, but replaces
+	// heavy signers with lighter ones, so the signed weight stays closest to 2/3.
+	StrategyMinimalByCount Strategy = "minimal-by-count"
+	// StrategyAll takes the signatures of all validators of the set.
+	StrategyAll Strategy = "all"
+	// StrategyThreshold takes the heaviest signers until the signed weight exceeds 2/3
+	// plus a margin, so the block is still accepted if some signers are not in the set of the LiteClient.
+	StrategyThreshold Strategy = "threshold"
+)
+
+// Strategies are the names of the supported strategies.
+var Strategies = []Strategy{StrategyMinimalByWeight, StrategyMinimalByCount, StrategyAll, StrategyThreshold}
+
+var (
+	ErrUnknownStrategy = errors.New("unknown signature selection strategy")
+	ErrInvalidMargin   = errors.New("signature margin must be at least 0 and less than 33.3 percent")
+)
+
+// ParseStrategy returns the strategy with the given name.
+func ParseStrategy(name string) (Strategy, error) {
+	for _, s := range Strategies {
+		if string(s) == name {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownStrategy, name)
+}
+
+// Selection is the set of signatures chosen by a strategy.
+type Selection struct {
+	Signatures   map[[32]byte][]byte
+	SignedWeight uint64
+	TotalWeight  uint64
+	// Signers is the number of signatures of validators of the set, the most a strategy can select.
+	Signers int
+}
+
+// Margin returns the signed weight above 2/3 of the total weight, in percent of the total weight.
+func (s *Selection) Margin() float64 {
+	if s.TotalWeight == 0 {
+		return 0
+	}
+	return (float64(s.SignedWeight) - float64(s.TotalWeight)*2/3) * 100 / float64(s.TotalWeight)
+}
+
+type signer struct {
+	key       [32]byte
+	weight    uint64
+	signature []byte
+}
+
+// SelectSignatures matches the signatures to the validators by their short node id and selects them
+// with the strategy. margin is the extra weight in percent of the total weight required by
+// StrategyThreshold; if the signers do not reach it, all signatures are selected.
+// It fails if all signatures together do not exceed 2/3 of the total weight.
+func SelectSignatures(
+	validators []*tlb.ValidatorAddr,
+	signatures []ton.Signature,
+	strategy Strategy,
+	margin float64,
+) (*Selection, error) {
+	var totalWeight uint64
+	validatorsMap := make(map[string]*tlb.ValidatorAddr)
+
+	for _, validator := range validators {
+		kid, err := tl.Hash(adnl.PublicKeyED25519{Key: validator.PublicKey.Key})
+		if err != nil {
+			return nil, err
+		}
+		validatorsMap[string(kid)] = validator
+		totalWeight += validator.Weight
+	}
+
+	var signers []signer
+	var availableWeight uint64
+	for _, s := range signatures {
+		v, ok := validatorsMap[string(s.NodeIDShort)]
+		if !ok {
+			continue
+		}
+		delete(validatorsMap, string(s.NodeIDShort))
+
+		var key [32]byte
+		copy(key[:], v.PublicKey.Key)
+		signers = append(signers, signer{key: key, weight: v.Weight, signature: s.Signature})
+		availableWeight += v.Weight
+	}
+
+	if 3*availableWeight <= 2*totalWeight {
+		return nil, fmt.Errorf("%w: %d/%d", ErrInsufficientWeight, 3*availableWeight, 2*totalWeight)
+	}
+
+	sort.SliceStable(signers, func(i, j int) bool {
+		return signers[i].weight > signers[j].weight
+	})
+
+	var selected []signer
+	switch strategy {
+	case StrategyMinimalByWeight, "":
+		selected = heaviestAbove(signers, 2*totalWeight)
+	case StrategyMinimalByCount:
+		selected = lightenAbove(heaviestAbove(signers, 2*totalWeight), signers, 2*totalWeight)
+	case StrategyAll:
+		selected = signers
+	case StrategyThreshold:
+		if margin < 0 || margin >= 100.0/3 {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMargin, margin)
+		}
+		required := 2*totalWeight + uint64(3*float64(totalWeight)*margin/100)
+		selected = heaviestAbove(signers, required)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStrategy, strategy)
+	}
+
+	selection := &Selection{
+		Signatures:  make(map[[32]byte][]byte, len(selected)),
+		TotalWeight: totalWeight,
+		Signers:     len(signers),
+	}
+	for _, s := range selected {
+		selection.Signatures[s.key] = s.signature
+		selection.SignedWeight += s.weight
+	}
+	return selection, nil
+}
+
+// heaviestAbove takes the signers, sorted by weight in descending order, until three times
+// the signed weight exceeds required. If it is never exceeded, all signers are taken.
+func heaviestAbove(signers []signer, required uint64) []signer {
+	var signedWeight uint64
+	for i, s := range signers {
+		signedWeight += s.weight
+		if 3*signedWeight > required {
+			return signers[:i+1]
+		}
+	}
+	return signers
+}
+
+// lightenAbove replaces the selected signers with lighter ones that are not selected,
+// as long as three times the signed weight still exceeds required.
+func lightenAbove(selected, signers []signer, required uint64) []signer {
+	result := append([]signer(nil), selected...)
+	used := make(map[[32]byte]bool, len(result))
+	var signedWeight uint64
+	for _, s := range result {
+		used[s.key] = true
+		signedWeight += s.weight
+	}
+
+	// The signers are sorted by weight in descending order, so the lightest candidates are tried first.
+	for i := range result {
+		for j := len(signers) - 1; j >= 0; j-- {
+			candidate := signers[j]
+			if used[candidate.key] || candidate.weight >= result[i].weight {
+				continue
+			}
+			weight := signedWeight - result[i].weight + candidate.weight
+			if 3*weight <= required {
+				continue
+			}
+			used[result[i].key] = false
+			used[candidate.key] = true
+			result[i] = candidate
+			signedWeight = weight
+			break
+		}
+	}
+	return result
+}
@@ -0,0 +1,95 @@
+package verifier_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rsquad/trustless-bridge-cli/internal/verifier"
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+func prepareSigners(weights ...uint64) ([]*tlb.ValidatorAddr, []ton.Signature) {
+	validators, _ := prepareValidators(weights...)
+	addrs := make([]*tlb.ValidatorAddr, len(validators))
+	signatures := make([]ton.Signature, len(validators))
+	for i, v := range validators {
+		addrs[i] = &tlb.ValidatorAddr{PublicKey: tlb.SigPubKeyED25519{Key: v.pub}, Weight: weights[i]}
+		kid, err := tl.Hash(adnl.PublicKeyED25519{Key: v.pub})
+		if err != nil {
+			panic(err)
+		}
+		signatures[i] = ton.Signature{NodeIDShort: kid, Signature: make([]byte, 64)}
+	}
+	return addrs, signatures
+}
+
+func TestSelectSignatures(t *testing.T) {
+	validators, signatures := prepareSigners(40, 30, 29, 1)
+
+	tests := []struct {
+		strategy verifier.Strategy
+		count    int
+		weight   uint64
+	}{
+		{verifier.StrategyMinimalByWeight, 2, 70},
+		{verifier.StrategyMinimalByCount, 2, 69},
+		{verifier.StrategyAll, 4, 100},
+		{verifier.StrategyThreshold, 3, 99},
+	}
+	for _, tt := range tests {
+		sel, err := verifier.SelectSignatures(validators, signatures, tt.strategy, 10)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.strategy, err)
+		}
+		if len(sel.Signatures) != tt.count || sel.SignedWeight != tt.weight || sel.TotalWeight != 100 {
+			t.Fatalf("%s: unexpected selection of %d signatures with weight %d/%d",
+				tt.strategy, len(sel.Signatures), sel.SignedWeight, sel.TotalWeight)
+		}
+	}
+}
+
+func TestSelectSignaturesThresholdNotReached(t *testing.T) {
+	validators, signatures := prepareSigners(40, 30, 29, 1)
+
+	// the margin is reached before the last signer
+	sel, err := verifier.SelectSignatures(validators, signatures, verifier.StrategyThreshold, 30)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sel.Signatures) != 3 || sel.Signers != 4 || sel.Margin() <= 30 {
+		t.Fatalf("unexpected selection of %d of %d signatures with margin %.2f", len(sel.Signatures), sel.Signers, sel.Margin())
+	}
+
+	// the signers of the set do not reach the margin, so all of them are selected
+	sel, err = verifier.SelectSignatures(validators, signatures[:3], verifier.StrategyThreshold, 33)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sel.Signatures) != 3 || sel.Signers != 3 || sel.Margin() > 33 {
+		t.Fatalf("unexpected selection of %d of %d signatures with margin %.2f", len(sel.Signatures), sel.Signers, sel.Margin())
+	}
+}
+
+func TestSelectSignaturesInsufficientWeight(t *testing.T) {
+	validators, signatures := prepareSigners(40, 30, 29, 1)
+
+	_, err := verifier.SelectSignatures(validators, signatures[1:2], verifier.StrategyAll, 0)
+	if !errors.Is(err, verifier.ErrInsufficientWeight) {
+		t.Fatalf("expected ErrInsufficientWeight, got %v", err)
+	}
+}
+
+func TestSelectSignaturesInvalidOptions(t *testing.T) {
+	validators, signatures := prepareSigners(40, 30, 29, 1)
+
+	if _, err := verifier.ParseStrategy("fastest"); !errors.Is(err, verifier.ErrUnknownStrategy) {
+		t.Fatalf("expected ErrUnknownStrategy, got %v", err)
+	}
+	_, err := verifier.SelectSignatures(validators, signatures, verifier.StrategyThreshold, 40)
+	if !errors.Is(err, verifier.ErrInvalidMargin) {
+		t.Fatalf("expected ErrInvalidMargin, got %v", err)
+	}
+}
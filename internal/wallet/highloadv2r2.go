@@ -62,7 +62,10 @@ func (s *SpecHighloadV2R2) BuildMessage(ctx context.Context, messages []*Message
 		MustStoreUInt(boundedID, 64).
 		MustStoreDict(dict)
 
-	sign := payload.EndCell().Sign(s.wallet.key)
+	sign, err := s.wallet.sign(ctx, payload.EndCell())
+	if err != nil {
+		return nil, err
+	}
 	msg := cell.BeginCell().MustStoreSlice(sign, 512).MustStoreBuilder(payload).EndCell()
 
 	return msg, nil
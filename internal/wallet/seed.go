@@ -66,6 +66,15 @@ func FromSeed(api TonAPI, seed []string, version VersionConfig, wc byte) (*Walle
 }
 
 func FromSeedWithPassword(api TonAPI, seed []string, password string, version VersionConfig, wc byte) (*Wallet, error) {
+	key, err := SeedToPrivateKey(seed, password)
+	if err != nil {
+		return nil, err
+	}
+	return FromPrivateKey(api, key, version, wc)
+}
+
+// SeedToPrivateKey validates the seed and derives the private key of the wallet from it.
+func SeedToPrivateKey(seed []string, password string) (ed25519.PrivateKey, error) {
 	// validate seed
 	if len(seed) < 12 {
 		return nil, fmt.Errorf("seed should have at least 12 words")
@@ -93,8 +102,7 @@ func FromSeedWithPassword(api TonAPI, seed []string, password string, version Ve
 	}
 
 	k := pbkdf2.Key(hash, []byte(_Salt), _Iterations, 32, sha512.New)
-
-	return FromPrivateKey(api, ed25519.NewKeyFromSeed(k), version, wc)
+	return ed25519.NewKeyFromSeed(k), nil
 }
 
 var wordsArr = func() []string {
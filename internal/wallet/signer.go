@@ -0,0 +1,47 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Signer signs the hashes of the external messages of a wallet.
+// It allows the private key to be kept outside the process.
+type Signer interface {
+	PublicKey() ed25519.PublicKey
+	Sign(ctx context.Context, hash []byte) ([]byte, error)
+}
+
+// KeySigner signs with a private key held in memory.
+type KeySigner struct {
+	key ed25519.PrivateKey
+}
+
+func NewKeySigner(key ed25519.PrivateKey) *KeySigner {
+	return &KeySigner{key: key}
+}
+
+func (s *KeySigner) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+func (s *KeySigner) Sign(_ context.Context, hash []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, hash), nil
+}
+
+// sign signs the hash of the cell. The signature is verified, so that a faulty
+// signer fails here and not in the wallet contract.
+func (w *Wallet) sign(ctx context.Context, c *cell.Cell) ([]byte, error) {
+	signature, err := w.signer.Sign(ctx, c.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message: %w", err)
+	}
+	if len(signature) != ed25519.SignatureSize || !ed25519.Verify(w.signer.PublicKey(), c.Hash(), signature) {
+		return nil, errors.New("failed to sign message: invalid signature")
+	}
+	return signature, nil
+}
@@ -85,7 +85,10 @@ func (s *SpecV5R1Final) BuildMessage(ctx context.Context, _ bool, _ *ton.BlockID
 		MustStoreUInt(uint64(seq), 32).                                                                   // seq (block)
 		MustStoreBuilder(actions)                                                                         // Action list
 
-	sign := payload.EndCell().Sign(s.wallet.key)
+	sign, err := s.wallet.sign(ctx, payload.EndCell())
+	if err != nil {
+		return nil, err
+	}
 	msg := cell.BeginCell().MustStoreBuilder(payload).MustStoreSlice(sign, 512).EndCell()
 
 	return msg, nil
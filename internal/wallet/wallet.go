@@ -144,10 +144,10 @@ type Message struct {
 }
 
 type Wallet struct {
-	api  TonAPI
-	key  ed25519.PrivateKey
-	addr *address.Address
-	ver  VersionConfig
+	api    TonAPI
+	signer Signer
+	addr   *address.Address
+	ver    VersionConfig
 
 	// Can be used to operate multiple wallets with the same key and version.
 	// use GetSubwallet if you need it.
@@ -158,6 +158,11 @@ type Wallet struct {
 }
 
 func FromPrivateKey(api TonAPI, key ed25519.PrivateKey, version VersionConfig, wc byte) (*Wallet, error) {
+	return FromSigner(api, NewKeySigner(key), version, wc)
+}
+
+// FromSigner creates a wallet whose messages are signed by the signer.
+func FromSigner(api TonAPI, signer Signer, version VersionConfig, wc byte) (*Wallet, error) {
 	var subwallet uint32 = DefaultSubwallet
 
 	// default subwallet depends on wallet type
@@ -167,14 +172,14 @@ func FromPrivateKey(api TonAPI, key ed25519.PrivateKey, version VersionConfig, w
 		subwallet = 0
 	}
 
-	addr, err := AddressFromPubKey(key.Public().(ed25519.PublicKey), version, subwallet, wc)
+	addr, err := AddressFromPubKey(signer.PublicKey(), version, subwallet, wc)
 	if err != nil {
 		return nil, err
 	}
 
 	w := &Wallet{
 		api:       api,
-		key:       key,
+		signer:    signer,
 		addr:      addr,
 		ver:       version,
 		subwallet: subwallet,
@@ -265,19 +270,27 @@ func (w *Wallet) WalletAddress() *address.Address {
 	return w.addr.Bounce(false)
 }
 
+// PrivateKey returns the private key of the wallet, or nil if the key is not held in memory.
 func (w *Wallet) PrivateKey() ed25519.PrivateKey {
-	return w.key
+	if s, ok := w.signer.(*KeySigner); ok {
+		return s.key
+	}
+	return nil
+}
+
+func (w *Wallet) PublicKey() ed25519.PublicKey {
+	return w.signer.PublicKey()
 }
 
 func (w *Wallet) GetSubwallet(subwallet uint32) (*Wallet, error) {
-	addr, err := AddressFromPubKey(w.key.Public().(ed25519.PublicKey), w.ver, subwallet, 0)
+	addr, err := AddressFromPubKey(w.signer.PublicKey(), w.ver, subwallet, 0)
 	if err != nil {
 		return nil, err
 	}
 
 	sub := &Wallet{
 		api:       w.api,
-		key:       w.key,
+		signer:    w.signer,
 		addr:      addr,
 		ver:       w.ver,
 		subwallet: subwallet,
@@ -341,7 +354,7 @@ func (w *Wallet) BuildExternalMessageForMany(ctx context.Context, messages []*Me
 func (w *Wallet) PrepareExternalMessageForMany(ctx context.Context, withStateInit bool, messages []*Message) (_ *tlb.ExternalMessage, err error) {
 	var stateInit *tlb.StateInit
 	if withStateInit {
-		stateInit, err = GetStateInit(w.key.Public().(ed25519.PublicKey), w.ver, w.subwallet)
+		stateInit, err = GetStateInit(w.signer.PublicKey(), w.ver, w.subwallet)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get state init: %w", err)
 		}
@@ -418,12 +431,16 @@ func (w *Wallet) BuildTransfer(to *address.Address, amount tlb.Coins, bounce boo
 func (w *Wallet) BuildTransferEncrypted(ctx context.Context, to *address.Address, amount tlb.Coins, bounce bool, comment string) (_ *Message, err error) {
 	var body *cell.Cell
 	if comment != "" {
+		if w.PrivateKey() == nil {
+			return nil, errors.New("encrypted comments require the private key of the wallet")
+		}
+
 		key, err := GetPublicKey(ctx, w.api, to)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get destination contract (wallet) public key")
 		}
 
-		body, err = CreateEncryptedCommentCell(comment, w.WalletAddress(), w.key, key)
+		body, err = CreateEncryptedCommentCell(comment, w.WalletAddress(), w.PrivateKey(), key)
 		if err != nil {
 			return nil, err
 		}